@@ -0,0 +1,293 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"fmt"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	sdk "github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/programs"
+	"github.com/onflow/flow-go/fvm/state"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/rs/zerolog"
+
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+)
+
+const setBalanceTransaction = `
+import FungibleToken from 0x%s
+import FlowToken from 0x%s
+
+transaction(balance: UFix64) {
+    prepare(%s) {%s
+        let vault = account.borrow<&FlowToken.Vault>(from: /storage/flowTokenVault)
+            ?? panic("account has no FLOW vault")
+
+        if vault.balance > balance {
+            let amount = vault.balance - balance
+            destroy vault.withdraw(amount: amount)
+        } else if vault.balance < balance {
+            let amount = balance - vault.balance
+            let admin = service.borrow<&FlowToken.Administrator>(from: /storage/flowTokenAdmin)
+                ?? panic("service account has no FLOW administrator")
+            let minter <- admin.createNewMinter(allowedAmount: amount)
+            vault.deposit(from: <-minter.mintTokens(amount: amount))
+            destroy minter
+        }
+    }
+}
+`
+
+// removeStorageStatements removes the value stored at the path, destroying it if it is a resource.
+const removeStorageStatements = `
+        if account.borrow<&AnyResource>(from: path) != nil {
+            destroy account.load<@AnyResource>(from: path)
+        } else {
+            account.load<AnyStruct>(from: path)
+        }
+`
+
+const setStorageTransaction = `
+transaction(path: StoragePath, value: AnyStruct) {
+    prepare(account: AuthAccount) {` + removeStorageStatements + `
+        account.save(value, to: path)
+    }
+}
+`
+
+const removeStorageTransaction = `
+transaction(path: StoragePath) {
+    prepare(account: AuthAccount) {` + removeStorageStatements + `
+    }
+}
+`
+
+// SetAccountBalance sets the FLOW balance of an account, minting or burning the difference.
+//
+// Like all direct account state edits, the change is applied to the pending block, which must
+// not contain transactions, and committed as a block without transactions.
+func (b *Blockchain) SetAccountBalance(address sdk.Address, balance cadence.UFix64) (*flowgo.Block, error) {
+	b.lock()
+	defer b.unlock()
+
+	flowAddress := sdkconvert.SDKAddressToFlow(address)
+	serviceAddress := b.vmCtx.Chain.ServiceAddress()
+
+	authorizers := []flowgo.Address{serviceAddress, flowAddress}
+	parameters := "service: AuthAccount, account: AuthAccount"
+	prelude := ""
+
+	// the service account can only authorize the transaction once
+	if flowAddress == serviceAddress {
+		authorizers = []flowgo.Address{serviceAddress}
+		parameters = "service: AuthAccount"
+		prelude = "\n        let account = service"
+	}
+
+	script := fmt.Sprintf(
+		setBalanceTransaction,
+		fvm.FungibleTokenAddress(b.vmCtx.Chain).Hex(),
+		fvm.FlowTokenAddress(b.vmCtx.Chain).Hex(),
+		parameters,
+		prelude,
+	)
+
+	return b.editAccountState(flowAddress, func(view state.View) ([]flowgo.Event, error) {
+		return b.executeSyntheticTransaction(view, script, []cadence.Value{balance}, authorizers...)
+	})
+}
+
+// AddAccountKey adds a public key to an account.
+func (b *Blockchain) AddAccountKey(address sdk.Address, key *sdk.AccountKey) (*flowgo.Block, error) {
+	b.lock()
+	defer b.unlock()
+
+	flowAddress := sdkconvert.SDKAddressToFlow(address)
+
+	publicKey, err := sdkconvert.SDKAccountKeyToFlow(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.editAccountState(flowAddress, func(view state.View) ([]flowgo.Event, error) {
+		return nil, newAccounts(view).AppendPublicKey(flowAddress, publicKey)
+	})
+}
+
+// RevokeAccountKey revokes the public key of an account with the given index.
+func (b *Blockchain) RevokeAccountKey(address sdk.Address, keyIndex int) (*flowgo.Block, error) {
+	b.lock()
+	defer b.unlock()
+
+	flowAddress := sdkconvert.SDKAddressToFlow(address)
+
+	return b.editAccountState(flowAddress, func(view state.View) ([]flowgo.Event, error) {
+		accounts := newAccounts(view)
+
+		count, err := accounts.GetPublicKeyCount(flowAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		if keyIndex < 0 || uint64(keyIndex) >= count {
+			return nil, &AccountKeyNotFoundError{Address: flowAddress, KeyIndex: keyIndex}
+		}
+
+		publicKey, err := accounts.GetPublicKey(flowAddress, uint64(keyIndex))
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey.Revoked = true
+
+		_, err = accounts.SetPublicKey(flowAddress, uint64(keyIndex), publicKey)
+		return nil, err
+	})
+}
+
+// SetAccountStorage writes a value to a storage path of an account, replacing the stored value.
+func (b *Blockchain) SetAccountStorage(address sdk.Address, path cadence.Path, value cadence.Value) (*flowgo.Block, error) {
+	b.lock()
+	defer b.unlock()
+
+	flowAddress := sdkconvert.SDKAddressToFlow(address)
+
+	return b.editAccountState(flowAddress, func(view state.View) ([]flowgo.Event, error) {
+		return b.executeSyntheticTransaction(
+			view,
+			setStorageTransaction,
+			[]cadence.Value{path, value},
+			flowAddress,
+		)
+	})
+}
+
+// RemoveAccountStorage removes the value stored at a storage path of an account.
+func (b *Blockchain) RemoveAccountStorage(address sdk.Address, path cadence.Path) (*flowgo.Block, error) {
+	b.lock()
+	defer b.unlock()
+
+	flowAddress := sdkconvert.SDKAddressToFlow(address)
+
+	return b.editAccountState(flowAddress, func(view state.View) ([]flowgo.Event, error) {
+		return b.executeSyntheticTransaction(
+			view,
+			removeStorageTransaction,
+			[]cadence.Value{path},
+			flowAddress,
+		)
+	})
+}
+
+// editAccountState applies an edit of an existing account directly to the ledger of the pending block,
+// and commits the pending block, including the events emitted by the edit.
+//
+// The edit is only applied if it succeeds.
+func (b *Blockchain) editAccountState(
+	address flowgo.Address,
+	edit func(view state.View) ([]flowgo.Event, error),
+) (*flowgo.Block, error) {
+	if !b.pendingBlock.Empty() {
+		return nil, &PendingBlockNotEmptyError{BlockID: b.pendingBlock.ID()}
+	}
+
+	view := b.pendingBlock.ledgerView.NewChild()
+
+	exists, err := newAccounts(view).Exists(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, &AccountNotFoundError{Address: address}
+	}
+
+	events, err := edit(view)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.pendingBlock.ledgerView.MergeView(view)
+	if err != nil {
+		return nil, err
+	}
+
+	b.pendingBlock.AddEvents(events)
+
+	return b.commitBlock()
+}
+
+// executeSyntheticTransaction executes a transaction against the given view,
+// without signature verification, sequence number checks or fees, and returns its events.
+//
+// The transaction is executed on behalf of the emulator, so it is not instrumented.
+func (b *Blockchain) executeSyntheticTransaction(
+	view state.View,
+	script string,
+	arguments []cadence.Value,
+	authorizers ...flowgo.Address,
+) ([]flowgo.Event, error) {
+	tx := flowgo.NewTransactionBody().
+		SetScript([]byte(script)).
+		SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+		SetPayer(b.vmCtx.Chain.ServiceAddress())
+
+	for _, argument := range arguments {
+		encoded, err := jsoncdc.Encode(argument)
+		if err != nil {
+			return nil, err
+		}
+
+		tx.AddArgument(encoded)
+	}
+
+	for _, authorizer := range authorizers {
+		tx.AddAuthorizer(authorizer)
+	}
+
+	ctx := fvm.NewContextFromParent(
+		b.vmCtx,
+		fvm.WithBlockHeader(b.pendingBlock.Block().Header),
+		fvm.WithTransactionProcessors(fvm.NewTransactionInvoker(zerolog.Nop())),
+		fvm.WithTransactionFeesEnabled(false),
+		fvm.WithAccountStorageLimit(false),
+	)
+
+	proc := fvm.Transaction(tx, 0)
+
+	err := b.uninstrumentedVM.Run(ctx, proc, view, programs.NewEmptyPrograms())
+	if err != nil {
+		return nil, err
+	}
+
+	if proc.Err != nil {
+		return nil, &ExecutionError{
+			Code:    int(proc.Err.Code()),
+			Message: proc.Err.Error(),
+		}
+	}
+
+	return proc.Events, nil
+}
+
+func newAccounts(view state.View) *state.StatefulAccounts {
+	return state.NewAccounts(state.NewStateHolder(state.NewState(view)))
+}
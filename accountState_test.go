@@ -0,0 +1,232 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/test"
+	"github.com/onflow/flow-go/fvm"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+func TestAccountStateEditing(t *testing.T) {
+
+	t.Parallel()
+
+	accountKeys := test.AccountKeyGenerator()
+
+	setup := func(t *testing.T) (*emulator.Blockchain, flow.Address) {
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		address, err := b.CreateAccount([]*flow.AccountKey{accountKeys.New()}, nil)
+		require.NoError(t, err)
+
+		return b, address
+	}
+
+	getBalance := func(t *testing.T, b *emulator.Blockchain, address flow.Address) cadence.Value {
+		script := fmt.Sprintf(`pub fun main(): UFix64 { return getAccount(0x%s).balance }`, address.Hex())
+
+		result, err := b.ExecuteScript([]byte(script), nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Error)
+
+		return result.Value
+	}
+
+	t.Run("should set balance", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, address := setup(t)
+
+		for _, value := range []string{"1234.5", "0.25", "0.0"} {
+			balance, err := cadence.NewUFix64(value)
+			require.NoError(t, err)
+
+			block, err := b.SetAccountBalance(address, balance)
+			require.NoError(t, err)
+
+			latestBlock, err := b.GetLatestBlock()
+			require.NoError(t, err)
+			assert.Equal(t, block.ID(), latestBlock.ID())
+			assert.Empty(t, block.Payload.Guarantees)
+
+			assert.Equal(t, balance, getBalance(t, b, address))
+		}
+	})
+
+	t.Run("should record events of balance changes", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, address := setup(t)
+
+		balance, err := cadence.NewUFix64("1234.5")
+		require.NoError(t, err)
+
+		block, err := b.SetAccountBalance(address, balance)
+		require.NoError(t, err)
+
+		eventType := fmt.Sprintf("A.%s.FlowToken.TokensMinted", fvm.FlowTokenAddress(flowgo.Emulator.Chain()).Hex())
+
+		events, err := b.GetEventsByHeight(block.Header.Height, eventType)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+
+		assert.Equal(t, eventType, events[0].Type)
+	})
+
+	t.Run("should set balance of service account", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, _ := setup(t)
+
+		balance, err := cadence.NewUFix64("42.0")
+		require.NoError(t, err)
+
+		_, err = b.SetAccountBalance(b.ServiceKey().Address, balance)
+		require.NoError(t, err)
+
+		assert.Equal(t, balance, getBalance(t, b, b.ServiceKey().Address))
+	})
+
+	t.Run("should add and revoke keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, address := setup(t)
+
+		key := accountKeys.New()
+
+		_, err := b.AddAccountKey(address, key)
+		require.NoError(t, err)
+
+		account, err := b.GetAccount(address)
+		require.NoError(t, err)
+		require.Len(t, account.Keys, 2)
+		assert.Equal(t, key.PublicKey, account.Keys[1].PublicKey)
+		assert.False(t, account.Keys[1].Revoked)
+
+		_, err = b.RevokeAccountKey(address, 1)
+		require.NoError(t, err)
+
+		account, err = b.GetAccount(address)
+		require.NoError(t, err)
+		assert.True(t, account.Keys[1].Revoked)
+
+		_, err = b.RevokeAccountKey(address, 2)
+		assert.IsType(t, &emulator.AccountKeyNotFoundError{}, err)
+	})
+
+	t.Run("should set and remove storage", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, address := setup(t)
+
+		path := cadence.Path{Domain: "storage", Identifier: "answer"}
+
+		checkStored := func(t *testing.T, expected string) {
+			tx := flow.NewTransaction().
+				SetScript([]byte(fmt.Sprintf(`
+					transaction {
+						prepare(signer: AuthAccount) {
+							assert(signer.copy<Int>(from: /storage/answer) == %s)
+						}
+					}
+				`, expected))).
+				SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+				SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+				SetPayer(b.ServiceKey().Address).
+				AddAuthorizer(address)
+
+			b.Impersonate(address)
+			defer b.StopImpersonating(address)
+
+			err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+			require.NoError(t, err)
+
+			err = b.AddTransaction(*tx)
+			require.NoError(t, err)
+
+			result, err := b.ExecuteNextTransaction()
+			require.NoError(t, err)
+			assertTransactionSucceeded(t, result)
+
+			_, err = b.CommitBlock()
+			require.NoError(t, err)
+		}
+
+		_, err := b.SetAccountStorage(address, path, cadence.NewInt(42))
+		require.NoError(t, err)
+		checkStored(t, "42")
+
+		_, err = b.SetAccountStorage(address, path, cadence.NewInt(43))
+		require.NoError(t, err)
+		checkStored(t, "43")
+
+		_, err = b.RemoveAccountStorage(address, path)
+		require.NoError(t, err)
+		checkStored(t, "nil")
+	})
+
+	t.Run("should fail for non-existent account", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, _ := setup(t)
+
+		_, err := b.AddAccountKey(flow.HexToAddress("ffffffffffffffff"), accountKeys.New())
+		assert.IsType(t, &emulator.AccountNotFoundError{}, err)
+	})
+
+	t.Run("should fail if pending block is not empty", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, address := setup(t)
+
+		tx := flow.NewTransaction().
+			SetScript([]byte(`transaction {}`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		_, err = b.AddAccountKey(address, accountKeys.New())
+		assert.IsType(t, &emulator.PendingBlockNotEmptyError{}, err)
+	})
+}
@@ -0,0 +1,252 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/common"
+	sdk "github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/programs"
+	"github.com/onflow/flow-go/fvm/state"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+)
+
+// storageDomains are the path domains of account storage, in the order their paths are returned.
+var storageDomains = []common.PathDomain{
+	common.PathDomainStorage,
+	common.PathDomainPublic,
+	common.PathDomainPrivate,
+}
+
+// storagePathSeparator separates the path domain and identifier in the keys of the registers
+// Cadence stores the value of each path in.
+const storagePathSeparator = "\x1F"
+
+// A StoredValue is a value stored in account storage.
+//
+// The value of a public or private path is the link stored at the path.
+type StoredValue struct {
+	Path  cadence.Path
+	Type  string
+	Value cadence.Value
+}
+
+// GetAccountStorage returns the values stored at all storage, public and private paths of an account
+// at the given block height, ordered by domain and identifier.
+func (b *Blockchain) GetAccountStorage(address sdk.Address, blockHeight uint64) ([]StoredValue, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	block, err := b.getBlockByHeight(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	flowAddress := sdkconvert.SDKAddressToFlow(address)
+
+	// reading storage may allocate storage indices, so read from a child view that is discarded
+	view := b.storage.LedgerViewByHeight(blockHeight).NewChild()
+
+	exists, err := newAccounts(view).Exists(flowAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, &AccountNotFoundError{Address: flowAddress}
+	}
+
+	registers, err := b.storage.RegistersByOwner(flowAddress, blockHeight)
+	if err != nil {
+		return nil, &StorageError{err}
+	}
+
+	paths := storagePaths(registers)
+
+	env := b.storageEnvironment(block, view)
+
+	// storage is read without instrumenting the contracts of stored values
+	rt := runtime.NewInterpreterRuntime()
+
+	values := make([]StoredValue, 0, len(paths))
+
+	for _, path := range paths {
+		value, err := readStored(rt, env, common.Address(flowAddress), path)
+		if err != nil {
+			return nil, err
+		}
+
+		// values are read as optionals, which are nil if nothing is stored at the path
+		if optional, ok := value.(cadence.Optional); ok {
+			value = optional.Value
+		}
+
+		if value == nil {
+			continue
+		}
+
+		values = append(values, StoredValue{
+			Path:  path,
+			Type:  storedValueType(value),
+			Value: value,
+		})
+	}
+
+	return values, nil
+}
+
+// storageEnvironment returns a script environment reading account storage from the given view.
+func (b *Blockchain) storageEnvironment(block *flowgo.Block, view state.View) runtime.Interface {
+	ctx := fvm.NewContextFromParent(b.vmCtx, fvm.WithBlockHeader(block.Header))
+
+	return fvm.NewScriptEnvironment(
+		ctx,
+		b.vm,
+		state.NewStateHolder(state.NewState(view)),
+		programs.NewEmptyPrograms(),
+	)
+}
+
+// readStored returns the value stored at a path of an account.
+//
+// Cadence checks the contracts of stored values without the standard library when reading them,
+// which fails for contracts using functions like panic. Such contracts are checked with the
+// standard library and cached by importing them into an empty script first.
+func readStored(
+	rt runtime.Runtime,
+	env runtime.Interface,
+	address common.Address,
+	path cadence.Path,
+) (cadence.Value, error) {
+	imported := make(map[common.LocationID]struct{})
+
+	for {
+		value, err := rt.ReadStored(
+			address,
+			path,
+			runtime.Context{
+				Interface: env,
+				Location:  common.ScriptLocation{},
+			},
+		)
+
+		var checkingErr *runtime.ParsingCheckingError
+		if err == nil || !errors.As(err, &checkingErr) {
+			return value, err
+		}
+
+		location, ok := checkingErr.Location.(common.AddressLocation)
+		if !ok {
+			return nil, err
+		}
+
+		if _, ok := imported[location.ID()]; ok {
+			return nil, err
+		}
+		imported[location.ID()] = struct{}{}
+
+		script := fmt.Sprintf("import %s from 0x%s\n\npub fun main() {}", location.Name, location.Address.Hex())
+
+		_, importErr := rt.ExecuteScript(
+			runtime.Script{Source: []byte(script)},
+			runtime.Context{
+				Interface: env,
+				Location:  common.ScriptLocation{},
+			},
+		)
+		if importErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// registerStoragePath returns the storage, public or private path whose value is stored
+// in the register with the given key.
+func registerStoragePath(key string) (cadence.Path, bool) {
+	parts := strings.SplitN(key, storagePathSeparator, 2)
+	if len(parts) != 2 {
+		return cadence.Path{}, false
+	}
+
+	for _, domain := range storageDomains {
+		if parts[0] == domain.Identifier() {
+			return cadence.Path{Domain: parts[0], Identifier: parts[1]}, true
+		}
+	}
+
+	return cadence.Path{}, false
+}
+
+// storagePaths returns the paths of the values stored in the given account registers,
+// ordered by domain and identifier.
+func storagePaths(registers []flowgo.RegisterEntry) []cadence.Path {
+	identifiers := make(map[string][]string)
+
+	for _, register := range registers {
+		if len(register.Value) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(register.Key.Key, storagePathSeparator, 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		domain, identifier := parts[0], parts[1]
+		identifiers[domain] = append(identifiers[domain], identifier)
+	}
+
+	var paths []cadence.Path
+
+	for _, domain := range storageDomains {
+		domainIdentifiers := identifiers[domain.Identifier()]
+		sort.Strings(domainIdentifiers)
+
+		for _, identifier := range domainIdentifiers {
+			paths = append(paths, cadence.Path{
+				Domain:     domain.Identifier(),
+				Identifier: identifier,
+			})
+		}
+	}
+
+	return paths
+}
+
+// storedValueType returns the type ID of a stored value, or the borrow type of a link.
+func storedValueType(value cadence.Value) string {
+	if link, ok := value.(cadence.Link); ok {
+		return link.BorrowType
+	}
+
+	typ := value.Type()
+	if typ == nil {
+		return ""
+	}
+
+	return typ.ID()
+}
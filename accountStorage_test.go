@@ -0,0 +1,102 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+func TestGetAccountStorage(t *testing.T) {
+
+	t.Parallel()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+	)
+	require.NoError(t, err)
+
+	address, err := b.CreateAccount([]*flow.AccountKey{test.AccountKeyGenerator().New()}, nil)
+	require.NoError(t, err)
+
+	_, err = b.SetAccountStorage(
+		address,
+		cadence.Path{Domain: "storage", Identifier: "answer"},
+		cadence.NewInt(42),
+	)
+	require.NoError(t, err)
+
+	latestBlock, err := b.GetLatestBlock()
+	require.NoError(t, err)
+
+	t.Run("should list stored values and links", func(t *testing.T) {
+		values, err := b.GetAccountStorage(address, latestBlock.Header.Height)
+		require.NoError(t, err)
+
+		paths := make([]string, len(values))
+		byPath := make(map[string]emulator.StoredValue)
+		for i, value := range values {
+			paths[i] = value.Path.String()
+			byPath[value.Path.String()] = value
+		}
+
+		assert.Equal(t, []string{
+			"/storage/answer",
+			"/storage/flowTokenVault",
+			"/public/flowTokenBalance",
+			"/public/flowTokenReceiver",
+		}, paths)
+
+		answer := byPath["/storage/answer"]
+		assert.Equal(t, "Int", answer.Type)
+		assert.Equal(t, cadence.NewInt(42), answer.Value)
+
+		vault := byPath["/storage/flowTokenVault"]
+		assert.Contains(t, vault.Type, "FlowToken.Vault")
+
+		receiver := byPath["/public/flowTokenReceiver"]
+		assert.IsType(t, cadence.Link{}, receiver.Value)
+		assert.Equal(t, "/storage/flowTokenVault", receiver.Value.(cadence.Link).TargetPath.String())
+	})
+
+	t.Run("should list values at earlier height", func(t *testing.T) {
+		values, err := b.GetAccountStorage(address, latestBlock.Header.Height-1)
+		require.NoError(t, err)
+
+		for _, value := range values {
+			assert.NotEqual(t, "/storage/answer", value.Path.String())
+		}
+	})
+
+	t.Run("should fail for non-existent account", func(t *testing.T) {
+		_, err := b.GetAccountStorage(flow.HexToAddress("ffffffffffffffff"), latestBlock.Header.Height)
+		assert.IsType(t, &emulator.AccountNotFoundError{}, err)
+	})
+
+	t.Run("should fail for non-existent block", func(t *testing.T) {
+		_, err := b.GetAccountStorage(address, latestBlock.Header.Height+1)
+		assert.IsType(t, &emulator.BlockNotFoundByHeightError{}, err)
+	})
+}
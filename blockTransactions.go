@@ -0,0 +1,181 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emulator
+
+import (
+	"errors"
+
+	sdk "github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+	"github.com/onflow/flow-emulator/storage"
+	"github.com/onflow/flow-emulator/types"
+)
+
+// A BlockTransaction is a transaction committed in a block, along with its result.
+type BlockTransaction struct {
+	Transaction *sdk.Transaction
+	Result      *types.TransactionResult
+}
+
+// GetTransactionBlock returns the committed block that includes a transaction,
+// along with the position of the transaction in the block's execution order.
+func (b *Blockchain) GetTransactionBlock(ID sdk.Identifier) (*flowgo.Block, uint32, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	txID := sdkconvert.SDKIdentifierToFlow(ID)
+
+	block, txIDs, err := b.findTransactionBlock(txID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i, id := range txIDs {
+		if id == txID {
+			return block, uint32(i), nil
+		}
+	}
+
+	return nil, 0, &TransactionNotFoundError{ID: txID}
+}
+
+// GetTransactionsByBlockHeight returns the transactions committed in the block at the
+// given height, along with their results, in execution order.
+func (b *Blockchain) GetTransactionsByBlockHeight(height uint64) ([]BlockTransaction, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	block, err := b.getBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	txIDs, err := b.blockTransactionIDs(block)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]BlockTransaction, len(txIDs))
+	for i, txID := range txIDs {
+		tx, err := b.storage.TransactionByID(txID)
+		if err != nil {
+			return nil, &StorageError{err}
+		}
+
+		storedResult, err := b.storage.TransactionResultByID(txID)
+		if err != nil {
+			return nil, &StorageError{err}
+		}
+
+		result, err := storedTransactionResult(txID, storedResult, block)
+		if err != nil {
+			return nil, err
+		}
+
+		sdkTx := sdkconvert.FlowTransactionToSDK(tx)
+
+		txs[i] = BlockTransaction{
+			Transaction: &sdkTx,
+			Result:      result,
+		}
+	}
+
+	return txs, nil
+}
+
+// findTransactionBlock returns the committed block including a transaction,
+// along with the IDs of the block's transactions in execution order.
+func (b *Blockchain) findTransactionBlock(txID flowgo.Identifier) (*flowgo.Block, []flowgo.Identifier, error) {
+	location, err := b.storage.TransactionLocationByID(txID)
+	if err == nil {
+		block, err := b.storage.BlockByHeight(location.BlockHeight)
+		if err != nil {
+			return nil, nil, &StorageError{err}
+		}
+
+		txIDs, err := b.blockTransactionIDs(block)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return block, txIDs, nil
+	}
+
+	if !errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, &StorageError{err}
+	}
+
+	_, err = b.storage.TransactionByID(txID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, &TransactionNotFoundError{ID: txID}
+		}
+
+		return nil, nil, &StorageError{err}
+	}
+
+	latestBlock, err := b.storage.LatestBlock()
+	if err != nil {
+		return nil, nil, &StorageError{err}
+	}
+
+	// transactions persisted before their locations were stored are not indexed,
+	// so search the collections of blocks, starting with the most recent ones
+	for height := latestBlock.Header.Height; height > 0; height-- {
+		block, err := b.storage.BlockByHeight(height)
+		if err != nil {
+			return nil, nil, &StorageError{err}
+		}
+
+		txIDs, err := b.blockTransactionIDs(block)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, id := range txIDs {
+			if id == txID {
+				return block, txIDs, nil
+			}
+		}
+	}
+
+	return nil, nil, &TransactionNotFoundError{ID: txID}
+}
+
+// blockTransactionIDs returns the IDs of the transactions in a block in execution order.
+func (b *Blockchain) blockTransactionIDs(block *flowgo.Block) ([]flowgo.Identifier, error) {
+	txIDs := make([]flowgo.Identifier, 0)
+
+	if block.Payload == nil {
+		return txIDs, nil
+	}
+
+	for _, guarantee := range block.Payload.Guarantees {
+		collection, err := b.storage.CollectionByID(guarantee.CollectionID)
+		if err != nil {
+			return nil, &StorageError{err}
+		}
+
+		txIDs = append(txIDs, collection.Transactions...)
+	}
+
+	return txIDs, nil
+}
@@ -19,15 +19,20 @@
 package emulator_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go-sdk"
 	flowgo "github.com/onflow/flow-go/model/flow"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/storage"
+	"github.com/onflow/flow-emulator/storage/memstore"
 )
 
 func TestCommitBlock(t *testing.T) {
@@ -103,6 +108,72 @@ func TestCommitBlock(t *testing.T) {
 	assert.Error(t, tx2Result.Error)
 }
 
+func TestBlockTransactions(t *testing.T) {
+
+	t.Parallel()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+	)
+	require.NoError(t, err)
+
+	newTransaction := func(script string) *flow.Transaction {
+		tx := flow.NewTransaction().
+			SetScript([]byte(script)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		return tx
+	}
+
+	tx1 := newTransaction(`transaction { execute { log("one") } }`)
+	tx2 := newTransaction(`transaction { execute { panic("revert!") } }`)
+
+	block, _, err := b.ExecuteAndCommitBlock()
+	require.NoError(t, err)
+
+	t.Run("should return block and index of transaction", func(t *testing.T) {
+		txBlock, index, err := b.GetTransactionBlock(tx2.ID())
+		require.NoError(t, err)
+		assert.Equal(t, block.ID(), txBlock.ID())
+		assert.Equal(t, uint32(1), index)
+
+		_, _, err = b.GetTransactionBlock(flow.EmptyID)
+		assert.IsType(t, &emulator.TransactionNotFoundError{}, err)
+	})
+
+	t.Run("should return block of transaction result", func(t *testing.T) {
+		result, err := b.GetTransactionExecutionResult(tx1.ID())
+		require.NoError(t, err)
+		assert.Equal(t, flow.Identifier(block.ID()), result.BlockID)
+		assert.Equal(t, block.Header.Height, result.BlockHeight)
+	})
+
+	t.Run("should list transactions of block in execution order", func(t *testing.T) {
+		txs, err := b.GetTransactionsByBlockHeight(block.Header.Height)
+		require.NoError(t, err)
+		require.Len(t, txs, 2)
+
+		assert.Equal(t, tx1.ID(), txs[0].Transaction.ID())
+		assert.Equal(t, []string{`"one"`}, txs[0].Result.Logs)
+		assert.NoError(t, txs[0].Result.Error)
+
+		assert.Equal(t, tx2.ID(), txs[1].Transaction.ID())
+		assert.Error(t, txs[1].Result.Error)
+		assert.Equal(t, block.Header.Height, txs[1].Result.BlockHeight)
+
+		_, err = b.GetTransactionsByBlockHeight(block.Header.Height + 1)
+		assert.IsType(t, &emulator.BlockNotFoundByHeightError{}, err)
+	})
+}
+
 func TestBlockView(t *testing.T) {
 
 	t.Parallel()
@@ -154,3 +225,219 @@ func TestBlockView(t *testing.T) {
 		})
 	}
 }
+
+func TestRollbackToHeight(t *testing.T) {
+
+	t.Parallel()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+	)
+	require.NoError(t, err)
+
+	addTwoScript, counterAddress := deployAndGenerateAddTwoScript(t, b)
+
+	rollbackBlock, err := b.GetLatestBlock()
+	require.NoError(t, err)
+
+	tx := flow.NewTransaction().
+		SetScript([]byte(addTwoScript)).
+		SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+		SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+		SetPayer(b.ServiceKey().Address).
+		AddAuthorizer(b.ServiceKey().Address)
+
+	err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+	require.NoError(t, err)
+
+	err = b.AddTransaction(*tx)
+	require.NoError(t, err)
+
+	_, _, err = b.ExecuteAndCommitBlock()
+	require.NoError(t, err)
+
+	getCountScript := generateGetCounterCountScript(counterAddress, b.ServiceKey().Address)
+
+	t.Run("should not roll back above latest block", func(t *testing.T) {
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		err = b.RollbackToHeight(latestBlock.Header.Height + 1)
+		assert.IsType(t, &emulator.BlockNotFoundByHeightError{}, err)
+	})
+
+	t.Run("should remove blocks, transactions and state", func(t *testing.T) {
+		err := b.RollbackToHeight(rollbackBlock.Header.Height)
+		require.NoError(t, err)
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+		assert.Equal(t, rollbackBlock.ID(), latestBlock.ID())
+
+		_, err = b.GetBlockByHeight(rollbackBlock.Header.Height + 1)
+		assert.IsType(t, &emulator.BlockNotFoundByHeightError{}, err)
+
+		_, err = b.GetTransaction(tx.ID())
+		assert.IsType(t, &emulator.TransactionNotFoundError{}, err)
+
+		result, err := b.ExecuteScript([]byte(getCountScript), nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Error)
+		assert.Equal(t, cadence.NewInt(0), result.Value)
+	})
+
+	t.Run("should replay transaction after rollback", func(t *testing.T) {
+		err := b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		block, results, err := b.ExecuteAndCommitBlock()
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, results[0])
+		assert.Equal(t, rollbackBlock.Header.Height+1, block.Header.Height)
+
+		result, err := b.ExecuteScript([]byte(getCountScript), nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Error)
+		assert.Equal(t, cadence.NewInt(2), result.Value)
+	})
+}
+
+func TestCommitEmptyBlocks(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("should commit empty blocks", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		block, err := b.CommitEmptyBlocks(1000, 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, latestBlock.Header.Height+1000, block.Header.Height)
+
+		newLatestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+		assert.Equal(t, block.ID(), newLatestBlock.ID())
+
+		// blocks are chained and their timestamps increase
+		parent := latestBlock
+		for height := latestBlock.Header.Height + 1; height <= block.Header.Height; height++ {
+			current, err := b.GetBlockByHeight(height)
+			require.NoError(t, err)
+
+			assert.Equal(t, parent.ID(), current.Header.ParentID)
+			assert.True(t, current.Header.Timestamp.After(parent.Header.Timestamp))
+
+			parent = current
+		}
+
+		next, err := b.CommitBlock()
+		require.NoError(t, err)
+		assert.Equal(t, block.Header.Height+1, next.Header.Height)
+		assert.Equal(t, block.ID(), next.Header.ParentID)
+	})
+
+	t.Run("should space block timestamps by interval", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		first := b.PendingBlockTimestamp()
+
+		block, err := b.CommitEmptyBlocks(10, time.Hour)
+		require.NoError(t, err)
+
+		assert.Equal(t, first.Add(9*time.Hour), block.Header.Timestamp)
+		assert.WithinDuration(t, block.Header.Timestamp.Add(time.Hour), b.PendingBlockTimestamp(), time.Minute)
+	})
+
+	t.Run("should not commit empty blocks if pending block has transactions", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		tx := flow.NewTransaction().
+			SetScript([]byte(`transaction { execute {} }`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address)
+
+		err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		_, err = b.CommitEmptyBlocks(10, 0)
+		assert.IsType(t, &emulator.PendingBlockNotEmptyError{}, err)
+	})
+
+	t.Run("should space block timestamps across chunks", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		first := b.PendingBlockTimestamp()
+
+		// blocks are written in chunks of 10000
+		block, err := b.CommitEmptyBlocks(10001, time.Second)
+		require.NoError(t, err)
+
+		assert.Equal(t, first.Add(10000*time.Second), block.Header.Timestamp)
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+		assert.Equal(t, block.ID(), latestBlock.ID())
+	})
+
+	t.Run("should continue from latest stored block if storage fails", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStore(&failingEmptyBlocksStore{Store: memstore.New()}),
+		)
+		require.NoError(t, err)
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		_, err = b.CommitEmptyBlocks(10, 0)
+		assert.IsType(t, &emulator.StorageError{}, err)
+
+		// half of the blocks were written before the failure
+		newLatestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+		assert.Equal(t, latestBlock.Header.Height+5, newLatestBlock.Header.Height)
+
+		next, err := b.CommitBlock()
+		require.NoError(t, err)
+		assert.Equal(t, newLatestBlock.ID(), next.Header.ParentID)
+	})
+}
+
+// failingEmptyBlocksStore writes half of the empty blocks before failing.
+type failingEmptyBlocksStore struct {
+	storage.Store
+}
+
+func (s *failingEmptyBlocksStore) CommitEmptyBlocks(blocks []flowgo.Block) error {
+	err := s.Store.CommitEmptyBlocks(blocks[:len(blocks)/2])
+	if err != nil {
+		return err
+	}
+
+	return errors.New("failed to write blocks")
+}
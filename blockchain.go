@@ -49,6 +49,10 @@ type Blockchain struct {
 	// mutex protecting pending block
 	mu sync.RWMutex
 
+	// mutex held while changing the blockchain, which stays locked while a paused
+	// program has released mu, so other changes wait without blocking reads
+	writeMu sync.Mutex
+
 	// pending block containing block info, register state, pending transactions
 	pendingBlock *pendingBlock
 
@@ -56,9 +60,51 @@ type Blockchain struct {
 	vm    *fvm.VirtualMachine
 	vmCtx fvm.Context
 
+	// used to execute programs on behalf of the emulator, without coverage, profiling, tracing or debugging
+	uninstrumentedVM *fvm.VirtualMachine
+
 	transactionValidator *access.TransactionValidator
 
 	serviceKey ServiceKey
+
+	// collected Cadence code coverage, nil if disabled
+	coverageReport *CoverageReport
+
+	// recorded execution profiles, nil if disabled
+	profiler *Profiler
+
+	// provides the timestamps of new blocks
+	clock *clock
+
+	// accounts whose transactions are accepted without valid signatures
+	impersonation *impersonation
+
+	// whether to record the ledger changes of each transaction in its result
+	stateDeltasEnabled bool
+
+	// records the execution traces of transactions, nil if disabled
+	tracer *tracer
+
+	// whether traces, including those of replayed transactions, record the values of storage accesses
+	traceValuesEnabled bool
+
+	// maximum gas limit of transactions, used to estimate computation
+	transactionMaxGasLimit uint64
+
+	// pauses the execution of programs, nil if debugging is disabled
+	debugger *Debugger
+
+	// mutex protecting event subscriptions and transaction waiters
+	subscriptionsMu sync.Mutex
+
+	// subscriptions notified of the events of committed blocks
+	eventSubscriptions map[*EventSubscription]struct{}
+
+	// maximum number of blocks queued for an event subscription
+	eventQueueLimit int
+
+	// callers waiting for transactions to be sealed, by transaction ID
+	transactionWaiters map[flowgo.Identifier]map[chan struct{}]struct{}
 }
 
 type ServiceKey struct {
@@ -129,6 +175,7 @@ type config struct {
 	ServiceKey                ServiceKey
 	Store                     storage.Store
 	SimpleAddresses           bool
+	ChainID                   flowgo.ChainID
 	GenesisTokenSupply        cadence.UFix64
 	TransactionMaxGasLimit    uint64
 	ScriptGasLimit            uint64
@@ -137,6 +184,15 @@ type config struct {
 	TransactionFeesEnabled    bool
 	MinimumStorageReservation cadence.UFix64
 	StorageMBPerFLOW          cadence.UFix64
+	CoverageReport            *CoverageReport
+	Profiler                  *Profiler
+	Debugger                  *Debugger
+	ClockOffset               time.Duration
+	ImpersonatedAccounts      []sdk.Address
+	StateDeltasEnabled        bool
+	TransactionTracesEnabled  bool
+	TraceValuesEnabled        bool
+	EventQueueLimit           int
 }
 
 func (conf config) GetStore() storage.Store {
@@ -151,6 +207,10 @@ func (conf config) GetStore() storage.Store {
 }
 
 func (conf config) GetChainID() flowgo.ChainID {
+	if conf.ChainID != "" {
+		return conf.ChainID
+	}
+
 	if conf.SimpleAddresses {
 		return flowgo.MonotonicEmulator
 	}
@@ -189,6 +249,7 @@ var defaultConfig = func() config {
 		StorageMBPerFLOW:          fvm.DefaultStorageMBPerFLOW,
 		TransactionExpiry:         0, // TODO: replace with sensible default
 		StorageLimitEnabled:       true,
+		EventQueueLimit:           defaultEventSubscriptionQueueLimit,
 	}
 }()
 
@@ -240,6 +301,15 @@ func WithSimpleAddresses() Option {
 	}
 }
 
+// WithChainID sets the ID of the emulated chain, which determines the generated account addresses.
+//
+// It takes precedence over WithSimpleAddresses, and is used to emulate the chain of a forked network.
+func WithChainID(chainID flowgo.ChainID) Option {
+	return func(c *config) {
+		c.ChainID = chainID
+	}
+}
+
 // WithGenesisTokenSupply sets the genesis token supply.
 func WithGenesisTokenSupply(supply cadence.UFix64) Option {
 	return func(c *config) {
@@ -320,6 +390,96 @@ func WithTransactionFeesEnabled(enabled bool) Option {
 	}
 }
 
+// WithCoverageReport enables collecting Cadence code coverage into the given report.
+//
+// Coverage of the bootstrapping procedure is not included. The computation used by the
+// inserted probes is neither included in the reported computation, nor in the gas limit.
+// The default is to not collect coverage.
+func WithCoverageReport(report *CoverageReport) Option {
+	return func(c *config) {
+		c.CoverageReport = report
+	}
+}
+
+// WithClockOffset sets a fixed offset from the system time for the timestamps of new blocks.
+//
+// The default is to use the system time.
+func WithClockOffset(offset time.Duration) Option {
+	return func(c *config) {
+		c.ClockOffset = offset
+	}
+}
+
+// WithImpersonatedAccounts sets the accounts whose transactions are accepted without valid signatures,
+// if they are the proposer, payer or an authorizer.
+//
+// The default is to verify the signatures of all transactions.
+func WithImpersonatedAccounts(addresses ...sdk.Address) Option {
+	return func(c *config) {
+		c.ImpersonatedAccounts = addresses
+	}
+}
+
+// WithProfiler enables recording execution profiles of transactions and scripts into the given profiler.
+//
+// Profiled executions are serialized, which slows down concurrent script execution.
+// The default is to not record profiles.
+func WithProfiler(profiler *Profiler) Option {
+	return func(c *config) {
+		c.Profiler = profiler
+	}
+}
+
+// WithDebugger enables pausing the execution of transactions and scripts with the given debugger.
+//
+// Changes to the blockchain wait while a program is paused, reads do not.
+//
+// The computation used by the inserted probes is neither included in the reported computation,
+// nor in the gas limit.
+// The default is to not instrument programs for debugging.
+func WithDebugger(debugger *Debugger) Option {
+	return func(c *config) {
+		c.Debugger = debugger
+	}
+}
+
+// WithStateDeltas enables recording the ledger changes of each transaction in its result.
+//
+// The default is to not record state deltas.
+func WithStateDeltas(enabled bool) Option {
+	return func(c *config) {
+		c.StateDeltasEnabled = enabled
+	}
+}
+
+// WithTransactionTraces enables recording the execution trace of each transaction in its result.
+//
+// Function invocations are recorded by instrumenting programs, like for coverage reporting.
+// The default is to not record traces.
+func WithTransactionTraces(enabled bool) Option {
+	return func(c *config) {
+		c.TransactionTracesEnabled = enabled
+	}
+}
+
+// WithTraceValues enables recording the values read from and written to storage in transaction traces.
+//
+// Values can be large, so by default storage accesses are only recorded with their key and the size
+// of their value.
+func WithTraceValues(enabled bool) Option {
+	return func(c *config) {
+		c.TraceValuesEnabled = enabled
+	}
+}
+
+// WithEventSubscriptionQueueLimit sets the maximum number of blocks with matching events
+// queued for an event subscription. Subscriptions falling further behind are terminated.
+func WithEventSubscriptionQueueLimit(limit int) Option {
+	return func(c *config) {
+		c.EventQueueLimit = limit
+	}
+}
+
 // NewBlockchain instantiates a new emulated blockchain with the provided options.
 func NewBlockchain(opts ...Option) (*Blockchain, error) {
 
@@ -330,32 +490,95 @@ func NewBlockchain(opts ...Option) (*Blockchain, error) {
 	}
 
 	b := &Blockchain{
-		storage:    conf.GetStore(),
-		serviceKey: conf.GetServiceKey(),
+		storage:                conf.GetStore(),
+		serviceKey:             conf.GetServiceKey(),
+		coverageReport:         conf.CoverageReport,
+		profiler:               conf.Profiler,
+		debugger:               conf.Debugger,
+		clock:                  newClock(conf.ClockOffset),
+		impersonation:          newImpersonation(sdkconvert.SDKAddressesToFlow(conf.ImpersonatedAccounts)...),
+		stateDeltasEnabled:     conf.StateDeltasEnabled,
+		traceValuesEnabled:     conf.TraceValuesEnabled,
+		transactionMaxGasLimit: conf.TransactionMaxGasLimit,
+		eventSubscriptions:     make(map[*EventSubscription]struct{}),
+		eventQueueLimit:        conf.EventQueueLimit,
+		transactionWaiters:     make(map[flowgo.Identifier]map[chan struct{}]struct{}),
 	}
 
 	var err error
 
 	blocks := newBlocks(b)
 
-	b.vm, b.vmCtx, err = configureFVM(conf, blocks)
+	if conf.TransactionTracesEnabled {
+		b.tracer = newTracer(b.traceValuesEnabled)
+	}
+
+	b.vm, b.vmCtx, err = configureFVM(conf, blocks, b.impersonation, b.tracer)
 	if err != nil {
 		return nil, err
 	}
 
+	b.uninstrumentedVM = fvm.NewVirtualMachine(runtime.NewInterpreterRuntime())
+
 	latestBlock, latestLedgerView, err := configureLedger(conf, b.storage, b.vm, b.vmCtx)
 	if err != nil {
 		return nil, err
 	}
 
-	b.pendingBlock = newPendingBlock(latestBlock, latestLedgerView)
+	b.pendingBlock = newPendingBlock(latestBlock, latestLedgerView, b.clock.nextBlockTimestamp(latestBlock.Header))
 	b.transactionValidator = configureTransactionValidator(conf, blocks)
 
+	if b.coverageReport != nil {
+		// only report coverage of user programs, not of the bootstrapping procedure
+		b.coverageReport.Reset()
+	}
+
 	return b, nil
 }
 
-func configureFVM(conf config, blocks *blocks) (*fvm.VirtualMachine, fvm.Context, error) {
-	rt := runtime.NewInterpreterRuntime()
+func configureFVM(
+	conf config,
+	blocks *blocks,
+	impersonation *impersonation,
+	tracer *tracer,
+) (*fvm.VirtualMachine, fvm.Context, error) {
+	var rt runtime.Runtime = runtime.NewInterpreterRuntime()
+
+	var observers []probeObserver
+	var callObservers []callObserver
+
+	if conf.CoverageReport != nil {
+		observers = append(observers, conf.CoverageReport)
+	}
+
+	if conf.Debugger != nil {
+		observers = append(observers, conf.Debugger)
+	}
+
+	if conf.Profiler != nil {
+		callObservers = append(callObservers, conf.Profiler)
+	}
+
+	if tracer != nil {
+		callObservers = append(callObservers, tracer)
+	}
+
+	if len(observers) > 0 || len(callObservers) > 0 {
+		instrumentingRuntime, err := newInstrumentingRuntime(rt, observers, callObservers)
+		if err != nil {
+			return nil, fvm.Context{}, err
+		}
+
+		rt = instrumentingRuntime
+	}
+
+	if tracer != nil {
+		rt = newTracingRuntime(rt, tracer)
+	}
+
+	if conf.Profiler != nil {
+		rt = newProfilingRuntime(rt, conf.Profiler)
+	}
 
 	vm := fvm.NewVirtualMachine(rt)
 
@@ -370,6 +593,11 @@ func configureFVM(conf config, blocks *blocks) (*fvm.VirtualMachine, fvm.Context
 		fvm.WithTransactionFeesEnabled(conf.TransactionFeesEnabled),
 	)
 
+	ctx, err := withImpersonation(ctx, impersonation)
+	if err != nil {
+		return nil, fvm.Context{}, err
+	}
+
 	return vm, ctx, nil
 }
 
@@ -548,6 +776,53 @@ func (b *Blockchain) PendingBlockTimestamp() time.Time {
 	return b.pendingBlock.Block().Header.Timestamp
 }
 
+// SetNextBlockTimestamp sets the timestamp of the pending block.
+//
+// The timestamp must be after the timestamp of the latest block. The clock is shifted
+// accordingly, so the timestamps of following blocks continue from the given time.
+func (b *Blockchain) SetNextBlockTimestamp(timestamp time.Time) error {
+	b.lock()
+	defer b.unlock()
+
+	if b.pendingBlock.ExecutionStarted() {
+		return &PendingBlockMidExecutionError{BlockID: b.pendingBlock.ID()}
+	}
+
+	timestamp = timestamp.UTC()
+
+	if !timestamp.After(b.pendingBlock.parentTimestamp) {
+		return &InvalidBlockTimestampError{
+			Timestamp:       timestamp,
+			ParentTimestamp: b.pendingBlock.parentTimestamp,
+		}
+	}
+
+	b.clock.offset += timestamp.Sub(b.pendingBlock.timestamp)
+	b.pendingBlock.SetTimestamp(timestamp)
+
+	return nil
+}
+
+// AdvanceTime moves the clock forward by the given duration,
+// including the timestamp of the pending block.
+func (b *Blockchain) AdvanceTime(duration time.Duration) error {
+	b.lock()
+	defer b.unlock()
+
+	if duration < 0 {
+		return &InvalidTimeAdvanceError{Duration: duration}
+	}
+
+	if b.pendingBlock.ExecutionStarted() {
+		return &PendingBlockMidExecutionError{BlockID: b.pendingBlock.ID()}
+	}
+
+	b.clock.offset += duration
+	b.pendingBlock.SetTimestamp(b.pendingBlock.timestamp.Add(duration))
+
+	return nil
+}
+
 // GetLatestBlock gets the latest sealed block.
 func (b *Blockchain) GetLatestBlock() (*flowgo.Block, error) {
 	block, err := b.storage.LatestBlock()
@@ -664,15 +939,6 @@ func (b *Blockchain) GetTransactionResult(ID sdk.Identifier) (*sdk.TransactionRe
 		return nil, &StorageError{err}
 	}
 
-	var errResult error
-
-	if storedResult.ErrorCode != 0 {
-		errResult = &ExecutionError{
-			Code:    storedResult.ErrorCode,
-			Message: storedResult.ErrorMessage,
-		}
-	}
-
 	sdkEvents, err := sdkconvert.FlowEventsToSDK(storedResult.Events)
 	if err != nil {
 		return nil, err
@@ -680,13 +946,110 @@ func (b *Blockchain) GetTransactionResult(ID sdk.Identifier) (*sdk.TransactionRe
 
 	result := sdk.TransactionResult{
 		Status: sdk.TransactionStatusSealed,
-		Error:  errResult,
+		Error:  storedResultError(storedResult),
 		Events: sdkEvents,
 	}
 
 	return &result, nil
 }
 
+// GetTransactionExecutionResult returns the full result of an executed transaction,
+// including its logs, computation used and debug information.
+//
+// The function first looks in the pending block, then the current blockchain state.
+func (b *Blockchain) GetTransactionExecutionResult(ID sdk.Identifier) (*types.TransactionResult, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	txID := sdkconvert.SDKIdentifierToFlow(ID)
+
+	if pendingResult, ok := b.pendingBlock.transactionResults[txID]; ok {
+		result, err := convert.VMTransactionResultToEmulator(pendingResult.Transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Debug = pendingResult.Debug
+		result.StateDelta = pendingResult.StateDelta
+		result.Trace = pendingResult.Trace
+
+		return result, nil
+	}
+
+	storedResult, err := b.storage.TransactionResultByID(txID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, &TransactionNotFoundError{ID: txID}
+		}
+		return nil, &StorageError{err}
+	}
+
+	block, _, err := b.findTransactionBlock(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	return storedTransactionResult(txID, storedResult, block)
+}
+
+// storedTransactionResult converts the stored result of a transaction included in the given block.
+func storedTransactionResult(
+	txID flowgo.Identifier,
+	result types.StorableTransactionResult,
+	block *flowgo.Block,
+) (*types.TransactionResult, error) {
+	sdkEvents, err := sdkconvert.FlowEventsToSDK(result.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.TransactionResult{
+		TransactionID:   sdkconvert.FlowIdentifierToSDK(txID),
+		ComputationUsed: result.ComputationUsed,
+		Error:           storedResultError(result),
+		Logs:            result.Logs,
+		Events:          sdkEvents,
+		Debug:           result.Debug,
+		BlockID:         sdkconvert.FlowIdentifierToSDK(block.ID()),
+		BlockHeight:     block.Header.Height,
+		StateDelta:      result.StateDelta,
+		Trace:           result.Trace,
+	}, nil
+}
+
+// storedResultError returns the execution error of a stored transaction result, if any.
+func storedResultError(result types.StorableTransactionResult) error {
+	if result.ErrorCode == 0 {
+		return nil
+	}
+
+	return &ExecutionError{
+		Code:    result.ErrorCode,
+		Message: result.ErrorMessage,
+	}
+}
+
+// GetTransactionsByAccount returns the committed transactions involving an account as proposer,
+// payer, authorizer or event-emitting contract, newest first.
+//
+// If a cursor is given, only transactions committed before it are returned. At most limit
+// transactions are returned, or all of them if limit is zero.
+func (b *Blockchain) GetTransactionsByAccount(
+	address sdk.Address,
+	cursor *storage.AccountTransactionCursor,
+	limit int,
+) ([]storage.AccountTransaction, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	txs, err := b.storage.TransactionsByAccount(sdkconvert.SDKAddressToFlow(address), cursor, limit)
+	if err != nil {
+		return nil, &StorageError{err}
+	}
+
+	return txs, nil
+}
+
 // GetAccount returns the account for the given address.
 func (b *Blockchain) GetAccount(address sdk.Address) (*sdk.Account, error) {
 	b.mu.RLock()
@@ -769,10 +1132,37 @@ func (b *Blockchain) GetEventsByHeight(blockHeight uint64, eventType string) ([]
 	return sdkEvents, err
 }
 
+// GetEventsByHeightRange returns the events in the blocks from the start height up to and
+// including the end height by block height, optionally filtered by type. Blocks without
+// matching events are omitted.
+func (b *Blockchain) GetEventsByHeightRange(
+	eventType string,
+	startHeight, endHeight uint64,
+) (map[uint64][]sdk.Event, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	flowEvents, err := b.storage.EventsByHeightRange(eventType, startHeight, endHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	sdkEvents := make(map[uint64][]sdk.Event, len(flowEvents))
+
+	for height, events := range flowEvents {
+		sdkEvents[height], err = sdkconvert.FlowEventsToSDK(events)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert events: %w", err)
+		}
+	}
+
+	return sdkEvents, nil
+}
+
 // AddTransaction validates a transaction and adds it to the current pending block.
 func (b *Blockchain) AddTransaction(tx sdk.Transaction) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.lock()
+	defer b.unlock()
 
 	return b.addTransaction(tx)
 }
@@ -800,7 +1190,12 @@ func (b *Blockchain) addTransaction(sdkTx sdk.Transaction) error {
 		return fmt.Errorf("failed to check storage for transaction %w", err)
 	}
 
-	err = b.transactionValidator.Validate(tx)
+	validatedTx := tx
+	if impersonated := b.impersonation.includes(tx); len(impersonated) > 0 {
+		validatedTx = withoutSignaturesOf(tx, impersonated)
+	}
+
+	err = b.transactionValidator.Validate(validatedTx)
 	if err != nil {
 		return convertAccessError(err)
 	}
@@ -813,8 +1208,8 @@ func (b *Blockchain) addTransaction(sdkTx sdk.Transaction) error {
 
 // ExecuteBlock executes the remaining transactions in pending block.
 func (b *Blockchain) ExecuteBlock() ([]*types.TransactionResult, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.lock()
+	defer b.unlock()
 
 	return b.executeBlock()
 }
@@ -855,8 +1250,8 @@ func (b *Blockchain) executeBlock() ([]*types.TransactionResult, error) {
 
 // ExecuteNextTransaction executes the next indexed transaction in pending block.
 func (b *Blockchain) ExecuteNextTransaction() (*types.TransactionResult, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.lock()
+	defer b.unlock()
 
 	header := b.pendingBlock.Block().Header
 	blockContext := fvm.NewContextFromParent(
@@ -877,6 +1272,9 @@ func (b *Blockchain) executeNextTransaction(ctx fvm.Context) (*types.Transaction
 		}
 	}
 
+	var stateDelta *types.TransactionStateDelta
+	var trace *types.TransactionTrace
+
 	// use the computer to execute the next transaction
 	tp, err := b.pendingBlock.ExecuteNextTransaction(
 		func(
@@ -886,10 +1284,29 @@ func (b *Blockchain) executeNextTransaction(ctx fvm.Context) (*types.Transaction
 		) (*fvm.TransactionProcedure, error) {
 			tx := fvm.Transaction(txBody, txIndex)
 
-			err := b.vm.Run(ctx, tx, ledgerView, programs.NewEmptyPrograms())
+			if b.debugger != nil {
+				b.debugger.executionStarted(&b.mu, true, true)
+				defer b.debugger.executionFinished()
+			}
+
+			err := b.profile(tx.ID, ProfileKindTransaction, func() (uint64, error) {
+				var err error
+				trace, err = b.trace(func() error {
+					return b.vm.Run(ctx, tx, ledgerView, programs.NewEmptyPrograms())
+				})
+				return tx.ComputationUsed, err
+			})
 			if err != nil {
 				return nil, err
 			}
+
+			if b.stateDeltasEnabled {
+				stateDelta, err = newTransactionStateDelta(b.pendingBlock.ledgerView, ledgerView)
+				if err != nil {
+					return nil, err
+				}
+			}
+
 			return tx, nil
 		},
 	)
@@ -907,6 +1324,17 @@ func (b *Blockchain) executeNextTransaction(ctx fvm.Context) (*types.Transaction
 	// if transaction error exist try to further debug what was the problem
 	if tr.Error != nil {
 		tr.Debug = b.debugSignatureError(tr.Error, tp.Transaction)
+		b.pendingBlock.SetDebug(tp.ID, tr.Debug)
+	}
+
+	if stateDelta != nil {
+		tr.StateDelta = stateDelta
+		b.pendingBlock.SetStateDelta(tp.ID, stateDelta)
+	}
+
+	if trace != nil {
+		tr.Trace = trace
+		b.pendingBlock.SetTrace(tp.ID, trace)
 	}
 
 	return tr, nil
@@ -916,8 +1344,8 @@ func (b *Blockchain) executeNextTransaction(ctx fvm.Context) (*types.Transaction
 //
 // This function clears the pending transaction pool and resets the pending block.
 func (b *Blockchain) CommitBlock() (*flowgo.Block, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.lock()
+	defer b.unlock()
 
 	block, err := b.commitBlock()
 	if err != nil {
@@ -957,15 +1385,107 @@ func (b *Blockchain) commitBlock() (*flowgo.Block, error) {
 	ledgerView := b.storage.LedgerViewByHeight(block.Header.Height)
 
 	// reset pending block using current block and ledger state
-	b.pendingBlock = newPendingBlock(block, ledgerView)
+	b.pendingBlock = newPendingBlock(block, ledgerView, b.clock.nextBlockTimestamp(block.Header))
+
+	b.notifyEventSubscriptions(block, events)
+
+	txIDs := make([]flowgo.Identifier, 0, len(transactions))
+	for txID := range transactions {
+		txIDs = append(txIDs, txID)
+	}
+
+	b.notifyTransactionWaiters(txIDs)
 
 	return block, nil
 }
 
+// emptyBlocksChunkSize is the maximum number of empty blocks built and written at once.
+const emptyBlocksChunkSize = 10000
+
+// CommitEmptyBlocks commits the given number of empty blocks, starting with the pending block,
+// and returns the last committed block.
+//
+// Blocks are written in chunks. If writing a chunk fails, the blocks written before
+// are kept and the pending block follows the latest stored block.
+//
+// The pending block must not contain transactions. If the interval is positive, the timestamps
+// of the blocks are spaced by it and the clock is advanced accordingly, otherwise the timestamps
+// follow the clock.
+func (b *Blockchain) CommitEmptyBlocks(count uint64, interval time.Duration) (*flowgo.Block, error) {
+	b.lock()
+	defer b.unlock()
+
+	if interval < 0 {
+		return nil, &InvalidTimeAdvanceError{Duration: interval}
+	}
+
+	if !b.pendingBlock.Empty() {
+		return nil, &PendingBlockNotEmptyError{BlockID: b.pendingBlock.ID()}
+	}
+
+	if count == 0 {
+		block, err := b.storage.LatestBlock()
+		if err != nil {
+			return nil, &StorageError{err}
+		}
+		return &block, nil
+	}
+
+	var lastBlock *flowgo.Block
+
+	// build and write the blocks in chunks, so that memory use does not grow with the count
+	for committed := uint64(0); committed < count; {
+		size := count - committed
+		if size > emptyBlocksChunkSize {
+			size = emptyBlocksChunkSize
+		}
+
+		blocks := make([]flowgo.Block, size)
+
+		pending := b.pendingBlock
+		for i := range blocks {
+			if interval > 0 && lastBlock != nil {
+				pending.SetTimestamp(lastBlock.Header.Timestamp.Add(interval))
+			}
+
+			blocks[i] = *pending.Block()
+			lastBlock = &blocks[i]
+
+			pending = newPendingBlock(lastBlock, pending.ledgerView, b.clock.nextBlockTimestamp(lastBlock.Header))
+		}
+
+		// write all blocks of the chunk at once, instead of committing them one by one
+		err := b.storage.CommitEmptyBlocks(blocks)
+		if err != nil {
+			// the chunk may have been written partially, so continue from the latest stored block
+			_ = b.resetPendingBlock()
+			return nil, &StorageError{err}
+		}
+
+		if interval > 0 {
+			// following blocks continue after the last block
+			b.clock.offset += lastBlock.Header.Timestamp.Add(interval).Sub(b.clock.now())
+		}
+
+		ledgerView := b.storage.LedgerViewByHeight(lastBlock.Header.Height)
+
+		// reset pending block using last block and ledger state
+		b.pendingBlock = newPendingBlock(lastBlock, ledgerView, b.clock.nextBlockTimestamp(lastBlock.Header))
+
+		for i := range blocks {
+			b.notifyEventSubscriptions(&blocks[i], nil)
+		}
+
+		committed += size
+	}
+
+	return lastBlock, nil
+}
+
 // ExecuteAndCommitBlock is a utility that combines ExecuteBlock with CommitBlock.
 func (b *Blockchain) ExecuteAndCommitBlock() (*flowgo.Block, []*types.TransactionResult, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.lock()
+	defer b.unlock()
 
 	return b.executeAndCommitBlock()
 }
@@ -986,11 +1506,60 @@ func (b *Blockchain) executeAndCommitBlock() (*flowgo.Block, []*types.Transactio
 	return block, results, nil
 }
 
+// CoverageReport returns the collected Cadence code coverage, or nil if coverage is disabled.
+func (b *Blockchain) CoverageReport() *CoverageReport {
+	return b.coverageReport
+}
+
+// Profiler returns the recorded execution profiles, or nil if profiling is disabled.
+func (b *Blockchain) Profiler() *Profiler {
+	return b.profiler
+}
+
+// lock locks the blockchain to change it.
+func (b *Blockchain) lock() {
+	b.writeMu.Lock()
+	b.mu.Lock()
+}
+
+func (b *Blockchain) unlock() {
+	b.mu.Unlock()
+	b.writeMu.Unlock()
+}
+
+// Debugger returns the debugger pausing the execution of programs, or nil if debugging is disabled.
+func (b *Blockchain) Debugger() *Debugger {
+	return b.debugger
+}
+
+// profile runs a procedure, recording its profile if profiling is enabled.
+func (b *Blockchain) profile(id flowgo.Identifier, kind ProfileKind, run func() (uint64, error)) error {
+	if b.profiler == nil {
+		_, err := run()
+		return err
+	}
+
+	return b.profiler.profile(id, kind, run)
+}
+
+// trace runs a transaction and returns its execution trace, or nil if traces are not recorded.
+func (b *Blockchain) trace(run func() error) (*types.TransactionTrace, error) {
+	if b.tracer == nil {
+		return nil, run()
+	}
+
+	return b.tracer.trace(run)
+}
+
 // ResetPendingBlock clears the transactions in pending block.
 func (b *Blockchain) ResetPendingBlock() error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.lock()
+	defer b.unlock()
+
+	return b.resetPendingBlock()
+}
 
+func (b *Blockchain) resetPendingBlock() error {
 	latestBlock, err := b.storage.LatestBlock()
 	if err != nil {
 		return &StorageError{err}
@@ -999,7 +1568,145 @@ func (b *Blockchain) ResetPendingBlock() error {
 	latestLedgerView := b.storage.LedgerViewByHeight(latestBlock.Header.Height)
 
 	// reset pending block using latest committed block and ledger state
-	b.pendingBlock = newPendingBlock(&latestBlock, latestLedgerView)
+	b.pendingBlock = newPendingBlock(&latestBlock, latestLedgerView, b.clock.nextBlockTimestamp(latestBlock.Header))
+
+	return nil
+}
+
+// RollbackToHeight reverts the chain state to the block at the given height, removing
+// all later blocks along with their transactions, events and ledger changes.
+//
+// Pending transactions are discarded and the pending block is reset on top of
+// the block at the given height.
+func (b *Blockchain) RollbackToHeight(height uint64) error {
+	b.lock()
+	defer b.unlock()
+
+	latestBlock, err := b.storage.LatestBlock()
+	if err != nil {
+		return &StorageError{err}
+	}
+
+	if height > latestBlock.Header.Height {
+		return &BlockNotFoundByHeightError{Height: height}
+	}
+
+	err = b.storage.RollbackToHeight(height)
+	if err != nil {
+		return &StorageError{err}
+	}
+
+	if height < latestBlock.Header.Height {
+		b.resetEventSubscriptions()
+	}
+
+	return b.resetPendingBlock()
+}
+
+// Snapshot creates a snapshot of the current chain state with the given name,
+// or reverts the chain state to the snapshot if it already exists.
+//
+// The pending block is reset on top of the latest block in both cases.
+func (b *Blockchain) Snapshot(name string) error {
+	b.lock()
+	defer b.unlock()
+
+	names, err := b.storage.ListSnapshots()
+	if err != nil {
+		return &StorageError{err}
+	}
+
+	err = b.storage.JumpToContext(name)
+	if err != nil {
+		return &StorageError{err}
+	}
+
+	// jumping to an existing snapshot reverts the chain state
+	for _, existing := range names {
+		if existing == name {
+			b.resetEventSubscriptions()
+			break
+		}
+	}
+
+	return b.resetPendingBlock()
+}
+
+// CreateSnapshot saves the committed chain state under the given name.
+//
+// The pending block is left untouched, so pending transactions are not part
+// of the snapshot.
+func (b *Blockchain) CreateSnapshot(name string) error {
+	b.lock()
+	defer b.unlock()
+
+	err := b.storage.CreateSnapshot(name)
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return &DuplicateSnapshotError{Name: name}
+		}
+		return &StorageError{err}
+	}
+
+	return nil
+}
+
+// LoadSnapshot reverts the chain state to the snapshot with the given name.
+//
+// Pending transactions are discarded and the pending block is reset on top of
+// the latest block of the snapshot.
+func (b *Blockchain) LoadSnapshot(name string) error {
+	b.lock()
+	defer b.unlock()
+
+	err := b.storage.LoadSnapshot(name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return &SnapshotNotFoundError{Name: name}
+		}
+		return &StorageError{err}
+	}
+
+	b.resetEventSubscriptions()
+
+	err = b.resetPendingBlock()
+	if err != nil {
+		return err
+	}
+
+	// refresh the service key sequence number from the restored state
+	b.ServiceKey()
+
+	return nil
+}
+
+// ListSnapshots returns the names of all snapshots in lexicographic order.
+func (b *Blockchain) ListSnapshots() ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	names, err := b.storage.ListSnapshots()
+	if err != nil {
+		return nil, &StorageError{err}
+	}
+
+	return names, nil
+}
+
+// DeleteSnapshot deletes the snapshot with the given name.
+//
+// The current chain state is not affected.
+func (b *Blockchain) DeleteSnapshot(name string) error {
+	b.lock()
+	defer b.unlock()
+
+	err := b.storage.DeleteSnapshot(name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return &SnapshotNotFoundError{Name: name}
+		}
+		return &StorageError{err}
+	}
 
 	return nil
 }
@@ -1014,13 +1721,19 @@ func (b *Blockchain) ExecuteScript(script []byte, arguments [][]byte) (*types.Sc
 		return nil, err
 	}
 
-	return b.ExecuteScriptAtBlock(script, arguments, latestBlock.Header.Height)
+	return b.executeScriptAtBlock(script, arguments, latestBlock.Header.Height)
 }
 
 func (b *Blockchain) ExecuteScriptAtBlock(script []byte, arguments [][]byte, blockHeight uint64) (*types.ScriptResult, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
+	return b.executeScriptAtBlock(script, arguments, blockHeight)
+}
+
+// executeScriptAtBlock executes a script while holding the lock shared,
+// which a paused script releases.
+func (b *Blockchain) executeScriptAtBlock(script []byte, arguments [][]byte, blockHeight uint64) (*types.ScriptResult, error) {
 	requestedBlock, err := b.getBlockByHeight(blockHeight)
 	if err != nil {
 		return nil, err
@@ -1037,14 +1750,22 @@ func (b *Blockchain) ExecuteScriptAtBlock(script []byte, arguments [][]byte, blo
 
 	scriptProc := fvm.Script(script).WithArguments(arguments...)
 
-	err = b.vm.Run(blockContext, scriptProc, requestedLedgerView, programs.NewEmptyPrograms())
+	hasher := hash.NewSHA3_256()
+	scriptID := sdk.HashToID(hasher.ComputeHash(script))
+
+	if b.debugger != nil {
+		b.debugger.executionStarted(&b.mu, false, false)
+		defer b.debugger.executionFinished()
+	}
+
+	err = b.profile(sdkconvert.SDKIdentifierToFlow(scriptID), ProfileKindScript, func() (uint64, error) {
+		err := b.vm.Run(blockContext, scriptProc, requestedLedgerView, programs.NewEmptyPrograms())
+		return scriptProc.GasUsed, err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	hasher := hash.NewSHA3_256()
-	scriptID := sdk.HashToID(hasher.ComputeHash(script))
-
 	events, err := sdkconvert.FlowEventsToSDK(scriptProc.Events)
 	if err != nil {
 		return nil, err
@@ -1071,8 +1792,8 @@ func (b *Blockchain) ExecuteScriptAtBlock(script []byte, arguments [][]byte, blo
 // CreateAccount submits a transaction to create a new account with the given
 // account keys and contracts. The transaction is paid by the service account.
 func (b *Blockchain) CreateAccount(publicKeys []*sdk.AccountKey, contracts []templates.Contract) (sdk.Address, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.lock()
+	defer b.unlock()
 
 	serviceKey := b.ServiceKey()
 	serviceAddress := serviceKey.Address
@@ -1084,7 +1805,7 @@ func (b *Blockchain) CreateAccount(publicKeys []*sdk.AccountKey, contracts []tem
 
 	tx := templates.CreateAccount(publicKeys, contracts, serviceAddress)
 
-	tx.SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+	tx.SetGasLimit(b.transactionMaxGasLimit).
 		SetReferenceBlockID(sdk.Identifier(latestBlock.ID())).
 		SetProposalKey(serviceAddress, serviceKey.Index, serviceKey.SequenceNumber).
 		SetPayer(serviceAddress)
@@ -1142,6 +1863,9 @@ func convertToSealedResults(
 		if err != nil {
 			return nil, err
 		}
+		temp.Debug = result.Debug
+		temp.StateDelta = result.StateDelta
+		temp.Trace = result.Trace
 		output[id] = &temp
 	}
 
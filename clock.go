@@ -0,0 +1,55 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"time"
+
+	flowgo "github.com/onflow/flow-go/model/flow"
+)
+
+// minBlockTimestampIncrease is the smallest difference between the timestamps of a block and its parent.
+const minBlockTimestampIncrease = time.Millisecond
+
+// clock provides the timestamps of new blocks: the system time shifted by an offset.
+type clock struct {
+	offset time.Duration
+}
+
+func newClock(offset time.Duration) *clock {
+	return &clock{offset: offset}
+}
+
+// now returns the current time of the clock.
+func (c *clock) now() time.Time {
+	return time.Now().UTC().Add(c.offset)
+}
+
+// nextBlockTimestamp returns the timestamp of a new block following the given parent.
+//
+// Timestamps increase monotonically, even if the clock is behind the parent block,
+// e.g. after the timestamp of the parent block was set into the future.
+func (c *clock) nextBlockTimestamp(parent *flowgo.Header) time.Time {
+	now := c.now()
+
+	if !now.After(parent.Timestamp) {
+		return parent.Timestamp.Add(minBlockTimestampIncrease)
+	}
+
+	return now
+}
@@ -0,0 +1,195 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/storage/memstore"
+)
+
+func TestClock(t *testing.T) {
+
+	t.Parallel()
+
+	addTransaction := func(t *testing.T, b *emulator.Blockchain) *flow.Transaction {
+		tx := flow.NewTransaction().
+			SetScript([]byte(`transaction { execute { log(getCurrentBlock().timestamp) } }`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		return tx
+	}
+
+	t.Run("should offset block timestamps", func(t *testing.T) {
+
+		t.Parallel()
+
+		offset := 30 * 24 * time.Hour
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithClockOffset(offset),
+		)
+		require.NoError(t, err)
+
+		block, err := b.CommitBlock()
+		require.NoError(t, err)
+
+		assert.WithinDuration(t, time.Now().Add(offset), block.Header.Timestamp, time.Minute)
+	})
+
+	t.Run("should set next block timestamp", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		timestamp := time.Unix(4102444800, 0).UTC()
+
+		err = b.SetNextBlockTimestamp(timestamp)
+		require.NoError(t, err)
+
+		assert.Equal(t, timestamp, b.PendingBlockTimestamp())
+
+		addTransaction(t, b)
+
+		block, results, err := b.ExecuteAndCommitBlock()
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.NoError(t, results[0].Error)
+
+		assert.Equal(t, timestamp, block.Header.Timestamp)
+		assert.Equal(t, []string{"4102444800.00000000"}, results[0].Logs)
+
+		// following blocks continue from the set timestamp
+		assert.True(t, b.PendingBlockTimestamp().After(timestamp))
+		assert.WithinDuration(t, timestamp, b.PendingBlockTimestamp(), time.Minute)
+	})
+
+	t.Run("should not set next block timestamp before parent block", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		err = b.SetNextBlockTimestamp(latestBlock.Header.Timestamp)
+		assert.IsType(t, &emulator.InvalidBlockTimestampError{}, err)
+	})
+
+	t.Run("should not set next block timestamp mid-execution", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		addTransaction(t, b)
+		addTransaction(t, b)
+
+		_, err = b.ExecuteNextTransaction()
+		require.NoError(t, err)
+
+		err = b.SetNextBlockTimestamp(time.Now().Add(time.Hour))
+		assert.IsType(t, &emulator.PendingBlockMidExecutionError{}, err)
+
+		err = b.AdvanceTime(time.Hour)
+		assert.IsType(t, &emulator.PendingBlockMidExecutionError{}, err)
+	})
+
+	t.Run("should advance time", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		before := b.PendingBlockTimestamp()
+
+		err = b.AdvanceTime(365 * 24 * time.Hour)
+		require.NoError(t, err)
+
+		assert.Equal(t, before.Add(365*24*time.Hour), b.PendingBlockTimestamp())
+
+		block, err := b.CommitBlock()
+		require.NoError(t, err)
+
+		assert.WithinDuration(t, time.Now().Add(365*24*time.Hour), block.Header.Timestamp, time.Minute)
+		assert.True(t, b.PendingBlockTimestamp().After(block.Header.Timestamp))
+	})
+
+	t.Run("should not advance time by a negative duration", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		err = b.AdvanceTime(-time.Hour)
+		assert.IsType(t, &emulator.InvalidTimeAdvanceError{}, err)
+	})
+
+	t.Run("should keep timestamps monotonic", func(t *testing.T) {
+
+		t.Parallel()
+
+		store := memstore.New()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		err = b.SetNextBlockTimestamp(time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		latestBlock, err := b.CommitBlock()
+		require.NoError(t, err)
+
+		// the clock of a new instance is behind the latest block
+		b, err = emulator.NewBlockchain(
+			emulator.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		assert.True(t, b.PendingBlockTimestamp().After(latestBlock.Header.Timestamp))
+
+		block, err := b.CommitBlock()
+		require.NoError(t, err)
+
+		assert.True(t, b.PendingBlockTimestamp().After(block.Header.Timestamp))
+	})
+}
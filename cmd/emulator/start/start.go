@@ -65,6 +65,18 @@ type Config struct {
 	TransactionMaxGasLimit int           `default:"9999" flag:"transaction-max-gas-limit" info:"maximum gas limit for transactions"`
 	ScriptGasLimit         int           `default:"100000" flag:"script-gas-limit" info:"gas limit for scripts"`
 	WithContracts          bool          `default:"false" flag:"contracts" info:"deploy common contracts when emulator starts"`
+	ForkHost               string        `flag:"fork-host" info:"gRPC address of an access node to fork network state from, e.g. 'access.mainnet.nodes.onflow.org:9000'"`
+	ForkHeight             uint64        `default:"0" flag:"fork-height" info:"block height to fork network state from. Defaults to the latest sealed block"`
+	CoverageReporting      bool          `default:"false" flag:"coverage-reporting" info:"enable Cadence code coverage reporting"`
+	Profiling              bool          `default:"false" flag:"profiling" info:"enable recording execution profiles of transactions and scripts"`
+	StateDeltas            bool          `default:"false" flag:"state-deltas" info:"enable recording the ledger changes of each transaction"`
+	TransactionTraces      bool          `default:"false" flag:"transaction-traces" info:"enable recording the execution trace of each transaction"`
+	TraceValues            bool          `default:"false" flag:"trace-values" info:"enable recording the values of storage reads and writes in transaction traces"`
+	ClockOffset            time.Duration `flag:"clock-offset" info:"fixed offset from the system time for block timestamps, e.g. 720h"`
+	Debugger               bool          `default:"false" flag:"debugger" info:"enable the Cadence debugger over the Debug Adapter Protocol"`
+	DebuggerPort           int           `default:"2345" flag:"debugger-port" info:"port to run the Debug Adapter Protocol server"`
+	DebuggerPauseOnEntry   bool          `default:"false" flag:"debugger-pause" info:"pause the debugger before executing each transaction"`
+	EventsAllowedOrigins   []string      `flag:"events-allowed-origins" info:"origins allowed to subscribe to events over WebSocket in addition to the admin API origin, '*' allows any origin"`
 }
 
 const EnvPrefix = "FLOW"
@@ -176,6 +188,18 @@ func Cmd(getServiceKey serviceKeyFunc) *cobra.Command {
 				MinimumStorageReservation: minimumStorageReservation,
 				TransactionFeesEnabled:    conf.TransactionFeesEnabled,
 				WithContracts:             conf.WithContracts,
+				ForkHost:                  conf.ForkHost,
+				ForkHeight:                conf.ForkHeight,
+				CoverageReportingEnabled:  conf.CoverageReporting,
+				ProfilingEnabled:          conf.Profiling,
+				StateDeltasEnabled:        conf.StateDeltas,
+				TransactionTracesEnabled:  conf.TransactionTraces,
+				TraceValuesEnabled:        conf.TraceValues,
+				ClockOffset:               conf.ClockOffset,
+				DebuggerEnabled:           conf.Debugger,
+				DebuggerPort:              conf.DebuggerPort,
+				DebuggerPauseOnEntry:      conf.DebuggerPauseOnEntry,
+				EventsAllowedOrigins:      conf.EventsAllowedOrigins,
 			}
 
 			emu := server.NewEmulatorServer(logger, serverConf)
@@ -34,9 +34,10 @@ func ToStorableResult(tp *fvm.TransactionProcedure) (types.StorableTransactionRe
 	}
 
 	return types.StorableTransactionResult{
-		ErrorCode:    errorCode,
-		ErrorMessage: errorMessage,
-		Logs:         tp.Logs,
-		Events:       tp.Events,
+		ErrorCode:       errorCode,
+		ErrorMessage:    errorMessage,
+		Logs:            tp.Logs,
+		Events:          tp.Events,
+		ComputationUsed: tp.ComputationUsed,
 	}, nil
 }
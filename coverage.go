@@ -0,0 +1,170 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// CoverageReport aggregates the Cadence statement lines executed per program location.
+//
+// Coverage is collected by inserting probes into transactions, scripts and contracts
+// right before they are executed, see instrumentingRuntime.
+type CoverageReport struct {
+	mu sync.RWMutex
+	// line hits by location ID and line number
+	hits map[string]map[int]int
+}
+
+// NewCoverageReport returns a new empty coverage report.
+func NewCoverageReport() *CoverageReport {
+	return &CoverageReport{
+		hits: make(map[string]map[int]int),
+	}
+}
+
+// Reset removes all collected coverage.
+func (r *CoverageReport) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hits = make(map[string]map[int]int)
+}
+
+var _ probeObserver = &CoverageReport{}
+
+// programInstrumented registers the statement lines of a location, keeping existing hits.
+func (r *CoverageReport) programInstrumented(locationID string, _ []byte, lines []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lineHits, ok := r.hits[locationID]
+	if !ok {
+		lineHits = make(map[int]int)
+		r.hits[locationID] = lineHits
+	}
+
+	for _, line := range lines {
+		if _, ok := lineHits[line]; !ok {
+			lineHits[line] = 0
+		}
+	}
+}
+
+func (r *CoverageReport) statementExecuted(locationID string, line int, _ func() []DebugVariable) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lineHits, ok := r.hits[locationID]
+	if !ok {
+		lineHits = make(map[int]int)
+		r.hits[locationID] = lineHits
+	}
+
+	lineHits[line]++
+}
+
+func (r *CoverageReport) programFinished(_ string) {}
+
+// LocationCoverage is the coverage of a single program location.
+type LocationCoverage struct {
+	LineHits        map[int]int `json:"line_hits"`
+	Statements      int         `json:"statements"`
+	CoveredLines    int         `json:"covered_lines"`
+	CoveragePercent float64     `json:"coverage_percent"`
+}
+
+// Locations returns the coverage of all instrumented locations, keyed by location ID.
+func (r *CoverageReport) Locations() map[string]LocationCoverage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	locations := make(map[string]LocationCoverage, len(r.hits))
+
+	for locationID, lineHits := range r.hits {
+		coverage := LocationCoverage{
+			LineHits:   make(map[int]int, len(lineHits)),
+			Statements: len(lineHits),
+		}
+
+		for line, hits := range lineHits {
+			coverage.LineHits[line] = hits
+			if hits > 0 {
+				coverage.CoveredLines++
+			}
+		}
+
+		if coverage.Statements > 0 {
+			coverage.CoveragePercent = 100 * float64(coverage.CoveredLines) / float64(coverage.Statements)
+		}
+
+		locations[locationID] = coverage
+	}
+
+	return locations
+}
+
+// MarshalJSON encodes the report as a JSON object keyed by location ID.
+func (r *CoverageReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Coverage map[string]LocationCoverage `json:"coverage"`
+	}{
+		Coverage: r.Locations(),
+	})
+}
+
+// WriteLCOV writes the report in the LCOV tracefile format, using location IDs as source files.
+func (r *CoverageReport) WriteLCOV(w io.Writer) error {
+	locations := r.Locations()
+
+	locationIDs := make([]string, 0, len(locations))
+	for locationID := range locations {
+		locationIDs = append(locationIDs, locationID)
+	}
+	sort.Strings(locationIDs)
+
+	var buf bytes.Buffer
+
+	for _, locationID := range locationIDs {
+		coverage := locations[locationID]
+
+		lines := make([]int, 0, len(coverage.LineHits))
+		for line := range coverage.LineHits {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+
+		buf.WriteString("TN:\n")
+		fmt.Fprintf(&buf, "SF:%s\n", locationID)
+		for _, line := range lines {
+			fmt.Fprintf(&buf, "DA:%d,%d\n", line, coverage.LineHits[line])
+		}
+		fmt.Fprintf(&buf, "LF:%d\n", coverage.Statements)
+		fmt.Fprintf(&buf, "LH:%d\n", coverage.CoveredLines)
+		buf.WriteString("end_of_record\n")
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
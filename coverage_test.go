@@ -0,0 +1,171 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/templates"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+func TestCoverageReport(t *testing.T) {
+
+	t.Parallel()
+
+	report := emulator.NewCoverageReport()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+		emulator.WithCoverageReport(report),
+	)
+	require.NoError(t, err)
+
+	assert.Same(t, report, b.CoverageReport())
+	assert.Empty(t, report.Locations())
+
+	contract := `pub contract Test {
+    pub fun sign(x: Int): Int {
+        if x > 0 {
+            return 1
+        } else if x < 0 {
+            return -1
+        }
+        return 0
+    }
+}`
+
+	address, err := b.CreateAccount(
+		[]*flow.AccountKey{b.ServiceKey().AccountKey()},
+		[]templates.Contract{{Name: "Test", Source: contract}},
+	)
+	require.NoError(t, err)
+
+	script := fmt.Sprintf(`
+		import Test from 0x%s
+
+		pub fun main(): Int {
+			return Test.sign(x: 1)
+		}
+	`, address.Hex())
+
+	result, err := b.ExecuteScript([]byte(script), nil)
+	require.NoError(t, err)
+	require.NoError(t, result.Error)
+
+	assert.Empty(t, result.Logs)
+
+	locationID := fmt.Sprintf("A.%s.Test", address.Hex())
+
+	t.Run("should count line hits per location", func(t *testing.T) {
+		coverage, ok := report.Locations()[locationID]
+		require.True(t, ok)
+
+		assert.Equal(t, map[int]int{3: 1, 4: 1, 6: 0, 8: 0}, coverage.LineHits)
+		assert.Equal(t, 4, coverage.Statements)
+		assert.Equal(t, 2, coverage.CoveredLines)
+		assert.Equal(t, 50.0, coverage.CoveragePercent)
+	})
+
+	t.Run("should encode report as JSON", func(t *testing.T) {
+		encoded, err := json.Marshal(report)
+		require.NoError(t, err)
+
+		var decoded struct {
+			Coverage map[string]emulator.LocationCoverage `json:"coverage"`
+		}
+		err = json.Unmarshal(encoded, &decoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, report.Locations()[locationID], decoded.Coverage[locationID])
+	})
+
+	t.Run("should write report as LCOV", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := report.WriteLCOV(&buf)
+		require.NoError(t, err)
+
+		assert.Contains(
+			t,
+			buf.String(),
+			fmt.Sprintf("SF:%s\nDA:3,1\nDA:4,1\nDA:6,0\nDA:8,0\nLF:4\nLH:2\nend_of_record\n", locationID),
+		)
+	})
+
+	t.Run("should reset coverage", func(t *testing.T) {
+		report.Reset()
+		assert.Empty(t, report.Locations())
+	})
+}
+
+func TestCoverageComputation(t *testing.T) {
+
+	t.Parallel()
+
+	computationUsed := func(t *testing.T, opts ...emulator.Option) uint64 {
+		b, err := emulator.NewBlockchain(
+			append(opts, emulator.WithStorageLimitEnabled(false))...,
+		)
+		require.NoError(t, err)
+
+		tx := flow.NewTransaction().
+			SetScript([]byte(`
+				transaction {
+					prepare(signer: AuthAccount) {
+						var i = 0
+						while i < 10 {
+							i = i + 1
+						}
+						log(i)
+					}
+				}
+			`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(b.ServiceKey().Address)
+
+		err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err := b.ExecuteNextTransaction()
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+
+		assert.Equal(t, []string{"10"}, result.Logs)
+
+		return result.ComputationUsed
+	}
+
+	withoutCoverage := computationUsed(t)
+	withCoverage := computationUsed(t, emulator.WithCoverageReport(emulator.NewCoverageReport()))
+
+	assert.Greater(t, withoutCoverage, uint64(0))
+	assert.Equal(t, withoutCoverage, withCoverage)
+}
@@ -0,0 +1,388 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emulator
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// A DebugStop is a pause of the execution before a statement.
+type DebugStop struct {
+	// Location is the location ID of the executing program, e.g. "A.f8d6e0586b0a20c7.Foo"
+	Location string
+	// Source is the path of the source file the program was matched with by SetSourceBreakpoints,
+	// empty if it was not matched
+	Source string
+	Line   int
+	// Breakpoint is true if the execution paused at a breakpoint
+	Breakpoint bool
+	// Variables are the local variables in scope of the statement, innermost declarations first
+	Variables []DebugVariable
+}
+
+// A DebugVariable is the value of a variable of a paused program.
+type DebugVariable struct {
+	Name string
+	// Type is the static type of the value, e.g. "Int", empty if it is unknown
+	Type  string
+	Value string
+}
+
+// A Debugger pauses the execution of Cadence programs at breakpoints and on request.
+//
+// Programs are instrumented with probes to pause them before statements. The local variables
+// in scope of a statement are read when the program pauses.
+//
+// The debugger only pauses while it is attached, and only one program is paused at a time.
+// The blockchain can be read while a program is paused, and changes to it wait until the
+// program has finished.
+type Debugger struct {
+	stops chan DebugStop
+
+	mu           sync.Mutex
+	attached     bool
+	pauseOnEntry bool
+	pause        bool
+	// resume is closed to continue the paused program, nil if no program is paused
+	resume      chan struct{}
+	breakpoints map[string]map[int]struct{}
+	// source files with breakpoints by path
+	sources map[string]*debugSource
+	// executing programs matched with a source file by location ID
+	programs map[string]*debugProgram
+
+	// lock of the blockchain, released while a program is paused
+	lock *sync.RWMutex
+	// exclusive is true while a program holding the lock exclusively is executing.
+	// Programs holding it shared may only execute while it is paused.
+	exclusive bool
+}
+
+// A debugSource is a source file with breakpoints, matched with programs by their code.
+type debugSource struct {
+	lines       []string
+	breakpoints map[int]struct{}
+}
+
+type debugProgram struct {
+	source string
+	// number of executions of the program that have not finished
+	executions int
+}
+
+func NewDebugger() *Debugger {
+	return &Debugger{
+		stops:       make(chan DebugStop),
+		breakpoints: make(map[string]map[int]struct{}),
+		sources:     make(map[string]*debugSource),
+		programs:    make(map[string]*debugProgram),
+	}
+}
+
+// Stops returns the channel the stops of paused programs are sent on.
+//
+// A paused program waits until Continue is called.
+func (d *Debugger) Stops() <-chan DebugStop {
+	return d.stops
+}
+
+// Attach starts pausing programs.
+//
+// If pauseOnEntry is true, the debugger pauses before the first statement of each transaction.
+func (d *Debugger) Attach(pauseOnEntry bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.attached = true
+	d.pauseOnEntry = pauseOnEntry
+}
+
+// Detach removes all breakpoints and resumes a paused program.
+func (d *Debugger) Detach() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.attached = false
+	d.pauseOnEntry = false
+	d.pause = false
+	d.breakpoints = make(map[string]map[int]struct{})
+	d.sources = make(map[string]*debugSource)
+	d.programs = make(map[string]*debugProgram)
+	d.continueLocked()
+}
+
+// AddBreakpoint pauses programs before the statements on the given line of the program with the given location ID.
+func (d *Debugger) AddBreakpoint(location string, line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lines, ok := d.breakpoints[location]
+	if !ok {
+		lines = make(map[int]struct{})
+		d.breakpoints[location] = lines
+	}
+
+	lines[line] = struct{}{}
+}
+
+func (d *Debugger) RemoveBreakpoint(location string, line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.breakpoints[location], line)
+}
+
+// SetSourceBreakpoints replaces the breakpoints of a source file with the given lines.
+//
+// Transactions, scripts and contracts are matched with the source file when they are executed,
+// if their code has the same lines. Import declarations match regardless of how the imported
+// location is written, so the source of a transaction may import contracts by file path.
+func (d *Debugger) SetSourceBreakpoints(path string, code []byte, lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(lines) == 0 {
+		delete(d.sources, path)
+		return
+	}
+
+	breakpoints := make(map[int]struct{}, len(lines))
+	for _, line := range lines {
+		breakpoints[line] = struct{}{}
+	}
+
+	d.sources[path] = &debugSource{
+		lines:       sourceLines(code),
+		breakpoints: breakpoints,
+	}
+}
+
+// sourceLines returns the lines of code, normalized for matching programs with source files.
+func sourceLines(code []byte) []string {
+	lines := strings.Split(string(bytes.TrimRight(code, " \t\r\n")), "\n")
+
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "import ") {
+			line = "import"
+		}
+		lines[i] = line
+	}
+
+	return lines
+}
+
+// matchSource returns the path of the source file with the same lines as the code, if any.
+func (d *Debugger) matchSource(code []byte) (string, bool) {
+	lines := sourceLines(code)
+
+	for path, source := range d.sources {
+		if len(source.lines) != len(lines) {
+			continue
+		}
+
+		matches := true
+		for i, line := range lines {
+			if source.lines[i] != line {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// RequestPause pauses the execution before the next statement.
+func (d *Debugger) RequestPause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pause = true
+}
+
+// Continue resumes the paused program.
+func (d *Debugger) Continue() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.continueLocked()
+}
+
+func (d *Debugger) continueLocked() {
+	if d.resume != nil {
+		close(d.resume)
+		d.resume = nil
+	}
+}
+
+// executionStarted is called before a program is executed while holding the lock of the blockchain,
+// exclusively for transactions, or shared for scripts.
+//
+// If entry is true, the debugger pauses before the first statement if it was attached with pauseOnEntry.
+func (d *Debugger) executionStarted(lock *sync.RWMutex, exclusive bool, entry bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lock = lock
+	d.exclusive = exclusive
+
+	if entry && d.attached && d.pauseOnEntry {
+		d.pause = true
+	}
+}
+
+// executionFinished is called after a program was executed, while still holding the lock of the blockchain.
+func (d *Debugger) executionFinished() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// a program holding the lock shared cannot execute at the same time as one holding it exclusively
+	d.exclusive = false
+}
+
+func (d *Debugger) programInstrumented(location string, code []byte, _ []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if program, ok := d.programs[location]; ok {
+		program.executions++
+		return
+	}
+
+	if !d.attached || len(d.sources) == 0 {
+		return
+	}
+
+	source, ok := d.matchSource(code)
+	if !ok {
+		return
+	}
+
+	d.programs[location] = &debugProgram{
+		source:     source,
+		executions: 1,
+	}
+}
+
+func (d *Debugger) programFinished(location string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	program, ok := d.programs[location]
+	if !ok {
+		return
+	}
+
+	program.executions--
+	if program.executions == 0 {
+		delete(d.programs, location)
+	}
+}
+
+func (d *Debugger) statementExecuted(location string, line int, variables func() []DebugVariable) {
+	d.mu.Lock()
+
+	if !d.attached || d.resume != nil {
+		// another program is paused
+		d.mu.Unlock()
+		return
+	}
+
+	_, breakpoint := d.breakpoints[location][line]
+
+	var sourcePath string
+	if program, ok := d.programs[location]; ok {
+		if source, ok := d.sources[program.source]; ok {
+			sourcePath = program.source
+			if _, ok := source.breakpoints[line]; ok {
+				breakpoint = true
+			}
+		}
+	}
+
+	if !(d.pause || breakpoint) {
+		d.mu.Unlock()
+		return
+	}
+
+	d.pause = false
+	resume := make(chan struct{})
+	d.resume = resume
+
+	lock := d.lock
+	exclusive := d.exclusive
+	d.exclusive = false
+
+	d.mu.Unlock()
+
+	// the values are read before the blockchain is unlocked, as they may be loaded from storage
+	stop := DebugStop{
+		Location:   location,
+		Source:     sourcePath,
+		Line:       line,
+		Breakpoint: breakpoint,
+		Variables:  variables(),
+	}
+
+	unlockBlockchain(lock, exclusive)
+
+	select {
+	case d.stops <- stop:
+		<-resume
+	case <-resume:
+		// detached before the stop was received
+	}
+
+	lockBlockchain(lock, exclusive)
+
+	if exclusive {
+		d.mu.Lock()
+		d.exclusive = true
+		d.mu.Unlock()
+	}
+}
+
+func unlockBlockchain(lock *sync.RWMutex, exclusive bool) {
+	switch {
+	case lock == nil:
+	case exclusive:
+		lock.Unlock()
+	default:
+		lock.RUnlock()
+	}
+}
+
+func lockBlockchain(lock *sync.RWMutex, exclusive bool) {
+	switch {
+	case lock == nil:
+	case exclusive:
+		lock.Lock()
+	default:
+		lock.RLock()
+	}
+}
+
+var _ probeObserver = &Debugger{}
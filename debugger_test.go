@@ -0,0 +1,269 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/types"
+)
+
+func TestDebugger(t *testing.T) {
+
+	t.Parallel()
+
+	addTransaction := func(t *testing.T, b *emulator.Blockchain) flow.Identifier {
+		tx := flow.NewTransaction().
+			SetScript([]byte(`
+				transaction {
+					execute {
+						let x = 1
+						log(x)
+					}
+				}
+			`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		return tx.ID()
+	}
+
+	executeNextTransaction := func(t *testing.T, b *emulator.Blockchain) <-chan *types.TransactionResult {
+		results := make(chan *types.TransactionResult, 1)
+		go func() {
+			result, err := b.ExecuteNextTransaction()
+			assert.NoError(t, err)
+			results <- result
+		}()
+		return results
+	}
+
+	receiveStop := func(t *testing.T, debugger *emulator.Debugger) emulator.DebugStop {
+		select {
+		case stop := <-debugger.Stops():
+			return stop
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "debugger did not stop")
+			return emulator.DebugStop{}
+		}
+	}
+
+	t.Run("should pause before executing a transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		debugger := emulator.NewDebugger()
+
+		b, err := emulator.NewBlockchain(emulator.WithDebugger(debugger))
+		require.NoError(t, err)
+
+		debugger.Attach(true)
+
+		addTransaction(t, b)
+		results := executeNextTransaction(t, b)
+
+		stop := receiveStop(t, debugger)
+		assert.Equal(t, 4, stop.Line)
+
+		// step to the next statement
+		debugger.RequestPause()
+		debugger.Continue()
+
+		stop = receiveStop(t, debugger)
+		assert.Equal(t, 5, stop.Line)
+
+		debugger.Continue()
+
+		result := <-results
+		require.NoError(t, result.Error)
+		assert.Equal(t, []string{"1"}, result.Logs)
+	})
+
+	t.Run("should pause at breakpoints", func(t *testing.T) {
+
+		t.Parallel()
+
+		debugger := emulator.NewDebugger()
+
+		b, err := emulator.NewBlockchain(emulator.WithDebugger(debugger))
+		require.NoError(t, err)
+
+		debugger.Attach(false)
+
+		txID := addTransaction(t, b)
+		debugger.AddBreakpoint(fmt.Sprintf("t.%s", txID.Hex()), 5)
+
+		results := executeNextTransaction(t, b)
+
+		stop := receiveStop(t, debugger)
+		assert.Equal(t, fmt.Sprintf("t.%s", txID.Hex()), stop.Location)
+		assert.Equal(t, 5, stop.Line)
+
+		debugger.Continue()
+
+		result := <-results
+		require.NoError(t, result.Error)
+		assert.Equal(t, []string{"1"}, result.Logs)
+	})
+
+	t.Run("should report local variables", func(t *testing.T) {
+
+		t.Parallel()
+
+		debugger := emulator.NewDebugger()
+
+		b, err := emulator.NewBlockchain(emulator.WithDebugger(debugger))
+		require.NoError(t, err)
+
+		debugger.Attach(false)
+
+		txID := addTransaction(t, b)
+		debugger.AddBreakpoint(fmt.Sprintf("t.%s", txID.Hex()), 5)
+
+		results := executeNextTransaction(t, b)
+
+		stop := receiveStop(t, debugger)
+		assert.Contains(t, stop.Variables, emulator.DebugVariable{Name: "x", Type: "Int", Value: "1"})
+
+		debugger.Continue()
+
+		result := <-results
+		require.NoError(t, result.Error)
+	})
+
+	t.Run("should pause at breakpoints in source files", func(t *testing.T) {
+
+		t.Parallel()
+
+		debugger := emulator.NewDebugger()
+
+		b, err := emulator.NewBlockchain(emulator.WithDebugger(debugger))
+		require.NoError(t, err)
+
+		debugger.Attach(false)
+
+		script := []byte(`
+			pub fun main(): Int {
+				let a = 1
+				let b = a + 1
+				return b
+			}
+		`)
+
+		debugger.SetSourceBreakpoints("script.cdc", script, []int{5})
+
+		results := make(chan *types.ScriptResult, 1)
+		go func() {
+			result, err := b.ExecuteScript(script, nil)
+			assert.NoError(t, err)
+			results <- result
+		}()
+
+		stop := receiveStop(t, debugger)
+		assert.Equal(t, "script.cdc", stop.Source)
+		assert.Equal(t, 5, stop.Line)
+		assert.True(t, stop.Breakpoint)
+		assert.Equal(t,
+			[]emulator.DebugVariable{
+				{Name: "b", Type: "Int", Value: "2"},
+				{Name: "a", Type: "Int", Value: "1"},
+			},
+			stop.Variables,
+		)
+
+		debugger.Continue()
+
+		result := <-results
+		require.NoError(t, result.Error)
+	})
+
+	t.Run("should allow reading the blockchain while paused", func(t *testing.T) {
+
+		t.Parallel()
+
+		debugger := emulator.NewDebugger()
+
+		b, err := emulator.NewBlockchain(emulator.WithDebugger(debugger))
+		require.NoError(t, err)
+
+		debugger.Attach(true)
+
+		addTransaction(t, b)
+		results := executeNextTransaction(t, b)
+
+		receiveStop(t, debugger)
+
+		scriptResults := make(chan *types.ScriptResult, 1)
+		go func() {
+			result, err := b.ExecuteScript([]byte(`pub fun main(): Int { return 42 }`), nil)
+			assert.NoError(t, err)
+			scriptResults <- result
+		}()
+
+		select {
+		case result := <-scriptResults:
+			require.NoError(t, result.Error)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "script was not executed while the transaction is paused")
+		}
+
+		debugger.Continue()
+
+		result := <-results
+		require.NoError(t, result.Error)
+	})
+
+	t.Run("should not pause after detaching the debugger", func(t *testing.T) {
+
+		t.Parallel()
+
+		debugger := emulator.NewDebugger()
+
+		b, err := emulator.NewBlockchain(emulator.WithDebugger(debugger))
+		require.NoError(t, err)
+
+		debugger.Attach(true)
+		debugger.Detach()
+
+		addTransaction(t, b)
+
+		select {
+		case result := <-executeNextTransaction(t, b):
+			require.NoError(t, result.Error)
+		case <-debugger.Stops():
+			assert.Fail(t, "detached debugger stopped")
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "transaction was not executed")
+		}
+	})
+}
@@ -20,6 +20,7 @@ package emulator
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/crypto"
@@ -94,6 +95,29 @@ func (e *AccountNotFoundError) Error() string {
 	return fmt.Sprintf("could not find account with address %s", e.Address)
 }
 
+// An AccountKeyNotFoundError indicates that a key of an account could not be found.
+type AccountKeyNotFoundError struct {
+	Address  flowgo.Address
+	KeyIndex int
+}
+
+func (e *AccountKeyNotFoundError) isNotFoundError() {}
+
+func (e *AccountKeyNotFoundError) Error() string {
+	return fmt.Sprintf("could not find key %d of account with address %s", e.KeyIndex, e.Address)
+}
+
+// A SnapshotNotFoundError indicates that a snapshot with the specified name could not be found.
+type SnapshotNotFoundError struct {
+	Name string
+}
+
+func (e *SnapshotNotFoundError) isNotFoundError() {}
+
+func (e *SnapshotNotFoundError) Error() string {
+	return fmt.Sprintf("could not find snapshot with name %s", e.Name)
+}
+
 // A TransactionValidationError indicates that a submitted transaction is invalid.
 type TransactionValidationError interface {
 	isTransactionValidationError()
@@ -159,6 +183,16 @@ func (e *InvalidTransactionGasLimitError) Error() string {
 	return fmt.Sprintf("transaction gas limit (%d) exceeds the maximum gas limit (%d)", e.Actual, e.Maximum)
 }
 
+// An InvalidComputationSafetyMarginError indicates that a computation safety margin exceeds the maximum.
+type InvalidComputationSafetyMarginError struct {
+	Maximum uint64
+	Actual  uint64
+}
+
+func (e *InvalidComputationSafetyMarginError) Error() string {
+	return fmt.Sprintf("computation safety margin (%d%%) exceeds the maximum safety margin (%d%%)", e.Actual, e.Maximum)
+}
+
 // An InvalidStateVersionError indicates that a state version hash provided is invalid.
 type InvalidStateVersionError struct {
 	Version crypto.Hash
@@ -168,6 +202,34 @@ func (e *InvalidStateVersionError) Error() string {
 	return fmt.Sprintf("execution state with version hash %x is invalid", e.Version)
 }
 
+// An InvalidBlockHeightRangeError indicates that the start of a block height range is after its end.
+type InvalidBlockHeightRangeError struct {
+	StartHeight uint64
+	EndHeight   uint64
+}
+
+func (e *InvalidBlockHeightRangeError) Error() string {
+	return fmt.Sprintf("start height %d is greater than end height %d", e.StartHeight, e.EndHeight)
+}
+
+// An EventSubscriptionOverflowError indicates that an event subscription was terminated
+// because its consumer fell too far behind the committed blocks.
+type EventSubscriptionOverflowError struct {
+	MaxQueuedBlocks int
+}
+
+func (e *EventSubscriptionOverflowError) Error() string {
+	return fmt.Sprintf("event subscription fell behind by more than %d blocks", e.MaxQueuedBlocks)
+}
+
+// An EventSubscriptionResetError indicates that an event subscription was terminated
+// because the chain state was rewound.
+type EventSubscriptionResetError struct{}
+
+func (e *EventSubscriptionResetError) Error() string {
+	return "event subscription was terminated because the chain state was rewound"
+}
+
 // A PendingBlockCommitBeforeExecutionError indicates that the current pending block has not been executed (cannot commit).
 type PendingBlockCommitBeforeExecutionError struct {
 	BlockID flowgo.Identifier
@@ -186,6 +248,38 @@ func (e *PendingBlockMidExecutionError) Error() string {
 	return fmt.Sprintf("pending block with ID %s is currently being executed", e.BlockID)
 }
 
+// An InvalidBlockTimestampError indicates that a block timestamp is not after the timestamp of its parent block.
+type InvalidBlockTimestampError struct {
+	Timestamp       time.Time
+	ParentTimestamp time.Time
+}
+
+func (e *InvalidBlockTimestampError) Error() string {
+	return fmt.Sprintf(
+		"block timestamp %s must be after parent block timestamp %s",
+		e.Timestamp.Format(time.RFC3339Nano),
+		e.ParentTimestamp.Format(time.RFC3339Nano),
+	)
+}
+
+// An InvalidTimeAdvanceError indicates that the clock was advanced by a negative duration.
+type InvalidTimeAdvanceError struct {
+	Duration time.Duration
+}
+
+func (e *InvalidTimeAdvanceError) Error() string {
+	return fmt.Sprintf("cannot advance time by negative duration %s", e.Duration)
+}
+
+// A PendingBlockNotEmptyError indicates that the current pending block contains transactions.
+type PendingBlockNotEmptyError struct {
+	BlockID flowgo.Identifier
+}
+
+func (e *PendingBlockNotEmptyError) Error() string {
+	return fmt.Sprintf("pending block with ID %s contains transactions", e.BlockID)
+}
+
 // A PendingBlockTransactionsExhaustedError indicates that the current pending block has finished executing (no more transactions to execute).
 type PendingBlockTransactionsExhaustedError struct {
 	BlockID flowgo.Identifier
@@ -208,6 +302,15 @@ func (e *StorageError) Unwrap() error {
 	return e.inner
 }
 
+// A DuplicateSnapshotError indicates that a snapshot with the specified name already exists.
+type DuplicateSnapshotError struct {
+	Name string
+}
+
+func (e *DuplicateSnapshotError) Error() string {
+	return fmt.Sprintf("snapshot with name %s already exists", e.Name)
+}
+
 // An ExecutionError occurs when a transaction fails to execute.
 type ExecutionError struct {
 	Code    int
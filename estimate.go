@@ -0,0 +1,170 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"math"
+
+	sdk "github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	"github.com/onflow/flow-emulator/convert"
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+)
+
+// DefaultComputationSafetyMargin is the safety margin, in percent of the computation used,
+// added to estimated gas limits if clients do not request a margin.
+const DefaultComputationSafetyMargin = 20
+
+// MaxComputationSafetyMargin is the largest accepted safety margin, in percent of the computation used.
+//
+// A margin of 1000 percent already results in eleven times the required gas limit, so larger margins
+// are rejected as likely mistakes, e.g. a gas limit passed as the margin, rather than being silently
+// capped at the maximum gas limit. The bound also keeps the margin computation free of overflows.
+const MaxComputationSafetyMargin = 1000
+
+// A ComputationEstimate is the estimated computation of a transaction.
+type ComputationEstimate struct {
+	// ComputationUsed is the computation the transaction used when executed at the latest state.
+	ComputationUsed uint64
+	// GasLimit is the smallest gas limit the transaction succeeds with plus the safety margin,
+	// capped at the maximum gas limit.
+	GasLimit uint64
+	// Error is the error of the transaction, if it failed.
+	Error error
+}
+
+// EstimateComputation executes a transaction against the state of the pending block without changing it,
+// and returns the computation it used.
+//
+// The transaction is executed with the maximum gas limit, regardless of its own gas limit, and its signatures
+// are not verified. The estimated gas limit is the smallest gas limit the transaction succeeds with, plus the
+// safety margin. The safety margin is given in percent of that gas limit, and must not exceed
+// MaxComputationSafetyMargin.
+//
+// Finding the smallest gas limit may execute the transaction several times. The executions are not
+// instrumented, so they are not included in coverage reports, profiles, traces or debugging sessions.
+func (b *Blockchain) EstimateComputation(sdkTx sdk.Transaction, safetyMargin uint64) (*ComputationEstimate, error) {
+	b.lock()
+	defer b.unlock()
+
+	return b.estimateComputation(sdkconvert.SDKTransactionToFlow(sdkTx), safetyMargin)
+}
+
+func (b *Blockchain) estimateComputation(tx *flowgo.TransactionBody, safetyMargin uint64) (*ComputationEstimate, error) {
+	if safetyMargin > MaxComputationSafetyMargin {
+		return nil, &InvalidComputationSafetyMarginError{
+			Maximum: MaxComputationSafetyMargin,
+			Actual:  safetyMargin,
+		}
+	}
+
+	estimatedTx := *tx
+	estimatedTx.GasLimit = b.transactionMaxGasLimit
+
+	tp, _, err := b.simulateTransaction(b.uninstrumentedVM, &estimatedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	requiredGasLimit := tp.ComputationUsed
+	if tp.Err == nil {
+		requiredGasLimit, err = b.requiredGasLimit(&estimatedTx, tp.ComputationUsed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gasLimit := b.transactionMaxGasLimit
+
+	// the margin cannot overflow for computation within the maximum gas limit,
+	// but only add it if the sum stays representable
+	if requiredGasLimit <= (math.MaxUint64-99)/(MaxComputationSafetyMargin+100) {
+		// round the margin up, so that any computation used results in a margin
+		margin := (requiredGasLimit*safetyMargin + 99) / 100
+
+		if requiredGasLimit+margin < gasLimit {
+			gasLimit = requiredGasLimit + margin
+		}
+	}
+
+	return &ComputationEstimate{
+		ComputationUsed: tp.ComputationUsed,
+		GasLimit:        gasLimit,
+		Error:           convert.VMErrorToEmulator(tp.Err),
+	}, nil
+}
+
+// requiredGasLimit returns the smallest gas limit a transaction succeeds with,
+// given that it succeeds with the maximum gas limit.
+//
+// The computation of the contracts the FVM invokes on behalf of a transaction, e.g. to create
+// accounts, counts towards its gas limit, but is not included in the computation it used.
+// If the computation used is not sufficient, the gas limit is searched for between the
+// computation used and the maximum gas limit.
+func (b *Blockchain) requiredGasLimit(tx *flowgo.TransactionBody, computationUsed uint64) (uint64, error) {
+	succeeds := func(gasLimit uint64) (bool, error) {
+		limitedTx := *tx
+		limitedTx.GasLimit = gasLimit
+
+		tp, _, err := b.simulateTransaction(b.uninstrumentedVM, &limitedTx)
+		if err != nil {
+			return false, err
+		}
+
+		return tp.Err == nil, nil
+	}
+
+	// the gas limit of a transaction must be positive
+	low := computationUsed
+	if low == 0 {
+		low = 1
+	}
+
+	if low >= b.transactionMaxGasLimit {
+		return b.transactionMaxGasLimit, nil
+	}
+
+	ok, err := succeeds(low)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return low, nil
+	}
+
+	// the transaction fails with the gas limit low, and succeeds with the gas limit high
+	high := b.transactionMaxGasLimit
+
+	for high-low > 1 {
+		mid := low + (high-low)/2
+
+		ok, err := succeeds(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+
+	return high, nil
+}
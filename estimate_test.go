@@ -0,0 +1,208 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/templates"
+	"github.com/onflow/flow-go-sdk/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+func TestEstimateComputation(t *testing.T) {
+
+	t.Parallel()
+
+	newTransaction := func(b *emulator.Blockchain, script string) *flow.Transaction {
+		return flow.NewTransaction().
+			SetScript([]byte(script)).
+			SetGasLimit(1).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(b.ServiceKey().Address)
+	}
+
+	const script = `
+		transaction {
+			prepare(signer: AuthAccount) {
+				var i = 0
+				while i < 100 {
+					i = i + 1
+				}
+				signer.save(i, to: /storage/counter)
+			}
+		}
+	`
+
+	t.Run("should estimate computation with safety margin", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		tx := newTransaction(b, script)
+
+		estimate, err := b.EstimateComputation(*tx, 0)
+		require.NoError(t, err)
+		require.NoError(t, estimate.Error)
+
+		assert.Greater(t, estimate.ComputationUsed, uint64(1))
+		assert.Equal(t, estimate.ComputationUsed, estimate.GasLimit)
+
+		estimateWithMargin, err := b.EstimateComputation(*tx, 50)
+		require.NoError(t, err)
+
+		assert.Equal(t, estimate.ComputationUsed, estimateWithMargin.ComputationUsed)
+		assert.Equal(t, (estimate.ComputationUsed*150+99)/100, estimateWithMargin.GasLimit)
+
+		// the estimated gas limit is sufficient
+		tx.SetGasLimit(estimateWithMargin.GasLimit)
+
+		err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err := b.ExecuteNextTransaction()
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+		assert.Equal(t, estimate.ComputationUsed, result.ComputationUsed)
+	})
+
+	t.Run("should cap gas limit at maximum", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		estimate, err := b.EstimateComputation(*newTransaction(b, script), 0)
+		require.NoError(t, err)
+
+		b, err = emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+			emulator.WithTransactionMaxGasLimit(estimate.ComputationUsed+1),
+		)
+		require.NoError(t, err)
+
+		estimate, err = b.EstimateComputation(*newTransaction(b, script), emulator.MaxComputationSafetyMargin)
+		require.NoError(t, err)
+
+		assert.Equal(t, estimate.ComputationUsed+1, estimate.GasLimit)
+	})
+
+	t.Run("should estimate sufficient gas limit for account creation", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		accountKey, _ := test.AccountKeyGenerator().NewWithSigner()
+
+		tx := templates.CreateAccount([]*flow.AccountKey{accountKey}, nil, b.ServiceKey().Address).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address)
+
+		estimate, err := b.EstimateComputation(*tx, 0)
+		require.NoError(t, err)
+		require.NoError(t, estimate.Error)
+
+		// the computation of the invoked service account contract is not included in the computation used
+		assert.GreaterOrEqual(t, estimate.GasLimit, estimate.ComputationUsed)
+
+		tx.SetGasLimit(estimate.GasLimit)
+
+		err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err := b.ExecuteNextTransaction()
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+	})
+
+	t.Run("should not instrument estimated transactions", func(t *testing.T) {
+
+		t.Parallel()
+
+		report := emulator.NewCoverageReport()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+			emulator.WithCoverageReport(report),
+		)
+		require.NoError(t, err)
+
+		estimate, err := b.EstimateComputation(*newTransaction(b, script), 0)
+		require.NoError(t, err)
+		require.NoError(t, estimate.Error)
+
+		assert.Empty(t, report.Locations())
+	})
+
+	t.Run("should reject safety margin above maximum", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		_, err = b.EstimateComputation(*newTransaction(b, script), emulator.MaxComputationSafetyMargin+1)
+		assert.IsType(t, &emulator.InvalidComputationSafetyMarginError{}, err)
+	})
+
+	t.Run("should return error of failing transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		tx := newTransaction(b, `
+			transaction {
+				prepare(signer: AuthAccount) {
+					panic("failed")
+				}
+			}
+		`)
+
+		estimate, err := b.EstimateComputation(*tx, emulator.DefaultComputationSafetyMargin)
+		require.NoError(t, err)
+		assert.Error(t, estimate.Error)
+	})
+}
@@ -8,8 +8,10 @@ require (
 	github.com/fxamacker/cbor/v2 v2.3.1-0.20211029162100-5d5d7c3edd41
 	github.com/go-git/go-git/v5 v5.4.2
 	github.com/golang/mock v1.6.0
+	github.com/google/go-dap v0.6.0
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/grpc-ecosystem/go-grpc-prometheus v1.2.0
 	github.com/improbable-eng/grpc-web v0.12.0
 	github.com/logrusorgru/aurora v2.0.3+incompatible
@@ -29,4 +31,5 @@ require (
 	github.com/spf13/cobra v1.3.0
 	github.com/stretchr/testify v1.7.0
 	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.27.1
 )
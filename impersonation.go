@@ -0,0 +1,349 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"crypto/rand"
+	"sort"
+	"sync"
+
+	sdk "github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go/crypto"
+	"github.com/onflow/flow-go/crypto/hash"
+	"github.com/onflow/flow-go/fvm"
+	fvmcrypto "github.com/onflow/flow-go/fvm/crypto"
+	"github.com/onflow/flow-go/fvm/programs"
+	"github.com/onflow/flow-go/fvm/state"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+)
+
+// impersonation is the set of impersonated accounts.
+//
+// The signatures of impersonated accounts are not required, but the signatures of all other
+// accounts of a transaction are still verified.
+type impersonation struct {
+	mu       sync.RWMutex
+	accounts map[flowgo.Address]struct{}
+}
+
+func newImpersonation(addresses ...flowgo.Address) *impersonation {
+	i := &impersonation{
+		accounts: make(map[flowgo.Address]struct{}),
+	}
+	i.add(addresses...)
+	return i
+}
+
+func (i *impersonation) add(addresses ...flowgo.Address) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, address := range addresses {
+		i.accounts[address] = struct{}{}
+	}
+}
+
+func (i *impersonation) remove(addresses ...flowgo.Address) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, address := range addresses {
+		delete(i.accounts, address)
+	}
+}
+
+func (i *impersonation) addresses() []flowgo.Address {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	addresses := make([]flowgo.Address, 0, len(i.accounts))
+	for address := range i.accounts {
+		addresses = append(addresses, address)
+	}
+
+	sort.Slice(addresses, func(a, b int) bool {
+		return addresses[a].Hex() < addresses[b].Hex()
+	})
+
+	return addresses
+}
+
+// includes returns the impersonated accounts among the proposer, payer and authorizers of the transaction.
+func (i *impersonation) includes(tx *flowgo.TransactionBody) map[flowgo.Address]struct{} {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	included := make(map[flowgo.Address]struct{})
+
+	if len(i.accounts) == 0 {
+		return included
+	}
+
+	addresses := append([]flowgo.Address{tx.ProposalKey.Address, tx.Payer}, tx.Authorizers...)
+
+	for _, address := range addresses {
+		if _, ok := i.accounts[address]; ok {
+			included[address] = struct{}{}
+		}
+	}
+
+	return included
+}
+
+// impersonatingSignatureVerifier is a transaction processor that waives the signatures
+// of impersonated accounts, and verifies the signatures of all other accounts.
+//
+// Transactions of impersonated accounts are verified by the FVM's signature verifier, after the
+// signatures of impersonated accounts are replaced with signatures of an impersonation key.
+// The impersonation key is only added to the impersonated accounts in a discarded child view of
+// the state, and its signatures are accepted without verification.
+type impersonatingSignatureVerifier struct {
+	verifier         *fvm.TransactionSignatureVerifier
+	impersonation    *impersonation
+	impersonationKey crypto.PublicKey
+}
+
+func newImpersonatingSignatureVerifier(
+	verifier *fvm.TransactionSignatureVerifier,
+	impersonation *impersonation,
+) (*impersonatingSignatureVerifier, error) {
+	seed := make([]byte, crypto.KeyGenSeedMinLenECDSAP256)
+
+	_, err := rand.Read(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := crypto.GeneratePrivateKey(crypto.ECDSAP256, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &impersonatingSignatureVerifier{
+		verifier:         verifier,
+		impersonation:    impersonation,
+		impersonationKey: privateKey.PublicKey(),
+	}, nil
+}
+
+func (v *impersonatingSignatureVerifier) Process(
+	vm *fvm.VirtualMachine,
+	ctx *fvm.Context,
+	proc *fvm.TransactionProcedure,
+	sth *state.StateHolder,
+	programs *programs.Programs,
+) error {
+	impersonated := v.impersonation.includes(proc.Transaction)
+	if len(impersonated) == 0 {
+		return v.verifier.Process(vm, ctx, proc, sth, programs)
+	}
+
+	view := sth.State().View().NewChild()
+	accounts := newAccounts(view)
+
+	// the index of the impersonation key of each impersonated account
+	keyIndices := make(map[flowgo.Address]uint64, len(impersonated))
+
+	for address := range impersonated {
+		keyIndex, err := accounts.GetPublicKeyCount(address)
+		if err != nil {
+			return err
+		}
+
+		err = accounts.AppendPublicKey(address, flowgo.AccountPublicKey{
+			PublicKey: v.impersonationKey,
+			SignAlgo:  v.impersonationKey.Algorithm(),
+			HashAlgo:  hash.SHA3_256,
+			Weight:    v.verifier.KeyWeightThreshold,
+		})
+		if err != nil {
+			return err
+		}
+
+		keyIndices[address] = keyIndex
+	}
+
+	tx := impersonatedTransaction(proc.Transaction, keyIndices)
+
+	verifier := *v.verifier
+	verifier.SignatureVerifier = &impersonationKeyVerifier{
+		verifier:         v.verifier.SignatureVerifier,
+		impersonationKey: v.impersonationKey,
+		messages: map[string][]byte{
+			string(tx.PayloadMessage()):  proc.Transaction.PayloadMessage(),
+			string(tx.EnvelopeMessage()): proc.Transaction.EnvelopeMessage(),
+		},
+	}
+
+	impersonatedProc := *proc
+	impersonatedProc.Transaction = tx
+
+	return verifier.Process(vm, ctx, &impersonatedProc, state.NewStateHolder(state.NewState(view)), programs)
+}
+
+// impersonatedTransaction returns a copy of the transaction in which the signatures of impersonated
+// accounts are replaced with a signature of the impersonation key for each of their roles.
+func impersonatedTransaction(
+	tx *flowgo.TransactionBody,
+	keyIndices map[flowgo.Address]uint64,
+) *flowgo.TransactionBody {
+	impersonated := make(map[flowgo.Address]struct{}, len(keyIndices))
+	for address := range keyIndices {
+		impersonated[address] = struct{}{}
+	}
+
+	impersonatedTx := withoutSignaturesOf(tx, impersonated)
+
+	signed := make(map[flowgo.Address]struct{})
+
+	sign := func(address flowgo.Address, envelope bool) {
+		keyIndex, ok := keyIndices[address]
+		if !ok {
+			return
+		}
+
+		if _, ok := signed[address]; ok {
+			return
+		}
+		signed[address] = struct{}{}
+
+		signature := flowgo.TransactionSignature{
+			Address:  address,
+			KeyIndex: keyIndex,
+		}
+
+		if envelope {
+			impersonatedTx.EnvelopeSignatures = append(impersonatedTx.EnvelopeSignatures, signature)
+		} else {
+			impersonatedTx.PayloadSignatures = append(impersonatedTx.PayloadSignatures, signature)
+		}
+	}
+
+	// the payer signs the envelope, all other accounts the payload
+	sign(tx.Payer, true)
+
+	for _, address := range tx.Authorizers {
+		sign(address, false)
+	}
+
+	if keyIndex, ok := keyIndices[tx.ProposalKey.Address]; ok {
+		impersonatedTx.ProposalKey.KeyIndex = keyIndex
+		sign(tx.ProposalKey.Address, false)
+	}
+
+	return impersonatedTx
+}
+
+// impersonationKeyVerifier accepts signatures of the impersonation key without verification,
+// and verifies all other signatures against the messages of the original transaction.
+type impersonationKeyVerifier struct {
+	verifier         fvmcrypto.SignatureVerifier
+	impersonationKey crypto.PublicKey
+	// the messages of the original transaction, by the messages of the impersonated transaction
+	messages map[string][]byte
+}
+
+func (v *impersonationKeyVerifier) Verify(
+	signature []byte,
+	tag string,
+	message []byte,
+	publicKey crypto.PublicKey,
+	hashAlgo hash.HashingAlgorithm,
+) (bool, error) {
+	if publicKey.Equals(v.impersonationKey) {
+		return true, nil
+	}
+
+	if original, ok := v.messages[string(message)]; ok {
+		message = original
+	}
+
+	return v.verifier.Verify(signature, tag, message, publicKey, hashAlgo)
+}
+
+// withImpersonation replaces the signature verifier of the context's transaction processors,
+// so that signatures of impersonated accounts are not required.
+func withImpersonation(ctx fvm.Context, impersonation *impersonation) (fvm.Context, error) {
+	processors := make([]fvm.TransactionProcessor, len(ctx.TransactionProcessors))
+
+	for i, processor := range ctx.TransactionProcessors {
+		if verifier, ok := processor.(*fvm.TransactionSignatureVerifier); ok {
+			var err error
+			processor, err = newImpersonatingSignatureVerifier(verifier, impersonation)
+			if err != nil {
+				return fvm.Context{}, err
+			}
+		}
+
+		processors[i] = processor
+	}
+
+	return fvm.NewContextFromParent(ctx, fvm.WithTransactionProcessors(processors...)), nil
+}
+
+// withoutSignatures returns a copy of the transaction without signatures.
+func withoutSignatures(tx *flowgo.TransactionBody) *flowgo.TransactionBody {
+	unsigned := *tx
+	unsigned.PayloadSignatures = nil
+	unsigned.EnvelopeSignatures = nil
+	return &unsigned
+}
+
+// withoutSignaturesOf returns a copy of the transaction without the signatures of the given accounts,
+// used to validate transactions of impersonated accounts.
+func withoutSignaturesOf(tx *flowgo.TransactionBody, addresses map[flowgo.Address]struct{}) *flowgo.TransactionBody {
+	unsigned := *tx
+	unsigned.PayloadSignatures = signaturesNotOf(tx.PayloadSignatures, addresses)
+	unsigned.EnvelopeSignatures = signaturesNotOf(tx.EnvelopeSignatures, addresses)
+	return &unsigned
+}
+
+func signaturesNotOf(
+	signatures []flowgo.TransactionSignature,
+	addresses map[flowgo.Address]struct{},
+) []flowgo.TransactionSignature {
+	filtered := make([]flowgo.TransactionSignature, 0, len(signatures))
+
+	for _, signature := range signatures {
+		if _, ok := addresses[signature.Address]; !ok {
+			filtered = append(filtered, signature)
+		}
+	}
+
+	return filtered
+}
+
+// Impersonate adds accounts to the impersonated accounts.
+//
+// Transactions are accepted without the signatures of impersonated proposers, payers and authorizers.
+// The signatures of all other accounts of a transaction are still required.
+func (b *Blockchain) Impersonate(addresses ...sdk.Address) {
+	b.impersonation.add(sdkconvert.SDKAddressesToFlow(addresses)...)
+}
+
+// StopImpersonating removes accounts from the impersonated accounts.
+func (b *Blockchain) StopImpersonating(addresses ...sdk.Address) {
+	b.impersonation.remove(sdkconvert.SDKAddressesToFlow(addresses)...)
+}
+
+// ImpersonatedAccounts returns the addresses of the impersonated accounts.
+func (b *Blockchain) ImpersonatedAccounts() []sdk.Address {
+	return sdkconvert.FlowAddressesToSDK(b.impersonation.addresses())
+}
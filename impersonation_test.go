@@ -0,0 +1,208 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/test"
+	fvmerrors "github.com/onflow/flow-go/fvm/errors"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/types"
+	"github.com/onflow/flow-emulator/utils/unittest"
+)
+
+func TestImpersonation(t *testing.T) {
+
+	t.Parallel()
+
+	accountKeys := test.AccountKeyGenerator()
+
+	executeTransaction := func(t *testing.T, b *emulator.Blockchain, tx *flow.Transaction) *types.TransactionResult {
+		err := b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err := b.ExecuteNextTransaction()
+		require.NoError(t, err)
+
+		_, err = b.CommitBlock()
+		require.NoError(t, err)
+
+		return result
+	}
+
+	// newAuthorizedTransaction returns a transaction authorized by the given account,
+	// only signed by the service account
+	newAuthorizedTransaction := func(t *testing.T, b *emulator.Blockchain, authorizer flow.Address) *flow.Transaction {
+		tx := flow.NewTransaction().
+			SetScript([]byte(`transaction { prepare(signer: AuthAccount) { signer.save(1, to: /storage/answer) } }`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(authorizer)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		return tx
+	}
+
+	t.Run("should skip signature verification for impersonated authorizer", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		address, err := b.CreateAccount([]*flow.AccountKey{accountKeys.New()}, nil)
+		require.NoError(t, err)
+
+		result := executeTransaction(t, b, newAuthorizedTransaction(t, b, address))
+		assert.Error(t, result.Error)
+
+		b.Impersonate(address)
+		assert.Equal(t, []flow.Address{address}, b.ImpersonatedAccounts())
+
+		result = executeTransaction(t, b, newAuthorizedTransaction(t, b, address))
+		assertTransactionSucceeded(t, result)
+
+		b.StopImpersonating(address)
+		assert.Empty(t, b.ImpersonatedAccounts())
+
+		result = executeTransaction(t, b, newAuthorizedTransaction(t, b, address))
+		assert.Error(t, result.Error)
+	})
+
+	t.Run("should accept unsigned transactions of impersonated accounts", func(t *testing.T) {
+
+		t.Parallel()
+
+		serviceAddress := flow.ServiceAddress(flow.Emulator)
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+			emulator.WithImpersonatedAccounts(serviceAddress),
+		)
+		require.NoError(t, err)
+
+		tx := flow.NewTransaction().
+			SetScript([]byte(`transaction { prepare(signer: AuthAccount) { signer.save(1, to: /storage/answer) } }`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(serviceAddress, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(serviceAddress).
+			AddAuthorizer(serviceAddress)
+
+		result := executeTransaction(t, b, tx)
+		assertTransactionSucceeded(t, result)
+	})
+
+	t.Run("should ignore signatures of impersonated accounts", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		address, err := b.CreateAccount([]*flow.AccountKey{accountKeys.New()}, nil)
+		require.NoError(t, err)
+
+		b.Impersonate(address)
+
+		// the payload signature of the impersonated authorizer is invalid, but the envelope
+		// signature of the payer covers it
+		tx := flow.NewTransaction().
+			SetScript([]byte(`transaction { prepare(signer: AuthAccount) { signer.save(1, to: /storage/answer) } }`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(address)
+
+		err = tx.SignPayload(address, 0, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		result := executeTransaction(t, b, tx)
+		assertTransactionSucceeded(t, result)
+	})
+
+	t.Run("should accept impersonated accounts without keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		address, err := b.CreateAccount(nil, nil)
+		require.NoError(t, err)
+
+		b.Impersonate(address)
+
+		result := executeTransaction(t, b, newAuthorizedTransaction(t, b, address))
+		assertTransactionSucceeded(t, result)
+	})
+
+	t.Run("should verify signatures of accounts that are not impersonated", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		address, err := b.CreateAccount([]*flow.AccountKey{accountKeys.New()}, nil)
+		require.NoError(t, err)
+
+		b.Impersonate(address)
+
+		// the impersonated authorizer needs no signature, but the service account payer does
+		tx := flow.NewTransaction().
+			SetScript([]byte(`transaction { prepare(signer: AuthAccount) { signer.save(1, to: /storage/answer) } }`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(address)
+
+		result := executeTransaction(t, b, tx)
+		unittest.AssertFVMErrorType(t, &fvmerrors.InvalidProposalSignatureError{}, result.Error)
+
+		// a proposal signature alone does not satisfy the payer
+		tx = flow.NewTransaction().
+			SetScript([]byte(`transaction { prepare(signer: AuthAccount) { signer.save(1, to: /storage/answer) } }`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(address, 0, 0).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(address)
+
+		result = executeTransaction(t, b, tx)
+		unittest.AssertFVMErrorType(t, &fvmerrors.AccountAuthorizationError{}, result.Error)
+	})
+}
@@ -0,0 +1,1212 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emulator
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/parser2"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+// probeComputation is the computation Cadence meters for every probe that is hit:
+// one for the expression statement and one for the invocation of the probe function.
+const probeComputation = 2
+
+// probeComputationLimitFactor is the factor by which the computation limit of an instrumented
+// execution is raised, so that the computation of probes does not exceed it.
+const probeComputationLimitFactor = 10
+
+// probeFunctionType is the type of the function invoked by probes,
+// with the ID of the instrumented program and the index of the probe.
+var probeFunctionType = &sema.FunctionType{
+	Parameters: []*sema.Parameter{
+		{
+			Label:          sema.ArgumentLabelNotRequired,
+			Identifier:     "program",
+			TypeAnnotation: sema.NewTypeAnnotation(sema.UInt64Type),
+		},
+		{
+			Label:          sema.ArgumentLabelNotRequired,
+			Identifier:     "probe",
+			TypeAnnotation: sema.NewTypeAnnotation(sema.UInt64Type),
+		},
+	},
+	ReturnTypeAnnotation: sema.NewTypeAnnotation(sema.VoidType),
+}
+
+// A probe is an invocation of the probe function inserted into a Cadence program,
+// either in front of a statement, or at the start of a function body.
+type probe struct {
+	function cadenceFunction
+	line     int
+	entry    bool
+	// root is true for the entry of a function that is only invoked by the runtime,
+	// e.g. the prepare and execute functions of a transaction
+	root bool
+	// variables are the names of the local variables in scope of the statement
+	variables []string
+}
+
+// A cadenceFunction identifies a function declared in a Cadence program.
+type cadenceFunction struct {
+	location string
+	// name is qualified by the composites the function is declared in, e.g. "Test.Vault.deposit"
+	name string
+}
+
+func (f cadenceFunction) String() string {
+	return fmt.Sprintf("%s:%s", f.location, f.name)
+}
+
+// A probeObserver is notified of the statements executed by instrumented programs.
+type probeObserver interface {
+	// programInstrumented is called with the original code and the statement lines
+	// of a program every time it is executed.
+	programInstrumented(location string, code []byte, lines []int)
+	// statementExecuted is called right before a statement is executed.
+	// variables returns the values of the local variables in scope of the statement.
+	statementExecuted(location string, line int, variables func() []DebugVariable)
+	// programFinished is called for every instrumented program when the execution ends.
+	programFinished(location string)
+}
+
+// A functionCall is a call of a function declared in an instrumented program.
+type functionCall struct {
+	function cadenceFunction
+	duration time.Duration
+	// computation is the number of statements executed by the function itself,
+	// and of the functions it invoked
+	computation uint64
+}
+
+// A callObserver is notified of the function calls of instrumented programs when they return.
+//
+// Cadence does not report when a function returns, so a call is considered to return
+// when its caller executes its next statement, or when the execution ends.
+type callObserver interface {
+	functionReturned(call functionCall)
+}
+
+// instrumentingRuntime wraps a Cadence runtime to insert probes into the programs it executes.
+//
+// Cadence does not report executed statements to the embedder, so transactions, scripts
+// and imported contracts are instrumented right before they are executed. Probes invoke
+// a function that is only declared for instrumented executions, and the line numbers and
+// error positions reported for instrumented programs are the ones of the original code.
+//
+// The computation used by probes is deducted from the computation reported to the FVM.
+// The computation limit of the runtime is raised so probes cannot exceed it, and the
+// computation of the program itself is checked against the original limit when the
+// execution ends, so a transaction exceeding the limit fails at its end, rather than
+// at the statement exceeding it.
+type instrumentingRuntime struct {
+	runtime.Runtime
+	observers     []probeObserver
+	callObservers []callObserver
+	// probeFunction is the name of the function invoked by probes. It is unique to the runtime,
+	// so it cannot clash with the declarations of instrumented programs.
+	probeFunction string
+
+	mu            sync.Mutex
+	lastProgramID uint64
+	// instrumented contracts by location ID, replaced when their code changes.
+	// Transactions and scripts are only kept for the execution they are instrumented for.
+	contracts map[common.LocationID]*instrumentedProgram
+}
+
+// An instrumentedProgram is the code of a program with probes inserted.
+type instrumentedProgram struct {
+	id           uint64
+	location     common.Location
+	original     []byte
+	instrumented []byte
+	lines        []int
+	// probes by the index they are invoked with
+	probes []probe
+	// insertions of the probes into the original code, ordered by offset
+	insertions []probeInsertion
+}
+
+// A probeInsertion is the code of a probe inserted at a position of the original code.
+type probeInsertion struct {
+	ast.Position
+	length int
+}
+
+func newInstrumentingRuntime(
+	rt runtime.Runtime,
+	observers []probeObserver,
+	callObservers []callObserver,
+) (*instrumentingRuntime, error) {
+	nonce := make([]byte, 8)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate probe function name: %w", err)
+	}
+
+	return &instrumentingRuntime{
+		Runtime:       rt,
+		observers:     observers,
+		callObservers: callObservers,
+		probeFunction: "__emulator_probe_" + hex.EncodeToString(nonce),
+		contracts:     make(map[common.LocationID]*instrumentedProgram),
+	}, nil
+}
+
+func (r *instrumentingRuntime) ExecuteScript(script runtime.Script, context runtime.Context) (cadence.Value, error) {
+	i, context := r.newExecution(context, true)
+	defer i.finish()
+
+	script.Source = i.instrument(context.Location, script.Source)
+
+	value, err := r.Runtime.ExecuteScript(script, context)
+	return value, i.originalError(err)
+}
+
+func (r *instrumentingRuntime) ExecuteTransaction(script runtime.Script, context runtime.Context) error {
+	i, context := r.newExecution(context, true)
+	defer i.finish()
+
+	script.Source = i.instrument(context.Location, script.Source)
+
+	err := r.Runtime.ExecuteTransaction(script, context)
+	return i.originalError(err)
+}
+
+// InvokeContractFunction declares the probe function for contracts instrumented by previous
+// executions of the procedure, without instrumenting the contracts of the emulator's own
+// invocations, e.g. of fee deduction.
+func (r *instrumentingRuntime) InvokeContractFunction(
+	contractLocation common.AddressLocation,
+	functionName string,
+	arguments []interpreter.Value,
+	argumentTypes []sema.Type,
+	context runtime.Context,
+) (cadence.Value, error) {
+	i, context := r.newExecution(context, false)
+	defer i.finish()
+
+	value, err := r.Runtime.InvokeContractFunction(contractLocation, functionName, arguments, argumentTypes, context)
+	return value, i.originalError(err)
+}
+
+// newExecution returns the interface and context of an execution that declares the probe function.
+//
+// Contracts imported by the execution are only instrumented if instrumentImports is true.
+func (r *instrumentingRuntime) newExecution(
+	context runtime.Context,
+	instrumentImports bool,
+) (*instrumentedInterface, runtime.Context) {
+	i := &instrumentedInterface{
+		Interface:         context.Interface,
+		runtime:           r,
+		instrumentImports: instrumentImports,
+		programs:          make(map[uint64]*instrumentedProgram),
+		interpreterMeters: make(map[*interpreter.ValueDeclaration]*probeMeter),
+	}
+
+	context.Interface = i
+
+	// predeclared values are also declared for imported programs
+	context.PredeclaredValues = append(
+		append([]runtime.ValueDeclaration(nil), context.PredeclaredValues...),
+		runtime.ValueDeclaration{
+			Name:           r.probeFunction,
+			Type:           probeFunctionType,
+			Kind:           common.DeclarationKindFunction,
+			IsConstant:     true,
+			ArgumentLabels: []string{sema.ArgumentLabelNotRequired, sema.ArgumentLabelNotRequired},
+			Value:          interpreter.NewHostFunctionValue(i.probeHit, probeFunctionType),
+		},
+	)
+
+	// the runtime shares initialized codes, so the code of instrumented
+	// programs can be replaced with the original code for error messages
+	context.InitializeCodesAndPrograms()
+	i.context = context
+
+	return i, context
+}
+
+// instrument returns the program with a probe in front of every statement and at the start of every function body.
+//
+// It returns false if the code cannot be parsed.
+func (r *instrumentingRuntime) instrument(location common.Location, code []byte) (*instrumentedProgram, bool) {
+	parsed, err := parser2.ParseProgram(string(code))
+	if err != nil {
+		return nil, false
+	}
+
+	positions := probePositions(parsed)
+
+	lines := make([]int, 0, len(positions))
+	for _, position := range positions {
+		if !position.entry {
+			lines = append(lines, position.Line)
+		}
+	}
+
+	// the entry of a function precedes the probe of its first statement
+	sort.SliceStable(positions, func(i, j int) bool {
+		if positions[i].Offset != positions[j].Offset {
+			return positions[i].Offset < positions[j].Offset
+		}
+		return positions[i].entry && !positions[j].entry
+	})
+
+	r.mu.Lock()
+	r.lastProgramID++
+	id := r.lastProgramID
+	r.mu.Unlock()
+
+	locationID := string(location.ID())
+
+	program := &instrumentedProgram{
+		id:         id,
+		location:   location,
+		original:   code,
+		lines:      lines,
+		probes:     make([]probe, 0, len(positions)),
+		insertions: make([]probeInsertion, 0, len(positions)),
+	}
+
+	var instrumented bytes.Buffer
+	offset := 0
+
+	for index, position := range positions {
+		call := fmt.Sprintf("%s(%d, %d); ", r.probeFunction, id, index)
+
+		instrumented.Write(code[offset:position.Offset])
+		instrumented.WriteString(call)
+		offset = position.Offset
+
+		program.probes = append(program.probes, probe{
+			function: cadenceFunction{
+				location: locationID,
+				name:     position.function,
+			},
+			line:      position.Line,
+			entry:     position.entry,
+			root:      position.root,
+			variables: position.variables,
+		})
+
+		program.insertions = append(program.insertions, probeInsertion{
+			Position: position.Position,
+			length:   len(call),
+		})
+	}
+
+	instrumented.Write(code[offset:])
+	program.instrumented = instrumented.Bytes()
+
+	return program, true
+}
+
+// instrumentedContract returns the instrumented program of a contract,
+// which replaces the program instrumented for a previous version of the contract.
+func (r *instrumentingRuntime) instrumentedContract(location common.AddressLocation, code []byte) (*instrumentedProgram, bool) {
+	locationID := location.ID()
+
+	r.mu.Lock()
+	cached, ok := r.contracts[locationID]
+	r.mu.Unlock()
+
+	if ok && bytes.Equal(cached.original, code) {
+		return cached, true
+	}
+
+	program, ok := r.instrument(location, code)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !ok {
+		delete(r.contracts, locationID)
+		return nil, false
+	}
+
+	r.contracts[locationID] = program
+
+	return program, true
+}
+
+// contract returns the instrumented contract with the given program ID,
+// or nil if the contract has been replaced.
+func (r *instrumentingRuntime) contract(id uint64) *instrumentedProgram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, program := range r.contracts {
+		if program.id == id {
+			return program
+		}
+	}
+
+	return nil
+}
+
+// originalPosition returns the position in the original code of a position in the instrumented code.
+//
+// Probes do not contain line breaks, so only offsets and columns are shifted.
+func (p *instrumentedProgram) originalPosition(position ast.Position) ast.Position {
+	shift := 0
+	columnShift := 0
+
+	for _, insertion := range p.insertions {
+		start := insertion.Offset + shift
+		if position.Offset < start {
+			break
+		}
+
+		if position.Offset < start+insertion.length {
+			// a position in a probe is the position of the code it was inserted in front of
+			return insertion.Position
+		}
+
+		shift += insertion.length
+		if insertion.Line == position.Line {
+			columnShift += insertion.length
+		}
+	}
+
+	position.Offset -= shift
+	position.Column -= columnShift
+
+	return position
+}
+
+// instrumentedInterface wraps a runtime interface to instrument imported contracts,
+// to notify observers of the probes hit by an execution, and to deduct their computation.
+type instrumentedInterface struct {
+	runtime.Interface
+	runtime           *instrumentingRuntime
+	context           runtime.Context
+	instrumentImports bool
+	// instrumented programs of the execution by ID
+	programs map[uint64]*instrumentedProgram
+	// meters of the interpreters the runtime created for the execution
+	// that have not reported their computation yet, the innermost last
+	meters []*probeMeter
+	// meters by the first predeclared value of the interpreters, which is shared by their sub-interpreters
+	interpreterMeters map[*interpreter.ValueDeclaration]*probeMeter
+	// function calls that have not returned yet, the innermost last
+	calls []*callFrame
+}
+
+// A probeMeter counts the probes hit while an interpreter meters computation.
+//
+// The runtime meters the computation of every interpreter it creates, e.g. for
+// the instantiation of a deployed contract, against the same limit.
+type probeMeter struct {
+	limit       uint64
+	raisedLimit uint64
+	hits        uint64
+	// the interpreter of the meter hit a probe
+	hit bool
+	// the computation of the interpreter has been reported, but it may continue to execute,
+	// e.g. to invoke the initializer of a deployed contract
+	reported bool
+}
+
+type callFrame struct {
+	function    cadenceFunction
+	start       time.Time
+	computation uint64
+}
+
+// instrument returns the instrumented code of the executed transaction or script,
+// or the original code if it cannot be parsed.
+func (i *instrumentedInterface) instrument(location common.Location, code []byte) []byte {
+	if location == nil {
+		return code
+	}
+
+	program, ok := i.runtime.instrument(location, code)
+	if !ok {
+		return code
+	}
+
+	i.addProgram(program)
+
+	return program.instrumented
+}
+
+func (i *instrumentedInterface) addProgram(program *instrumentedProgram) {
+	i.programs[program.id] = program
+
+	for _, observer := range i.runtime.observers {
+		observer.programInstrumented(string(program.location.ID()), program.original, program.lines)
+	}
+}
+
+// GetProgram instruments the imported contracts the FVM has not cached yet.
+//
+// Contracts are instrumented when they are imported, rather than when their code is loaded,
+// as the runtime also loads the code to return it to programs, and to validate contract updates.
+// The runtime parses and checks the original code itself if the instrumented code is rejected.
+func (i *instrumentedInterface) GetProgram(location runtime.Location) (*interpreter.Program, error) {
+	program, err := i.Interface.GetProgram(location)
+	if err != nil || program != nil || !i.instrumentImports {
+		return program, err
+	}
+
+	addressLocation, ok := location.(common.AddressLocation)
+	if !ok {
+		return nil, nil
+	}
+
+	code, err := i.Interface.GetAccountContractCode(addressLocation.Address, addressLocation.Name)
+	if err != nil || len(code) == 0 {
+		return nil, nil
+	}
+
+	instrumented, ok := i.runtime.instrumentedContract(addressLocation, code)
+	if !ok {
+		return nil, nil
+	}
+
+	program, err = i.runtime.Runtime.ParseAndCheckProgram(instrumented.instrumented, i.context.WithLocation(location))
+	if err != nil {
+		return nil, nil
+	}
+
+	i.addProgram(instrumented)
+
+	return program, nil
+}
+
+// program returns the instrumented program with the given ID, or nil if it is unknown.
+func (i *instrumentedInterface) program(id uint64) *instrumentedProgram {
+	program, ok := i.programs[id]
+	if ok {
+		return program
+	}
+
+	// the FVM caches the contracts imported by previous executions of a procedure
+	program = i.runtime.contract(id)
+	if program != nil {
+		i.addProgram(program)
+	}
+
+	return program
+}
+
+// probeHit is the function invoked by probes.
+func (i *instrumentedInterface) probeHit(invocation interpreter.Invocation) interpreter.Value {
+	if meter := i.meter(invocation.Interpreter); meter != nil && !meter.reported {
+		meter.hits++
+	}
+
+	programID := uint64(invocation.Arguments[0].(interpreter.UInt64Value))
+	index := uint64(invocation.Arguments[1].(interpreter.UInt64Value))
+
+	program := i.program(programID)
+	if program == nil || index >= uint64(len(program.probes)) {
+		// the contract was replaced after the FVM cached it
+		return interpreter.VoidValue{}
+	}
+
+	p := program.probes[index]
+
+	if p.entry {
+		i.enterFunction(p)
+		return interpreter.VoidValue{}
+	}
+
+	i.executeStatement(p)
+
+	variables := func() []DebugVariable {
+		return localVariables(invocation.Interpreter, p.variables)
+	}
+
+	for _, observer := range i.runtime.observers {
+		observer.statementExecuted(p.function.location, p.line, variables)
+	}
+
+	return interpreter.VoidValue{}
+}
+
+func (i *instrumentedInterface) enterFunction(p probe) {
+	if len(i.runtime.callObservers) == 0 {
+		return
+	}
+
+	if p.root {
+		i.returnFrom(0)
+	}
+
+	if len(i.calls) > 0 {
+		// the invocation is metered for the caller
+		i.calls[len(i.calls)-1].computation++
+	}
+
+	i.calls = append(i.calls, &callFrame{
+		function: p.function,
+		start:    time.Now(),
+	})
+}
+
+func (i *instrumentedInterface) executeStatement(p probe) {
+	if len(i.runtime.callObservers) == 0 {
+		return
+	}
+
+	// calls made by the function of the statement have returned
+	depth := len(i.calls)
+	for depth > 0 && i.calls[depth-1].function != p.function {
+		depth--
+	}
+
+	if depth == 0 {
+		// the entry of the function was not observed, e.g. for a function expression
+		// declared in another function, so the call is the innermost one
+		depth = len(i.calls)
+		if depth == 0 {
+			i.calls = append(i.calls, &callFrame{
+				function: p.function,
+				start:    time.Now(),
+			})
+			depth = 1
+		}
+	}
+
+	i.returnFrom(depth)
+
+	i.calls[depth-1].computation++
+}
+
+// returnFrom returns from the calls above the given depth, starting with the innermost.
+func (i *instrumentedInterface) returnFrom(depth int) {
+	for len(i.calls) > depth {
+		frame := i.calls[len(i.calls)-1]
+		i.calls = i.calls[:len(i.calls)-1]
+
+		call := functionCall{
+			function:    frame.function,
+			duration:    time.Since(frame.start),
+			computation: frame.computation,
+		}
+
+		for _, observer := range i.runtime.callObservers {
+			observer.functionReturned(call)
+		}
+	}
+}
+
+// finish returns from the remaining calls of the execution,
+// and restores the original code of the instrumented programs for error messages.
+func (i *instrumentedInterface) finish() {
+	i.returnFrom(0)
+
+	for _, program := range i.programs {
+		i.context.SetCode(program.location, string(program.original))
+
+		for _, observer := range i.runtime.observers {
+			observer.programFinished(string(program.location.ID()))
+		}
+	}
+}
+
+// localVariables returns the values of the variables with the given names
+// in the current scope of the interpreter, innermost declarations first.
+//
+// Probes are host functions, which are invoked in the scope of the statement they precede.
+func localVariables(inter *interpreter.Interpreter, names []string) []DebugVariable {
+	variables := make([]DebugVariable, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+
+	for index := len(names) - 1; index >= 0; index-- {
+		name := names[index]
+		if _, ok := seen[name]; ok {
+			// shadowed by an inner declaration
+			continue
+		}
+		seen[name] = struct{}{}
+
+		variable, ok := localVariable(inter, name)
+		if ok {
+			variables = append(variables, variable)
+		}
+	}
+
+	return variables
+}
+
+// localVariable returns the value of a variable in the current scope of the interpreter.
+// It is not found if it has not been declared yet, e.g. in a function declared before it.
+func localVariable(inter *interpreter.Interpreter, name string) (variable DebugVariable, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	value, isValue := inter.VisitIdentifierExpression(&ast.IdentifierExpression{
+		Identifier: ast.Identifier{Identifier: name},
+	}).(interpreter.Value)
+	if !isValue || value == nil {
+		return DebugVariable{}, false
+	}
+
+	variable = DebugVariable{
+		Name:  name,
+		Value: value.String(),
+	}
+
+	if staticType := value.StaticType(); staticType != nil {
+		variable.Type = staticType.String()
+	}
+
+	return variable, true
+}
+
+// meter returns the meter of the interpreter a probe is hit in, or nil if it is unknown.
+//
+// The runtime creates a new interpreter for every metered execution, and the interpreter hitting
+// a probe for the first time belongs to the innermost meter without hits.
+func (i *instrumentedInterface) meter(inter *interpreter.Interpreter) *probeMeter {
+	if len(inter.PredeclaredValues) == 0 {
+		return nil
+	}
+
+	key := &inter.PredeclaredValues[0]
+
+	meter, ok := i.interpreterMeters[key]
+	if ok {
+		return meter
+	}
+
+	if len(i.meters) == 0 {
+		return nil
+	}
+
+	meter = i.meters[len(i.meters)-1]
+	if meter.hit {
+		// the interpreter has reported its computation before it hit a probe
+		return nil
+	}
+
+	meter.hit = true
+	i.interpreterMeters[key] = meter
+
+	return meter
+}
+
+// GetComputationLimit starts metering the probes hit by a new interpreter,
+// and returns a computation limit the probes cannot exceed.
+func (i *instrumentedInterface) GetComputationLimit() uint64 {
+	limit := i.Interface.GetComputationLimit()
+	if limit == 0 {
+		// the runtime does not meter computation
+		return 0
+	}
+
+	meter := &probeMeter{
+		limit:       limit,
+		raisedLimit: math.MaxUint64 - 1,
+	}
+
+	if limit <= (math.MaxUint64-1)/probeComputationLimitFactor {
+		meter.raisedLimit = limit * probeComputationLimitFactor
+	}
+
+	i.meters = append(i.meters, meter)
+
+	return meter.raisedLimit
+}
+
+// SetComputationUsed ends the metering of the innermost interpreter. It reports the computation
+// used without the computation of the probes, and checks it against the original computation limit.
+func (i *instrumentedInterface) SetComputationUsed(used uint64) error {
+	if len(i.meters) == 0 {
+		return i.Interface.SetComputationUsed(used)
+	}
+
+	meter := i.meters[len(i.meters)-1]
+	i.meters = i.meters[:len(i.meters)-1]
+	meter.reported = true
+
+	probesUsed := meter.hits * probeComputation
+	if probesUsed > used {
+		return fmt.Errorf(
+			"computation used by probes (%d) exceeds the computation used by the execution (%d)",
+			probesUsed,
+			used,
+		)
+	}
+
+	programUsed := used - probesUsed
+
+	if programUsed > meter.limit {
+		// without probes, the runtime reports the computation at which the limit is exceeded
+		err := i.Interface.SetComputationUsed(meter.limit + 1)
+		if err != nil {
+			return err
+		}
+
+		return runtime.ComputationLimitExceededError{
+			Limit: meter.limit,
+		}
+	}
+
+	if used > meter.raisedLimit {
+		return fmt.Errorf(
+			"computation used by probes (%d) exceeds the computation limit of the instrumented execution (%d)",
+			probesUsed,
+			meter.raisedLimit,
+		)
+	}
+
+	return i.Interface.SetComputationUsed(programUsed)
+}
+
+var (
+	positionType = reflect.TypeOf(ast.Position{})
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	locationType = reflect.TypeOf((*common.Location)(nil)).Elem()
+)
+
+// originalError returns a copy of an error of the execution, in which the positions
+// in instrumented programs are replaced with the positions in the original code.
+//
+// Errors are copied, as they may be shared, e.g. by the checked programs the FVM caches.
+func (i *instrumentedInterface) originalError(err error) error {
+	if err == nil || len(i.programs) == 0 {
+		return err
+	}
+
+	programs := make(map[common.LocationID]*instrumentedProgram, len(i.programs))
+	for _, program := range i.programs {
+		programs[program.location.ID()] = program
+	}
+
+	mapper := errorPositionMapper{
+		programs: programs,
+		copies:   make(map[uintptr]reflect.Value),
+	}
+
+	value := mapper.mapValue(reflect.ValueOf(&err).Elem(), nil)
+
+	return value.Interface().(error)
+}
+
+// errorPositionMapper copies errors and the errors they wrap, and maps the positions they contain.
+//
+// The position in an error is in the program of the closest location of the error or of an error wrapping it,
+// like when Cadence pretty-prints errors.
+type errorPositionMapper struct {
+	programs map[common.LocationID]*instrumentedProgram
+	// copies of the errors pointers refer to
+	copies map[uintptr]reflect.Value
+}
+
+func (m errorPositionMapper) mapValue(value reflect.Value, location common.Location) reflect.Value {
+	if value.Type() == positionType {
+		if location == nil {
+			return value
+		}
+
+		program, ok := m.programs[location.ID()]
+		if !ok {
+			return value
+		}
+
+		return reflect.ValueOf(program.originalPosition(value.Interface().(ast.Position)))
+	}
+
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+
+		result := reflect.New(value.Type()).Elem()
+		result.Set(m.mapValue(value.Elem(), location))
+		return result
+
+	case reflect.Ptr:
+		if value.IsNil() || !value.Type().Implements(errorType) || value.Elem().Kind() != reflect.Struct {
+			return value
+		}
+
+		if result, ok := m.copies[value.Pointer()]; ok {
+			return result
+		}
+
+		result := reflect.New(value.Type().Elem())
+		m.copies[value.Pointer()] = result
+		result.Elem().Set(m.mapValue(value.Elem(), location))
+		return result
+
+	case reflect.Struct:
+		location = structLocation(value, location)
+
+		result := reflect.New(value.Type()).Elem()
+		result.Set(value)
+
+		for index := 0; index < value.NumField(); index++ {
+			if value.Type().Field(index).PkgPath != "" {
+				continue
+			}
+
+			result.Field(index).Set(m.mapValue(value.Field(index), location))
+		}
+
+		return result
+
+	case reflect.Slice:
+		switch value.Type().Elem().Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Struct:
+		default:
+			return value
+		}
+
+		if value.IsNil() {
+			return value
+		}
+
+		result := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for index := 0; index < value.Len(); index++ {
+			result.Index(index).Set(m.mapValue(value.Index(index), location))
+		}
+
+		return result
+	}
+
+	return value
+}
+
+// structLocation returns the location of an error or location range, or the given location if it has none.
+func structLocation(value reflect.Value, location common.Location) common.Location {
+	if value.CanAddr() {
+		if err, ok := value.Addr().Interface().(common.HasImportLocation); ok {
+			if importLocation := err.ImportLocation(); importLocation != nil {
+				return importLocation
+			}
+		}
+	} else if err, ok := value.Interface().(common.HasImportLocation); ok {
+		if importLocation := err.ImportLocation(); importLocation != nil {
+			return importLocation
+		}
+	}
+
+	field, ok := value.Type().FieldByName("Location")
+	if ok && len(field.Index) == 1 && field.PkgPath == "" && field.Type == locationType {
+		if fieldLocation, ok := value.FieldByIndex(field.Index).Interface().(common.Location); ok && fieldLocation != nil {
+			return fieldLocation
+		}
+	}
+
+	return location
+}
+
+// A probePosition is the position in a program at which a probe is inserted.
+type probePosition struct {
+	ast.Position
+	// function is the qualified name of the function the probe is in
+	function string
+	entry    bool
+	root     bool
+	// variables are the names of the local variables in scope of a statement
+	variables []string
+}
+
+// statementStart returns the position of the first character of a statement.
+//
+// The start position Cadence reports for an index expression is the position of the opening
+// bracket, so it is not the start of statements that begin with one, e.g. `values[0] = 1`.
+func statementStart(statement ast.Statement) ast.Position {
+	switch statement := statement.(type) {
+	case *ast.AssignmentStatement:
+		return expressionStart(statement.Target)
+	case *ast.SwapStatement:
+		return expressionStart(statement.Left)
+	case *ast.ExpressionStatement:
+		return expressionStart(statement.Expression)
+	}
+
+	return statement.StartPosition()
+}
+
+// expressionStart returns the position of the first character of an expression.
+func expressionStart(expression ast.Expression) ast.Position {
+	for {
+		switch e := expression.(type) {
+		case *ast.IndexExpression:
+			expression = e.TargetExpression
+		case *ast.MemberExpression:
+			expression = e.Expression
+		case *ast.InvocationExpression:
+			expression = e.InvokedExpression
+		case *ast.BinaryExpression:
+			expression = e.Left
+		case *ast.CastingExpression:
+			expression = e.Expression
+		case *ast.ForceExpression:
+			expression = e.Expression
+		case *ast.ConditionalExpression:
+			expression = e.Test
+		default:
+			return expression.StartPosition()
+		}
+	}
+}
+
+// probePositions returns the start positions of all statements in the function bodies of a program,
+// and the positions at which the function bodies start.
+func probePositions(program *ast.Program) []probePosition {
+	var positions []probePosition
+
+	var walkBlock func(function string, block *ast.Block, scope []string)
+	var walkMembers func(prefix string, members *ast.Members, entries bool)
+
+	// walkFunctionBlock walks the body of a function. The entry probe is inserted in front
+	// of the first statement, or at the end of an empty body, as conditions must come first.
+	walkFunctionBlock := func(function string, functionBlock *ast.FunctionBlock, entry bool, root bool, scope []string) {
+		if functionBlock == nil || functionBlock.Block == nil {
+			return
+		}
+
+		block := functionBlock.Block
+
+		if entry {
+			position := block.EndPos
+			if len(block.Statements) > 0 {
+				position = statementStart(block.Statements[0])
+			}
+
+			positions = append(positions, probePosition{
+				Position: position,
+				function: function,
+				entry:    true,
+				root:     root,
+			})
+		}
+
+		walkBlock(function, block, scope)
+	}
+
+	var walkStatement func(function string, statement ast.Statement, record bool, scope []string)
+	walkStatement = func(function string, statement ast.Statement, record bool, scope []string) {
+		if record {
+			positions = append(positions, probePosition{
+				Position:  statementStart(statement),
+				function:  function,
+				variables: scope,
+			})
+		}
+
+		switch statement := statement.(type) {
+		case *ast.IfStatement:
+			thenScope := scope
+			if declaration, ok := statement.Test.(*ast.VariableDeclaration); ok {
+				thenScope = withVariables(scope, declaration.Identifier.Identifier)
+			}
+
+			walkBlock(function, statement.Then, thenScope)
+
+			if statement.Else == nil {
+				return
+			}
+
+			// an `else if` is parsed as an else block containing only the nested
+			// if statement, which cannot be preceded by another statement
+			elseStatements := statement.Else.Statements
+			if len(elseStatements) == 1 &&
+				elseStatements[0].StartPosition().Offset == statement.Else.StartPos.Offset {
+
+				walkStatement(function, elseStatements[0], false, scope)
+				return
+			}
+
+			walkBlock(function, statement.Else, scope)
+
+		case *ast.WhileStatement:
+			walkBlock(function, statement.Block, scope)
+
+		case *ast.ForStatement:
+			walkBlock(function, statement.Block, withVariables(scope, statement.Identifier.Identifier))
+
+		case *ast.SwitchStatement:
+			for _, switchCase := range statement.Cases {
+				caseScope := scope
+				for _, caseStatement := range switchCase.Statements {
+					walkStatement(function, caseStatement, true, caseScope)
+					caseScope = withVariables(caseScope, declaredVariables(caseStatement)...)
+				}
+			}
+
+		case *ast.FunctionDeclaration:
+			walkFunctionBlock(
+				fmt.Sprintf("%s.%s", function, statement.Identifier.Identifier),
+				statement.FunctionBlock,
+				true,
+				false,
+				withVariables(
+					withVariables(scope, statement.Identifier.Identifier),
+					parameterNames(statement.ParameterList)...,
+				),
+			)
+		}
+	}
+
+	walkBlock = func(function string, block *ast.Block, scope []string) {
+		if block == nil {
+			return
+		}
+
+		for _, statement := range block.Statements {
+			walkStatement(function, statement, true, scope)
+			scope = withVariables(scope, declaredVariables(statement)...)
+		}
+	}
+
+	// interface functions cannot have statements, so entry probes are only inserted into composites
+	walkMembers = func(prefix string, members *ast.Members, entries bool) {
+		if members == nil {
+			return
+		}
+
+		for _, function := range members.Functions() {
+			walkFunctionBlock(
+				fmt.Sprintf("%s.%s", prefix, function.Identifier.Identifier),
+				function.FunctionBlock,
+				entries,
+				false,
+				withVariables([]string{"self"}, parameterNames(function.ParameterList)...),
+			)
+		}
+
+		for _, specialFunction := range members.SpecialFunctions() {
+			walkFunctionBlock(
+				fmt.Sprintf("%s.%s", prefix, specialFunction.Kind.Keywords()),
+				specialFunction.FunctionDeclaration.FunctionBlock,
+				entries,
+				false,
+				withVariables([]string{"self"}, parameterNames(specialFunction.FunctionDeclaration.ParameterList)...),
+			)
+		}
+
+		for _, composite := range members.Composites() {
+			walkMembers(
+				fmt.Sprintf("%s.%s", prefix, composite.Identifier.Identifier),
+				composite.Members,
+				entries,
+			)
+		}
+
+		for _, intf := range members.Interfaces() {
+			walkMembers(
+				fmt.Sprintf("%s.%s", prefix, intf.Identifier.Identifier),
+				intf.Members,
+				false,
+			)
+		}
+	}
+
+	for _, declaration := range program.Declarations() {
+		switch declaration := declaration.(type) {
+		case *ast.FunctionDeclaration:
+			name := declaration.Identifier.Identifier
+
+			// the main function of a script is invoked by the runtime
+			walkFunctionBlock(
+				name,
+				declaration.FunctionBlock,
+				true,
+				name == "main",
+				parameterNames(declaration.ParameterList),
+			)
+
+		case *ast.CompositeDeclaration:
+			walkMembers(declaration.Identifier.Identifier, declaration.Members, true)
+
+		case *ast.InterfaceDeclaration:
+			walkMembers(declaration.Identifier.Identifier, declaration.Members, false)
+
+		case *ast.TransactionDeclaration:
+			scope := withVariables([]string{"self"}, parameterNames(declaration.ParameterList)...)
+
+			if declaration.Prepare != nil {
+				walkFunctionBlock(
+					"prepare",
+					declaration.Prepare.FunctionDeclaration.FunctionBlock,
+					true,
+					true,
+					withVariables(scope, parameterNames(declaration.Prepare.FunctionDeclaration.ParameterList)...),
+				)
+			}
+
+			if declaration.Execute != nil {
+				walkFunctionBlock("execute", declaration.Execute.FunctionDeclaration.FunctionBlock, true, true, scope)
+			}
+		}
+	}
+
+	return positions
+}
+
+// withVariables returns a copy of the names of the variables in scope, with the given names added.
+func withVariables(scope []string, names ...string) []string {
+	if len(names) == 0 {
+		return scope
+	}
+
+	return append(scope[:len(scope):len(scope)], names...)
+}
+
+// declaredVariables returns the names of the variables a statement declares in the scope it is in.
+func declaredVariables(statement ast.Statement) []string {
+	switch statement := statement.(type) {
+	case *ast.VariableDeclaration:
+		return []string{statement.Identifier.Identifier}
+	case *ast.FunctionDeclaration:
+		return []string{statement.Identifier.Identifier}
+	}
+
+	return nil
+}
+
+func parameterNames(parameterList *ast.ParameterList) []string {
+	if parameterList == nil {
+		return nil
+	}
+
+	names := make([]string, len(parameterList.Parameters))
+	for i, parameter := range parameterList.Parameters {
+		names[i] = parameter.Identifier.Identifier
+	}
+
+	return names
+}
@@ -0,0 +1,282 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emulator_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/templates"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/types"
+)
+
+// TestInstrumentation compares executions of programs with and without coverage reporting,
+// which instruments the programs.
+func TestInstrumentation(t *testing.T) {
+
+	t.Parallel()
+
+	contract := `pub contract Test {
+    pub fun element(index: Int): Int {
+        let values = [1, 2]
+        if index >= 0 {
+            return values[index]
+        }
+        return 0
+    }
+}`
+
+	// newBlockchains returns a blockchain without and a blockchain with instrumentation,
+	// with the test contract deployed to the same address.
+	newBlockchains := func(t *testing.T) (uninstrumented, instrumented *emulator.Blockchain, address flow.Address) {
+		var addresses []flow.Address
+
+		for _, opts := range [][]emulator.Option{
+			nil,
+			{emulator.WithCoverageReport(emulator.NewCoverageReport())},
+		} {
+			b, err := emulator.NewBlockchain(
+				append(opts, emulator.WithStorageLimitEnabled(false))...,
+			)
+			require.NoError(t, err)
+
+			address, err := b.CreateAccount(
+				[]*flow.AccountKey{b.ServiceKey().AccountKey()},
+				[]templates.Contract{{Name: "Test", Source: contract}},
+			)
+			require.NoError(t, err)
+
+			if uninstrumented == nil {
+				uninstrumented = b
+			} else {
+				instrumented = b
+			}
+			addresses = append(addresses, address)
+		}
+
+		require.Equal(t, addresses[0], addresses[1])
+
+		return uninstrumented, instrumented, addresses[0]
+	}
+
+	executeTransaction := func(t *testing.T, b *emulator.Blockchain, script string, gasLimit uint64) *types.TransactionResult {
+		tx := flow.NewTransaction().
+			SetScript([]byte(script)).
+			SetGasLimit(gasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(b.ServiceKey().Address)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err := b.ExecuteNextTransaction()
+		require.NoError(t, err)
+
+		_, err = b.CommitBlock()
+		require.NoError(t, err)
+
+		return result
+	}
+
+	// transactionError returns the error message of a transaction without its ID, which differs between blockchains.
+	transactionError := func(t *testing.T, result *types.TransactionResult) string {
+		require.Error(t, result.Error)
+		return strings.ReplaceAll(result.Error.Error(), result.TransactionID.Hex(), "")
+	}
+
+	t.Run("should use the same computation and keep logs", func(t *testing.T) {
+
+		t.Parallel()
+
+		uninstrumented, instrumented, address := newBlockchains(t)
+
+		script := fmt.Sprintf(`
+			import Test from 0x%s
+
+			transaction {
+				prepare(signer: AuthAccount) {
+					var i = 0
+					while i < 10 {
+						i = i + Test.element(index: 1)
+					}
+					log("__emulator_probe__:0")
+					log(i)
+				}
+			}
+		`, address.Hex())
+
+		expected := executeTransaction(t, uninstrumented, script, flowgo.DefaultMaxTransactionGasLimit)
+		assertTransactionSucceeded(t, expected)
+
+		result := executeTransaction(t, instrumented, script, flowgo.DefaultMaxTransactionGasLimit)
+		assertTransactionSucceeded(t, result)
+
+		assert.Greater(t, expected.ComputationUsed, uint64(0))
+		assert.Equal(t, expected.ComputationUsed, result.ComputationUsed)
+		assert.Equal(t, []string{`"__emulator_probe__:0"`, "10"}, expected.Logs)
+		assert.Equal(t, expected.Logs, result.Logs)
+	})
+
+	t.Run("should check the gas limit against the computation of the program", func(t *testing.T) {
+
+		t.Parallel()
+
+		uninstrumented, instrumented, address := newBlockchains(t)
+
+		script := fmt.Sprintf(`
+			import Test from 0x%s
+
+			transaction {
+				prepare(signer: AuthAccount) {
+					var i = 0
+					while i < 10 {
+						i = i + Test.element(index: 1)
+					}
+				}
+			}
+		`, address.Hex())
+
+		computation := executeTransaction(t, uninstrumented, script, flowgo.DefaultMaxTransactionGasLimit).ComputationUsed
+
+		result := executeTransaction(t, instrumented, script, computation)
+		assertTransactionSucceeded(t, result)
+		assert.Equal(t, computation, result.ComputationUsed)
+
+		expected := executeTransaction(t, uninstrumented, script, computation-1)
+		require.Error(t, expected.Error)
+
+		result = executeTransaction(t, instrumented, script, computation-1)
+		require.Error(t, result.Error)
+
+		assert.Contains(t, expected.Error.Error(), "computation limited exceeded")
+		assert.Contains(t, result.Error.Error(), "computation limited exceeded")
+		assert.Equal(t, expected.ComputationUsed, result.ComputationUsed)
+	})
+
+	t.Run("should report runtime errors at the original positions", func(t *testing.T) {
+
+		t.Parallel()
+
+		uninstrumented, instrumented, _ := newBlockchains(t)
+
+		script := `
+			transaction {
+				prepare(signer: AuthAccount) {
+					var i = 0
+					if i == 0 { i = [1][i + 1] }
+				}
+			}
+		`
+
+		expected := transactionError(t, executeTransaction(t, uninstrumented, script, flowgo.DefaultMaxTransactionGasLimit))
+		result := transactionError(t, executeTransaction(t, instrumented, script, flowgo.DefaultMaxTransactionGasLimit))
+
+		assert.Contains(t, expected, "if i == 0 { i = [1][i + 1] }")
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("should report script errors at the original positions", func(t *testing.T) {
+
+		t.Parallel()
+
+		uninstrumented, instrumented, address := newBlockchains(t)
+
+		script := []byte(fmt.Sprintf(`
+			import Test from 0x%s
+
+			pub fun main(): Int {
+				let index = 3
+				return Test.element(index: index)
+			}
+		`, address.Hex()))
+
+		expected, err := uninstrumented.ExecuteScript(script, nil)
+		require.NoError(t, err)
+		require.Error(t, expected.Error)
+
+		result, err := instrumented.ExecuteScript(script, nil)
+		require.NoError(t, err)
+		require.Error(t, result.Error)
+
+		assert.Contains(t, expected.Error.Error(), "return values[index]")
+		assert.Equal(t, expected.Error.Error(), result.Error.Error())
+	})
+
+	t.Run("should report checker errors at the original positions", func(t *testing.T) {
+
+		t.Parallel()
+
+		uninstrumented, instrumented, _ := newBlockchains(t)
+
+		script := []byte(`
+			pub fun main(): Int {
+				let x = 1
+				let y: String = x
+				return x
+			}
+		`)
+
+		expected, err := uninstrumented.ExecuteScript(script, nil)
+		require.NoError(t, err)
+		require.Error(t, expected.Error)
+
+		result, err := instrumented.ExecuteScript(script, nil)
+		require.NoError(t, err)
+		require.Error(t, result.Error)
+
+		assert.Equal(t, expected.Error.Error(), result.Error.Error())
+	})
+
+	t.Run("should return the original code of contracts", func(t *testing.T) {
+
+		t.Parallel()
+
+		uninstrumented, instrumented, address := newBlockchains(t)
+
+		script := []byte(fmt.Sprintf(`
+			import Test from 0x%[1]s
+
+			pub fun main(): [UInt8] {
+				Test.element(index: 0)
+				return getAccount(0x%[1]s).contracts.get(name: "Test")!.code
+			}
+		`, address.Hex()))
+
+		expected, err := uninstrumented.ExecuteScript(script, nil)
+		require.NoError(t, err)
+		require.NoError(t, expected.Error)
+
+		result, err := instrumented.ExecuteScript(script, nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Error)
+
+		assert.Equal(t, expected.Value, result.Value)
+	})
+}
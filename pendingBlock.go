@@ -8,11 +8,16 @@ import (
 	"github.com/onflow/flow-go/fvm"
 	"github.com/onflow/flow-go/fvm/state"
 	flowgo "github.com/onflow/flow-go/model/flow"
+
+	"github.com/onflow/flow-emulator/types"
 )
 
 type IndexedTransactionResult struct {
 	Transaction *fvm.TransactionProcedure
 	Index       uint32
+	Debug       *types.TransactionResultDebug
+	StateDelta  *types.TransactionStateDelta
+	Trace       *types.TransactionTrace
 }
 
 // MaxViewIncrease represents the largest difference in view number between
@@ -25,6 +30,8 @@ type pendingBlock struct {
 	view      uint64
 	parentID  flowgo.Identifier
 	timestamp time.Time
+	// timestamp of the parent block, which the block timestamp must be after
+	parentTimestamp time.Time
 	// mapping from transaction ID to transaction
 	transactions map[flowgo.Identifier]*flowgo.TransactionBody
 	// list of transaction IDs in the block
@@ -40,7 +47,7 @@ type pendingBlock struct {
 }
 
 // newPendingBlock creates a new pending block sequentially after a specified block.
-func newPendingBlock(prevBlock *flowgo.Block, ledgerView *delta.View) *pendingBlock {
+func newPendingBlock(prevBlock *flowgo.Block, ledgerView *delta.View, timestamp time.Time) *pendingBlock {
 
 	return &pendingBlock{
 		height: prevBlock.Header.Height + 1,
@@ -48,7 +55,8 @@ func newPendingBlock(prevBlock *flowgo.Block, ledgerView *delta.View) *pendingBl
 		// behaviour on a real network, where views are not consecutive
 		view:               prevBlock.Header.View + uint64(rand.Intn(MaxViewIncrease)+1),
 		parentID:           prevBlock.ID(),
-		timestamp:          time.Now().UTC(),
+		timestamp:          timestamp,
+		parentTimestamp:    prevBlock.Header.Timestamp,
 		transactions:       make(map[flowgo.Identifier]*flowgo.TransactionBody),
 		transactionIDs:     make([]flowgo.Identifier, 0),
 		transactionResults: make(map[flowgo.Identifier]IndexedTransactionResult),
@@ -181,11 +189,54 @@ func (b *pendingBlock) ExecuteNextTransaction(
 	return tp, nil
 }
 
+// SetDebug records the debug information of a failed transaction.
+func (b *pendingBlock) SetDebug(txID flowgo.Identifier, debug *types.TransactionResultDebug) {
+	result, ok := b.transactionResults[txID]
+	if !ok {
+		return
+	}
+
+	result.Debug = debug
+	b.transactionResults[txID] = result
+}
+
+// SetStateDelta records the ledger changes of an executed transaction.
+func (b *pendingBlock) SetStateDelta(txID flowgo.Identifier, stateDelta *types.TransactionStateDelta) {
+	result, ok := b.transactionResults[txID]
+	if !ok {
+		return
+	}
+
+	result.StateDelta = stateDelta
+	b.transactionResults[txID] = result
+}
+
+// SetTrace records the execution trace of an executed transaction.
+func (b *pendingBlock) SetTrace(txID flowgo.Identifier, trace *types.TransactionTrace) {
+	result, ok := b.transactionResults[txID]
+	if !ok {
+		return
+	}
+
+	result.Trace = trace
+	b.transactionResults[txID] = result
+}
+
+// AddEvents records events emitted by changes applied to the pending block outside of its transactions.
+func (b *pendingBlock) AddEvents(events []flowgo.Event) {
+	b.events = append(b.events, events...)
+}
+
 // Events returns all events captured during the execution of the pending block.
 func (b *pendingBlock) Events() []flowgo.Event {
 	return b.events
 }
 
+// SetTimestamp sets the timestamp of the pending block.
+func (b *pendingBlock) SetTimestamp(timestamp time.Time) {
+	b.timestamp = timestamp
+}
+
 // ExecutionStarted returns true if the pending block has started executing.
 func (b *pendingBlock) ExecutionStarted() bool {
 	return b.index > 0
@@ -0,0 +1,530 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emulator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProfileKind is the kind of procedure a profile was recorded for.
+type ProfileKind string
+
+const (
+	ProfileKindTransaction ProfileKind = "transaction"
+	ProfileKindScript      ProfileKind = "script"
+)
+
+// ProfileValue is the value measured by the samples of an exported profile.
+type ProfileValue string
+
+const (
+	ProfileValueWallTime    ProfileValue = "time"
+	ProfileValueComputation ProfileValue = "computation"
+)
+
+// ProfileSpan is a timed operation recorded while executing a procedure.
+//
+// Cadence function calls are named by their location and qualified name, e.g.
+// "A.f8d6e0586b0a20c7.Test:Test.foo", and FVM operations are prefixed with "fvm.".
+type ProfileSpan struct {
+	Name string
+	// Start is the offset from the start of the procedure.
+	Start    time.Duration
+	Duration time.Duration
+	// Computation is the computation used by a Cadence function call itself, excluding the calls it made.
+	Computation uint64
+}
+
+// Profile records where the execution of a transaction or script spent its time and computation.
+//
+// Cadence function calls are recorded by instrumenting the executed programs, see instrumentingRuntime.
+// The computation of a function call counts the statements it executed and the functions it invoked.
+// Loop iterations and calls of built-in functions are attributed to the procedure itself.
+type Profile struct {
+	ID              flowgo.Identifier
+	Kind            ProfileKind
+	ComputationUsed uint64
+	Duration        time.Duration
+	Spans           []ProfileSpan
+}
+
+// profileNode is a span in the call tree of a profile.
+type profileNode struct {
+	name        string
+	start       time.Duration
+	end         time.Duration
+	computation uint64
+	children    []*profileNode
+}
+
+// selfTime returns the time spent in the node itself, excluding its children.
+func (n *profileNode) selfTime() time.Duration {
+	self := n.end - n.start
+	for _, child := range n.children {
+		self -= child.end - child.start
+	}
+
+	if self < 0 {
+		return 0
+	}
+
+	return self
+}
+
+// callTree nests the spans of the profile by their time intervals.
+func (p *Profile) callTree() *profileNode {
+	root := &profileNode{
+		name:        fmt.Sprintf("%s:%s", p.Kind, p.ID),
+		end:         p.Duration,
+		computation: p.ComputationUsed,
+	}
+
+	spans := make([]ProfileSpan, len(p.Spans))
+	copy(spans, p.Spans)
+
+	// parents start before and end after their children
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		return spans[i].Duration > spans[j].Duration
+	})
+
+	stack := []*profileNode{root}
+
+	for _, span := range spans {
+		node := &profileNode{
+			name:        span.Name,
+			start:       span.Start,
+			end:         span.Start + span.Duration,
+			computation: span.Computation,
+		}
+
+		// the procedure used the computation not attributed to function calls
+		if root.computation > node.computation {
+			root.computation -= node.computation
+		} else {
+			root.computation = 0
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].end < node.end {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, node)
+
+		stack = append(stack, node)
+	}
+
+	return root
+}
+
+// walkStacks calls the given function with the stack of every node in the call tree.
+func walkStacks(node *profileNode, stack []string, f func(stack []string, node *profileNode)) {
+	stack = append(stack, node.name)
+
+	f(stack, node)
+
+	for _, child := range node.children {
+		walkStacks(child, stack, f)
+	}
+}
+
+// WriteFoldedStacks writes the profiles in the folded stack format used by flame graph tools.
+//
+// Each line contains a semicolon-separated call stack, followed by the self time in microseconds,
+// or the computation used by the call itself.
+func WriteFoldedStacks(w io.Writer, value ProfileValue, profiles ...*Profile) error {
+	var buf bytes.Buffer
+
+	for _, profile := range profiles {
+		walkStacks(profile.callTree(), nil, func(stack []string, node *profileNode) {
+			var sample int64
+
+			switch value {
+			case ProfileValueComputation:
+				sample = int64(node.computation)
+			default:
+				sample = node.selfTime().Microseconds()
+			}
+
+			if sample <= 0 {
+				return
+			}
+
+			fmt.Fprintf(&buf, "%s %d\n", strings.Join(stack, ";"), sample)
+		})
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WritePprof writes the profiles as a gzipped pprof protocol buffer with wall time and computation samples.
+func WritePprof(w io.Writer, profiles ...*Profile) error {
+	stringTable := []string{""}
+	stringIndex := map[string]uint64{"": 0}
+
+	internString := func(s string) uint64 {
+		index, ok := stringIndex[s]
+		if !ok {
+			index = uint64(len(stringTable))
+			stringTable = append(stringTable, s)
+			stringIndex[s] = index
+		}
+		return index
+	}
+
+	// every function has a single location with the same ID
+	functionIDs := make(map[string]uint64)
+
+	functionID := func(name string) uint64 {
+		id, ok := functionIDs[name]
+		if !ok {
+			id = uint64(len(functionIDs) + 1)
+			functionIDs[name] = id
+		}
+		return id
+	}
+
+	appendMessage := func(b []byte, num protowire.Number, message []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, message)
+	}
+
+	appendVarint := func(b []byte, num protowire.Number, v uint64) []byte {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, v)
+	}
+
+	var out []byte
+	var totalDuration time.Duration
+
+	valueType := appendVarint(nil, 1, internString("wall"))
+	valueType = appendVarint(valueType, 2, internString("nanoseconds"))
+
+	computationType := appendVarint(nil, 1, internString("computation"))
+	computationType = appendVarint(computationType, 2, internString("count"))
+
+	out = appendMessage(out, 1, valueType)
+	out = appendMessage(out, 1, computationType)
+
+	for _, profile := range profiles {
+		totalDuration += profile.Duration
+
+		walkStacks(profile.callTree(), nil, func(stack []string, node *profileNode) {
+			self := node.selfTime()
+			if self <= 0 && node.computation == 0 {
+				return
+			}
+
+			// pprof stacks start with the leaf
+			var locationIDs []byte
+			for i := len(stack) - 1; i >= 0; i-- {
+				locationIDs = protowire.AppendVarint(locationIDs, functionID(stack[i]))
+			}
+
+			sample := appendMessage(nil, 1, locationIDs)
+
+			var values []byte
+			values = protowire.AppendVarint(values, uint64(self.Nanoseconds()))
+			values = protowire.AppendVarint(values, node.computation)
+			sample = appendMessage(sample, 2, values)
+
+			out = appendMessage(out, 2, sample)
+		})
+	}
+
+	names := make([]string, len(functionIDs))
+	for name, id := range functionIDs {
+		names[id-1] = name
+	}
+
+	for i, name := range names {
+		id := uint64(i + 1)
+
+		line := appendVarint(nil, 1, id)
+
+		location := appendVarint(nil, 1, id)
+		location = appendMessage(location, 4, line)
+		out = appendMessage(out, 4, location)
+
+		function := appendVarint(nil, 1, id)
+		function = appendVarint(function, 2, internString(name))
+		function = appendVarint(function, 3, internString(name))
+		out = appendMessage(out, 5, function)
+	}
+
+	for _, s := range stringTable {
+		out = protowire.AppendTag(out, 6, protowire.BytesType)
+		out = protowire.AppendString(out, s)
+	}
+
+	out = appendVarint(out, 10, uint64(totalDuration.Nanoseconds()))
+	out = appendMessage(out, 11, valueType)
+	out = appendVarint(out, 12, 1)
+
+	gz := gzip.NewWriter(w)
+
+	_, err := gz.Write(out)
+	if err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// Profiler records profiles of the transactions and scripts executed by a blockchain.
+//
+// Profiled executions are serialized, so that all recorded operations can be
+// attributed to the procedure being executed.
+type Profiler struct {
+	// serializes profiled executions
+	runMu   sync.Mutex
+	current *profileRecorder
+
+	mu       sync.RWMutex
+	profiles map[flowgo.Identifier]*Profile
+	order    []flowgo.Identifier
+}
+
+type profileRecorder struct {
+	start time.Time
+	spans []ProfileSpan
+}
+
+// NewProfiler returns a new profiler without any recorded profiles.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		profiles: make(map[flowgo.Identifier]*Profile),
+	}
+}
+
+// Profile returns the latest profile recorded for a transaction or script ID.
+func (p *Profiler) Profile(id flowgo.Identifier) (*Profile, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	profile, ok := p.profiles[id]
+	return profile, ok
+}
+
+// Profiles returns all recorded profiles in the order they were recorded.
+func (p *Profiler) Profiles() []*Profile {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	profiles := make([]*Profile, 0, len(p.order))
+	for _, id := range p.order {
+		profiles = append(profiles, p.profiles[id])
+	}
+
+	return profiles
+}
+
+// Reset removes all recorded profiles.
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.profiles = make(map[flowgo.Identifier]*Profile)
+	p.order = nil
+}
+
+// profile runs a procedure and records its profile.
+func (p *Profiler) profile(id flowgo.Identifier, kind ProfileKind, run func() (uint64, error)) error {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	recorder := &profileRecorder{start: time.Now()}
+
+	p.current = recorder
+	computationUsed, err := run()
+	p.current = nil
+
+	profile := &Profile{
+		ID:              id,
+		Kind:            kind,
+		ComputationUsed: computationUsed,
+		Duration:        time.Since(recorder.start),
+		Spans:           recorder.spans,
+	}
+
+	p.mu.Lock()
+	if _, ok := p.profiles[id]; !ok {
+		p.order = append(p.order, id)
+	}
+	p.profiles[id] = profile
+	p.mu.Unlock()
+
+	return err
+}
+
+// record adds an operation that just completed to the profile being recorded.
+func (p *Profiler) record(name string, duration time.Duration) {
+	p.recordComputation(name, duration, 0)
+}
+
+func (p *Profiler) recordComputation(name string, duration time.Duration, computation uint64) {
+	recorder := p.current
+	if recorder == nil {
+		return
+	}
+
+	end := time.Since(recorder.start)
+
+	recorder.spans = append(recorder.spans, ProfileSpan{
+		Name:        name,
+		Start:       end - duration,
+		Duration:    duration,
+		Computation: computation,
+	})
+}
+
+var _ callObserver = &Profiler{}
+
+func (p *Profiler) functionReturned(call functionCall) {
+	p.recordComputation(call.function.String(), call.duration, call.computation)
+}
+
+// profilingRuntime wraps a Cadence runtime to record the operations of the programs it executes.
+type profilingRuntime struct {
+	runtime.Runtime
+	profiler *Profiler
+}
+
+func newProfilingRuntime(rt runtime.Runtime, profiler *Profiler) *profilingRuntime {
+	return &profilingRuntime{
+		Runtime:  rt,
+		profiler: profiler,
+	}
+}
+
+func (r *profilingRuntime) ExecuteScript(script runtime.Script, context runtime.Context) (cadence.Value, error) {
+	context.Interface = &profilingInterface{Interface: context.Interface, profiler: r.profiler}
+
+	start := time.Now()
+	defer func() {
+		r.profiler.record("cadence.script", time.Since(start))
+	}()
+
+	return r.Runtime.ExecuteScript(script, context)
+}
+
+func (r *profilingRuntime) ExecuteTransaction(script runtime.Script, context runtime.Context) error {
+	context.Interface = &profilingInterface{Interface: context.Interface, profiler: r.profiler}
+
+	start := time.Now()
+	defer func() {
+		r.profiler.record("cadence.transaction", time.Since(start))
+	}()
+
+	return r.Runtime.ExecuteTransaction(script, context)
+}
+
+// profilingInterface wraps a runtime interface to time FVM operations.
+type profilingInterface struct {
+	runtime.Interface
+	profiler *Profiler
+}
+
+func (i *profilingInterface) GetValue(owner, key []byte) ([]byte, error) {
+	start := time.Now()
+	defer func() {
+		i.profiler.record("fvm.GetValue", time.Since(start))
+	}()
+
+	return i.Interface.GetValue(owner, key)
+}
+
+func (i *profilingInterface) SetValue(owner, key, value []byte) error {
+	start := time.Now()
+	defer func() {
+		i.profiler.record("fvm.SetValue", time.Since(start))
+	}()
+
+	return i.Interface.SetValue(owner, key, value)
+}
+
+func (i *profilingInterface) ValueExists(owner, key []byte) (bool, error) {
+	start := time.Now()
+	defer func() {
+		i.profiler.record("fvm.ValueExists", time.Since(start))
+	}()
+
+	return i.Interface.ValueExists(owner, key)
+}
+
+func (i *profilingInterface) CreateAccount(payer runtime.Address) (runtime.Address, error) {
+	start := time.Now()
+	defer func() {
+		i.profiler.record("fvm.CreateAccount", time.Since(start))
+	}()
+
+	return i.Interface.CreateAccount(payer)
+}
+
+func (i *profilingInterface) GetAccountContractCode(address runtime.Address, name string) ([]byte, error) {
+	start := time.Now()
+	defer func() {
+		i.profiler.record("fvm.GetAccountContractCode", time.Since(start))
+	}()
+
+	return i.Interface.GetAccountContractCode(address, name)
+}
+
+func (i *profilingInterface) UpdateAccountContractCode(address runtime.Address, name string, code []byte) error {
+	start := time.Now()
+	defer func() {
+		i.profiler.record("fvm.UpdateAccountContractCode", time.Since(start))
+	}()
+
+	return i.Interface.UpdateAccountContractCode(address, name, code)
+}
+
+func (i *profilingInterface) EmitEvent(event cadence.Event) error {
+	start := time.Now()
+	defer func() {
+		i.profiler.record("fvm.EmitEvent", time.Since(start))
+	}()
+
+	return i.Interface.EmitEvent(event)
+}
+
+func (i *profilingInterface) GenerateUUID() (uint64, error) {
+	start := time.Now()
+	defer func() {
+		i.profiler.record("fvm.GenerateUUID", time.Since(start))
+	}()
+
+	return i.Interface.GenerateUUID()
+}
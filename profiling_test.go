@@ -0,0 +1,210 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/templates"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+	convert "github.com/onflow/flow-emulator/convert/sdk"
+)
+
+func TestProfiler(t *testing.T) {
+
+	t.Parallel()
+
+	profiler := emulator.NewProfiler()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+		emulator.WithProfiler(profiler),
+	)
+	require.NoError(t, err)
+
+	assert.Same(t, profiler, b.Profiler())
+
+	contract := `
+		pub contract Test {
+			pub fun count(to n: Int): Int {
+				var i = 0
+				while i < n {
+					i = i + 1
+				}
+				return i
+			}
+		}
+	`
+
+	address, err := b.CreateAccount(
+		[]*flow.AccountKey{b.ServiceKey().AccountKey()},
+		[]templates.Contract{{Name: "Test", Source: contract}},
+	)
+	require.NoError(t, err)
+
+	tx := flow.NewTransaction().
+		SetScript([]byte(fmt.Sprintf(`
+			import Test from 0x%s
+
+			transaction {
+				prepare(signer: AuthAccount) {
+					signer.save(Test.count(to: 10), to: /storage/answer)
+				}
+			}
+		`, address.Hex()))).
+		SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+		SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+		SetPayer(b.ServiceKey().Address).
+		AddAuthorizer(b.ServiceKey().Address)
+
+	err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+	require.NoError(t, err)
+
+	err = b.AddTransaction(*tx)
+	require.NoError(t, err)
+
+	result, err := b.ExecuteNextTransaction()
+	require.NoError(t, err)
+	require.NoError(t, result.Error)
+
+	_, err = b.CommitBlock()
+	require.NoError(t, err)
+
+	script := []byte(fmt.Sprintf(`
+		import Test from 0x%s
+
+		pub fun main(): Int {
+			return Test.count(to: 5)
+		}
+	`, address.Hex()))
+
+	scriptResult, err := b.ExecuteScript(script, nil)
+	require.NoError(t, err)
+	require.NoError(t, scriptResult.Error)
+
+	txID := convert.SDKIdentifierToFlow(tx.ID())
+	scriptID := convert.SDKIdentifierToFlow(scriptResult.ScriptID)
+
+	countName := fmt.Sprintf("A.%s.Test:Test.count", address.Hex())
+
+	functionSpan := func(profile *emulator.Profile, name string) *emulator.ProfileSpan {
+		for i, span := range profile.Spans {
+			if span.Name == name {
+				return &profile.Spans[i]
+			}
+		}
+		return nil
+	}
+
+	t.Run("should record transaction profile", func(t *testing.T) {
+		profile, ok := profiler.Profile(txID)
+		require.True(t, ok)
+
+		assert.Equal(t, emulator.ProfileKindTransaction, profile.Kind)
+		assert.Equal(t, result.ComputationUsed, profile.ComputationUsed)
+		assert.Greater(t, int64(profile.Duration), int64(0))
+		assert.NotEmpty(t, profile.Spans)
+	})
+
+	t.Run("should record computation of function calls", func(t *testing.T) {
+		profile, ok := profiler.Profile(txID)
+		require.True(t, ok)
+
+		prepare := functionSpan(profile, fmt.Sprintf("t.%s:prepare", txID))
+		require.NotNil(t, prepare)
+
+		count := functionSpan(profile, countName)
+		require.NotNil(t, count)
+
+		// the declaration, the loop, its 10 body statements and the return
+		assert.Equal(t, uint64(13), count.Computation)
+
+		// the call is nested in the prepare function
+		assert.GreaterOrEqual(t, int64(count.Start), int64(prepare.Start))
+		assert.LessOrEqual(t, int64(count.Start+count.Duration), int64(prepare.Start+prepare.Duration))
+
+		assert.LessOrEqual(t, prepare.Computation+count.Computation, profile.ComputationUsed)
+	})
+
+	t.Run("should record script profile", func(t *testing.T) {
+		profile, ok := profiler.Profile(scriptID)
+		require.True(t, ok)
+
+		assert.Equal(t, emulator.ProfileKindScript, profile.Kind)
+		assert.Greater(t, int64(profile.Duration), int64(0))
+		assert.Greater(t, profile.ComputationUsed, uint64(0))
+
+		count := functionSpan(profile, countName)
+		require.NotNil(t, count)
+		assert.Equal(t, uint64(8), count.Computation)
+	})
+
+	t.Run("should write folded stacks", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := emulator.WriteFoldedStacks(&buf, emulator.ProfileValueWallTime, profiler.Profiles()...)
+		require.NoError(t, err)
+
+		assert.Contains(t, buf.String(), fmt.Sprintf("transaction:%s", txID))
+		assert.Contains(t, buf.String(), fmt.Sprintf("script:%s", scriptID))
+	})
+
+	t.Run("should write folded stacks of computation", func(t *testing.T) {
+		profile, ok := profiler.Profile(txID)
+		require.True(t, ok)
+
+		var buf bytes.Buffer
+		err := emulator.WriteFoldedStacks(&buf, emulator.ProfileValueComputation, profile)
+		require.NoError(t, err)
+
+		assert.Contains(
+			t,
+			buf.String(),
+			fmt.Sprintf("transaction:%s;cadence.transaction;t.%s:prepare;%s 13\n", txID, txID, countName),
+		)
+	})
+
+	t.Run("should write pprof profile", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := emulator.WritePprof(&buf, profiler.Profiles()...)
+		require.NoError(t, err)
+
+		reader, err := gzip.NewReader(&buf)
+		require.NoError(t, err)
+
+		decoded, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		assert.NotEmpty(t, decoded)
+	})
+
+	t.Run("should reset profiles", func(t *testing.T) {
+		profiler.Reset()
+		assert.Empty(t, profiler.Profiles())
+
+		_, ok := profiler.Profile(txID)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,167 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/common"
+	sdk "github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+)
+
+// Keys of the registers that hold the account metadata.
+const (
+	registerKeyExists         = "exists"
+	registerKeyFrozen         = "frozen"
+	registerKeyStorageUsed    = "storage_used"
+	registerKeyStorageIndex   = "storage_index"
+	registerKeyPublicKeyCount = "public_key_count"
+	registerKeyContractNames  = "contract_names"
+	registerKeyCodePrefix     = "code."
+)
+
+// An AccountRegister is a ledger register owned by an account.
+type AccountRegister struct {
+	ID flowgo.RegisterID
+	// Value is the raw register value.
+	Value flowgo.RegisterValue
+	// Decoded is the register value as a Cadence value, or nil if the value could not be decoded.
+	//
+	// Account metadata registers are decoded to their values, and the registers of storage,
+	// public and private paths are decoded to the value or link stored at the path. Values
+	// stored by Cadence may span further storage slab registers, which are not decoded
+	// individually and are only available as raw bytes.
+	Decoded cadence.Value
+}
+
+// GetAccountRegisters returns all registers owned by an account at the given block height,
+// ordered by controller and key.
+func (b *Blockchain) GetAccountRegisters(address sdk.Address, blockHeight uint64) ([]AccountRegister, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	block, err := b.getBlockByHeight(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	flowAddress := sdkconvert.SDKAddressToFlow(address)
+
+	entries, err := b.storage.RegistersByOwner(flowAddress, blockHeight)
+	if err != nil {
+		return nil, &StorageError{err}
+	}
+
+	// reading storage may allocate storage indices, so read from a child view that is discarded
+	env := b.storageEnvironment(block, b.storage.LedgerViewByHeight(blockHeight).NewChild())
+
+	// storage is read without instrumenting the contracts of stored values
+	rt := runtime.NewInterpreterRuntime()
+
+	registers := make([]AccountRegister, len(entries))
+	for i, entry := range entries {
+		decoded := decodeRegister(entry.Key.Key, entry.Value)
+
+		if path, ok := registerStoragePath(entry.Key.Key); ok && decoded == nil {
+			decoded = decodeStoredRegister(rt, env, flowAddress, path)
+		}
+
+		registers[i] = AccountRegister{
+			ID:      entry.Key,
+			Value:   entry.Value,
+			Decoded: decoded,
+		}
+	}
+
+	return registers, nil
+}
+
+// decodeStoredRegister decodes the value stored at a path of an account with the Cadence decoder,
+// including the storage slabs it spans. It returns nil if the value cannot be decoded.
+func decodeStoredRegister(
+	rt runtime.Runtime,
+	env runtime.Interface,
+	address flowgo.Address,
+	path cadence.Path,
+) cadence.Value {
+	value, err := readStored(rt, env, common.Address(address), path)
+	if err != nil {
+		return nil
+	}
+
+	// values are read as optionals, which are nil if nothing is stored at the path
+	if optional, ok := value.(cadence.Optional); ok {
+		return optional.Value
+	}
+
+	return value
+}
+
+// decodeRegister decodes the value of an account metadata register.
+//
+// Values stored by Cadence are split across registers holding storage slabs,
+// which cannot be decoded individually, so nil is returned for them.
+func decodeRegister(key string, value flowgo.RegisterValue) cadence.Value {
+	switch key {
+	case registerKeyExists, registerKeyFrozen:
+		if len(value) == 1 {
+			return cadence.NewBool(value[0] != 0)
+		}
+
+	case registerKeyStorageUsed, registerKeyStorageIndex:
+		if len(value) == 8 {
+			return cadence.NewUInt64(binary.BigEndian.Uint64(value))
+		}
+
+	case registerKeyPublicKeyCount:
+		// the count is stored as a big-endian integer without leading zeros
+		count := new(big.Int).SetBytes(value)
+		if count.IsUint64() {
+			return cadence.NewUInt64(count.Uint64())
+		}
+
+	case registerKeyContractNames:
+		var names []string
+		err := cbor.Unmarshal(value, &names)
+		if err != nil {
+			return nil
+		}
+
+		values := make([]cadence.Value, len(names))
+		for i, name := range names {
+			values[i] = cadence.String(name)
+		}
+
+		return cadence.NewArray(values)
+
+	default:
+		if strings.HasPrefix(key, registerKeyCodePrefix) {
+			return cadence.String(value)
+		}
+	}
+
+	return nil
+}
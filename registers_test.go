@@ -0,0 +1,93 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+func TestGetAccountRegisters(t *testing.T) {
+
+	t.Parallel()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+	)
+	require.NoError(t, err)
+
+	latestBlock, err := b.GetLatestBlock()
+	require.NoError(t, err)
+
+	creationHeight := latestBlock.Header.Height
+
+	contract := `pub contract Test {}`
+
+	address, err := b.CreateAccount(
+		[]*flow.AccountKey{b.ServiceKey().AccountKey()},
+		[]templates.Contract{{Name: "Test", Source: contract}},
+	)
+	require.NoError(t, err)
+
+	_, err = b.SetAccountStorage(
+		address,
+		cadence.Path{Domain: "storage", Identifier: "answer"},
+		cadence.NewInt(42),
+	)
+	require.NoError(t, err)
+
+	latestBlock, err = b.GetLatestBlock()
+	require.NoError(t, err)
+
+	t.Run("should list and decode registers", func(t *testing.T) {
+		registers, err := b.GetAccountRegisters(address, latestBlock.Header.Height)
+		require.NoError(t, err)
+
+		decoded := make(map[string]cadence.Value)
+		for _, register := range registers {
+			assert.Equal(t, string(address.Bytes()), register.ID.Owner)
+			assert.NotEmpty(t, register.Value)
+
+			decoded[register.ID.Key] = register.Decoded
+		}
+
+		assert.Equal(t, cadence.NewBool(true), decoded["exists"])
+		assert.Equal(t, cadence.String(contract), decoded["code.Test"])
+		assert.Equal(t, cadence.NewArray([]cadence.Value{cadence.String("Test")}), decoded["contract_names"])
+		assert.Equal(t, cadence.NewUInt64(1), decoded["public_key_count"])
+		assert.Equal(t, cadence.NewInt(42), decoded["storage\x1fanswer"])
+	})
+
+	t.Run("should not list registers before account creation", func(t *testing.T) {
+		registers, err := b.GetAccountRegisters(address, creationHeight)
+		require.NoError(t, err)
+		assert.Empty(t, registers)
+	})
+
+	t.Run("should fail for non-existent block", func(t *testing.T) {
+		_, err := b.GetAccountRegisters(address, latestBlock.Header.Height+1)
+		assert.IsType(t, &emulator.BlockNotFoundByHeightError{}, err)
+	})
+}
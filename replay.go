@@ -0,0 +1,117 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"github.com/onflow/cadence/runtime"
+	sdk "github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/programs"
+
+	"github.com/onflow/flow-emulator/convert"
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+	"github.com/onflow/flow-emulator/types"
+)
+
+// ReplayTransaction re-executes a committed transaction and returns its result,
+// including its state delta and execution trace.
+//
+// The ledger state at the start of the transaction's block is rebuilt by re-executing
+// the preceding transactions of the block in order. Signatures are not verified again,
+// and the stored state is not modified.
+func (b *Blockchain) ReplayTransaction(txID sdk.Identifier) (*types.TransactionResult, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	flowTxID := sdkconvert.SDKIdentifierToFlow(txID)
+
+	block, txIDs, err := b.findTransactionBlock(flowTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a separate VM traces the replayed transaction, regardless of the blockchain's configuration
+	tracer := newTracer(b.traceValuesEnabled)
+	rt, err := newInstrumentingRuntime(runtime.NewInterpreterRuntime(), nil, []callObserver{tracer})
+	if err != nil {
+		return nil, err
+	}
+
+	vm := fvm.NewVirtualMachine(newTracingRuntime(rt, tracer))
+
+	ctx := fvm.NewContextFromParent(
+		withoutSignatureVerification(b.vmCtx),
+		fvm.WithBlockHeader(block.Header),
+	)
+
+	ledgerView := b.storage.LedgerViewByHeight(block.Header.Height - 1)
+
+	for i, id := range txIDs {
+		tx, err := b.storage.TransactionByID(id)
+		if err != nil {
+			return nil, &StorageError{err}
+		}
+
+		// transaction indices in a block start at 1
+		tp := fvm.Transaction(&tx, uint32(i+1))
+		view := ledgerView.NewChild()
+
+		if id != flowTxID {
+			err = vm.Run(ctx, tp, view, programs.NewEmptyPrograms())
+			if err != nil {
+				return nil, err
+			}
+
+			err = ledgerView.MergeView(view)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		trace, err := tracer.trace(func() error {
+			return vm.Run(ctx, tp, view, programs.NewEmptyPrograms())
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		tr, err := convert.VMTransactionResultToEmulator(tp)
+		if err != nil {
+			return nil, err
+		}
+
+		if tr.Error != nil {
+			tr.Debug = b.debugSignatureError(tr.Error, tp.Transaction)
+		}
+
+		tr.StateDelta, err = newTransactionStateDelta(ledgerView, view)
+		if err != nil {
+			return nil, err
+		}
+
+		tr.Trace = trace
+		tr.BlockID = sdkconvert.FlowIdentifierToSDK(block.ID())
+		tr.BlockHeight = block.Header.Height
+
+		return tr, nil
+	}
+
+	return nil, &TransactionNotFoundError{ID: flowTxID}
+}
@@ -0,0 +1,126 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+func TestReplayTransaction(t *testing.T) {
+
+	t.Parallel()
+
+	addTransaction := func(t *testing.T, b *emulator.Blockchain, script string, sequenceNumber uint64) *flow.Transaction {
+		tx := flow.NewTransaction().
+			SetScript([]byte(script)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, sequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(b.ServiceKey().Address)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		return tx
+	}
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+	)
+	require.NoError(t, err)
+
+	sequenceNumber := b.ServiceKey().SequenceNumber
+
+	saveTx := addTransaction(t, b, `
+		transaction {
+			prepare(signer: AuthAccount) {
+				signer.save("Hello, World!", to: /storage/greeting)
+			}
+		}
+	`, sequenceNumber)
+
+	loadTx := addTransaction(t, b, `
+		transaction {
+			prepare(signer: AuthAccount) {
+				log(signer.load<String>(from: /storage/greeting))
+			}
+		}
+	`, sequenceNumber+1)
+
+	_, results, err := b.ExecuteAndCommitBlock()
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assertTransactionSucceeded(t, results[0])
+	assertTransactionSucceeded(t, results[1])
+
+	latestBlock, err := b.GetLatestBlock()
+	require.NoError(t, err)
+
+	t.Run("should replay transaction after preceding transactions of block", func(t *testing.T) {
+		result, err := b.ReplayTransaction(loadTx.ID())
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+
+		assert.Equal(t, loadTx.ID(), result.TransactionID)
+		assert.Equal(t, results[1].Logs, result.Logs)
+		assert.Equal(t, results[1].ComputationUsed, result.ComputationUsed)
+
+		require.NotNil(t, result.StateDelta)
+		assert.NotEmpty(t, result.StateDelta.Registers)
+
+		require.NotNil(t, result.Trace)
+		assert.NotEmpty(t, result.Trace.Entries)
+	})
+
+	t.Run("should replay first transaction of block", func(t *testing.T) {
+		result, err := b.ReplayTransaction(saveTx.ID())
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+
+		assert.Equal(t, results[0].Events, result.Events)
+	})
+
+	t.Run("should not modify stored state", func(t *testing.T) {
+		block, err := b.GetLatestBlock()
+		require.NoError(t, err)
+		assert.Equal(t, latestBlock.ID(), block.ID())
+
+		// the greeting was loaded by the committed transaction, and not saved again by the replay
+		values, err := b.GetAccountStorage(b.ServiceKey().Address, block.Header.Height)
+		require.NoError(t, err)
+
+		for _, value := range values {
+			assert.NotEqual(t, "greeting", value.Path.Identifier)
+		}
+	})
+
+	t.Run("should fail for unknown transaction", func(t *testing.T) {
+		_, err := b.ReplayTransaction(flow.EmptyID)
+		assert.IsType(t, &emulator.TransactionNotFoundError{}, err)
+	})
+}
@@ -92,12 +92,14 @@ func (a *Adapter) GetTransaction(ctx context.Context, id flowgo.Identifier) (*fl
 }
 
 func (a *Adapter) GetTransactionResult(ctx context.Context, id flowgo.Identifier) (*access.TransactionResult, error) {
-	result, err := a.backend.GetTransactionResult(ctx, convert.FlowIdentifierToSDK(id))
+	sdkID := convert.FlowIdentifierToSDK(id)
+
+	result, err := a.backend.GetTransactionResult(ctx, sdkID)
 	if err != nil {
 		return nil, err
 	}
 
-	flowResult, err := convert.SDKTransactionResultToFlow(result)
+	flowResult, err := a.backend.TransactionResultToFlow(sdkID, result)
 	if err != nil {
 		return nil, err
 	}
@@ -23,8 +23,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/logrusorgru/aurora"
+	"github.com/onflow/cadence"
 	jsoncdc "github.com/onflow/cadence/encoding/json"
 	sdk "github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go/access"
@@ -36,6 +38,7 @@ import (
 
 	emulator "github.com/onflow/flow-emulator"
 	convert "github.com/onflow/flow-emulator/convert/sdk"
+	"github.com/onflow/flow-emulator/storage"
 	"github.com/onflow/flow-emulator/types"
 )
 
@@ -285,6 +288,77 @@ func (b *Backend) GetTransactionResult(
 	return result, nil
 }
 
+// GetTransactionExecutionResult returns the full result of an executed transaction,
+// including its logs, computation used and debug information.
+func (b *Backend) GetTransactionExecutionResult(id sdk.Identifier) (*types.TransactionResult, error) {
+	b.logger.
+		WithField("txID", id.String()).
+		Debugf("📝  GetTransactionExecutionResult called")
+
+	return b.emulator.GetTransactionExecutionResult(id)
+}
+
+// GetTransactionBlock returns the block that includes a committed transaction,
+// along with the position of the transaction in the block.
+func (b *Backend) GetTransactionBlock(id sdk.Identifier) (*flowgo.Block, uint32, error) {
+	b.logger.
+		WithField("txID", id.String()).
+		Debugf("📝  GetTransactionBlock called")
+
+	return b.emulator.GetTransactionBlock(id)
+}
+
+// GetTransactionsByBlockHeight returns the transactions of the block at the given height,
+// along with their results, in execution order.
+func (b *Backend) GetTransactionsByBlockHeight(height uint64) ([]emulator.BlockTransaction, error) {
+	b.logger.
+		WithField("blockHeight", height).
+		Debugf("📝  GetTransactionsByBlockHeight called")
+
+	return b.emulator.GetTransactionsByBlockHeight(height)
+}
+
+// TransactionResultToFlow converts a transaction result to its Access API representation.
+//
+// The SDK result does not identify the block that includes the transaction, so it is
+// looked up for sealed transactions.
+func (b *Backend) TransactionResultToFlow(
+	id sdk.Identifier,
+	result *sdk.TransactionResult,
+) (*access.TransactionResult, error) {
+	flowResult, err := convert.SDKTransactionResultToFlow(result)
+	if err != nil {
+		return nil, err
+	}
+
+	if flowResult.Status != flowgo.TransactionStatusSealed {
+		return flowResult, nil
+	}
+
+	block, _, err := b.emulator.GetTransactionBlock(id)
+	if err != nil {
+		return nil, err
+	}
+
+	flowResult.BlockID = block.ID()
+
+	return flowResult, nil
+}
+
+// GetTransactionsByAccount returns a page of the committed transactions involving an account,
+// newest first.
+func (b *Backend) GetTransactionsByAccount(
+	address sdk.Address,
+	cursor *storage.AccountTransactionCursor,
+	limit int,
+) ([]storage.AccountTransaction, error) {
+	b.logger.
+		WithField("address", address).
+		Debugf("📝  GetTransactionsByAccount called")
+
+	return b.emulator.GetTransactionsByAccount(address, cursor, limit)
+}
+
 // GetAccount returns an account by address at the latest sealed block.
 func (b *Backend) GetAccount(
 	ctx context.Context,
@@ -433,6 +507,12 @@ func (b *Backend) GetEventsForHeightRange(
 		return nil, status.Error(codes.InvalidArgument, "invalid query: start block must be <= end block")
 	}
 
+	// fetch the events of the whole range at once, rather than block by block
+	eventsByHeight, err := b.emulator.GetEventsByHeightRange(eventType, startHeight, endHeight)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
 	results := make([]flowgo.BlockEvents, 0)
 	eventCount := 0
 
@@ -447,10 +527,7 @@ func (b *Backend) GetEventsForHeightRange(
 			}
 		}
 
-		events, err := b.emulator.GetEventsByHeight(height, eventType)
-		if err != nil {
-			return nil, status.Error(codes.Internal, err.Error())
-		}
+		events := eventsByHeight[height]
 
 		flowEvents, err := convert.SDKEventsToFlow(events)
 		if err != nil {
@@ -560,6 +637,346 @@ func (b *Backend) CommitBlock() {
 	}).Debugf("📦  Block #%d committed", block.Header.Height)
 }
 
+// CommitEmptyBlocks commits the given number of empty blocks, spacing their timestamps by the interval if positive.
+func (b *Backend) CommitEmptyBlocks(count uint64, interval time.Duration) (*flowgo.Block, error) {
+	block, err := b.emulator.CommitEmptyBlocks(count, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	blockID := block.ID()
+
+	b.logger.WithFields(logrus.Fields{
+		"count":       count,
+		"blockHeight": block.Header.Height,
+		"blockID":     hex.EncodeToString(blockID[:]),
+	}).Debugf("📦  %d empty blocks committed up to block #%d", count, block.Header.Height)
+
+	return block, nil
+}
+
+// Snapshot creates a snapshot of the emulator state with the given name, or reverts
+// the emulator state to the snapshot if it already exists.
+func (b *Backend) Snapshot(name string) error {
+	err := b.emulator.Snapshot(name)
+	if err != nil {
+		return err
+	}
+
+	b.logger.
+		WithField("name", name).
+		Debugf("📸  Jumped to snapshot %s", name)
+
+	return nil
+}
+
+// RollbackToHeight reverts the emulator state to the block at the given height.
+func (b *Backend) RollbackToHeight(height uint64) error {
+	err := b.emulator.RollbackToHeight(height)
+	if err != nil {
+		return err
+	}
+
+	b.logger.
+		WithField("blockHeight", height).
+		Debugf("⏪  Rolled back to block #%d", height)
+
+	return nil
+}
+
+// Impersonate accepts transactions of the given account without valid signatures.
+func (b *Backend) Impersonate(address sdk.Address) {
+	b.emulator.Impersonate(address)
+
+	b.logger.
+		WithField("address", address.Hex()).
+		Debugf("🎭  Impersonating account %s", address.Hex())
+}
+
+// StopImpersonating verifies the signatures of transactions of the given account again.
+func (b *Backend) StopImpersonating(address sdk.Address) {
+	b.emulator.StopImpersonating(address)
+
+	b.logger.
+		WithField("address", address.Hex()).
+		Debugf("🎭  Stopped impersonating account %s", address.Hex())
+}
+
+// ImpersonatedAccounts returns the addresses of the impersonated accounts.
+func (b *Backend) ImpersonatedAccounts() []sdk.Address {
+	return b.emulator.ImpersonatedAccounts()
+}
+
+// GetAccountRegisters returns the registers owned by an account at the given block height.
+func (b *Backend) GetAccountRegisters(address sdk.Address, blockHeight uint64) ([]emulator.AccountRegister, error) {
+	b.logger.
+		WithField("address", address).
+		WithField("height", blockHeight).
+		Debugf("👤  GetAccountRegisters called")
+
+	return b.emulator.GetAccountRegisters(address, blockHeight)
+}
+
+// GetAccountStorage returns the values stored in an account at the given block height.
+func (b *Backend) GetAccountStorage(address sdk.Address, blockHeight uint64) ([]emulator.StoredValue, error) {
+	b.logger.
+		WithField("address", address).
+		WithField("height", blockHeight).
+		Debugf("👤  GetAccountStorage called")
+
+	return b.emulator.GetAccountStorage(address, blockHeight)
+}
+
+// DiffState returns the changes of the ledger state made by the blocks after the start height,
+// up to and including the end height.
+func (b *Backend) DiffState(startHeight, endHeight uint64) (*emulator.StateDiff, error) {
+	b.logger.
+		WithField("startHeight", startHeight).
+		WithField("endHeight", endHeight).
+		Debugf("🔍  DiffState called")
+
+	return b.emulator.DiffState(startHeight, endHeight)
+}
+
+// GetTransactionStateDelta returns the ledger changes of an executed transaction,
+// or nil if state deltas were not recorded for it.
+func (b *Backend) GetTransactionStateDelta(txID sdk.Identifier) (*types.TransactionStateDelta, error) {
+	b.logger.
+		WithField("txID", txID.String()).
+		Debugf("🔍  GetTransactionStateDelta called")
+
+	return b.emulator.GetTransactionStateDelta(txID)
+}
+
+// GetTransactionTrace returns the execution trace of a transaction,
+// or nil if traces were not recorded for it.
+func (b *Backend) GetTransactionTrace(txID sdk.Identifier) (*types.TransactionTrace, error) {
+	b.logger.
+		WithField("txID", txID.String()).
+		Debugf("🔍  GetTransactionTrace called")
+
+	return b.emulator.GetTransactionTrace(txID)
+}
+
+// ReplayTransaction re-executes a committed transaction with tracing, without modifying the stored state.
+func (b *Backend) ReplayTransaction(txID sdk.Identifier) (*types.TransactionResult, error) {
+	b.logger.
+		WithField("txID", txID.String()).
+		Debugf("🔁  ReplayTransaction called")
+
+	result, err := b.emulator.ReplayTransaction(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	printTransactionResult(b.logger, result)
+
+	return result, nil
+}
+
+// SimulateTransaction executes a transaction against the pending block without changing the chain state.
+func (b *Backend) SimulateTransaction(tx sdk.Transaction) (*types.TransactionResult, error) {
+	b.logger.
+		WithField("txID", tx.ID().String()).
+		Debugf("🧪  SimulateTransaction called")
+
+	result, err := b.emulator.SimulateTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	printTransactionResult(b.logger, result)
+
+	return result, nil
+}
+
+// EstimateComputation returns the computation used by a transaction at the latest state,
+// and a gas limit with the given safety margin in percent.
+func (b *Backend) EstimateComputation(tx sdk.Transaction, safetyMargin uint64) (*emulator.ComputationEstimate, error) {
+	estimate, err := b.emulator.EstimateComputation(tx, safetyMargin)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logger.
+		WithField("txID", tx.ID().String()).
+		WithField("computationUsed", estimate.ComputationUsed).
+		WithField("gasLimit", estimate.GasLimit).
+		Debugf("📏  EstimateComputation called")
+
+	return estimate, nil
+}
+
+// SetAccountBalance sets the FLOW balance of an account and commits the change as a new block.
+func (b *Backend) SetAccountBalance(address sdk.Address, balance cadence.UFix64) (*flowgo.Block, error) {
+	block, err := b.emulator.SetAccountBalance(address, balance)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logAccountEdit(address, block, "✏️   Balance of account %s set to %s", address.Hex(), balance)
+
+	return block, nil
+}
+
+// AddAccountKey adds a public key to an account and commits the change as a new block.
+func (b *Backend) AddAccountKey(address sdk.Address, key *sdk.AccountKey) (*flowgo.Block, error) {
+	block, err := b.emulator.AddAccountKey(address, key)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logAccountEdit(address, block, "✏️   Key added to account %s", address.Hex())
+
+	return block, nil
+}
+
+// RevokeAccountKey revokes a key of an account and commits the change as a new block.
+func (b *Backend) RevokeAccountKey(address sdk.Address, keyIndex int) (*flowgo.Block, error) {
+	block, err := b.emulator.RevokeAccountKey(address, keyIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logAccountEdit(address, block, "✏️   Key %d of account %s revoked", keyIndex, address.Hex())
+
+	return block, nil
+}
+
+// SetAccountStorage writes a value to a storage path of an account and commits the change as a new block.
+func (b *Backend) SetAccountStorage(address sdk.Address, path cadence.Path, value cadence.Value) (*flowgo.Block, error) {
+	block, err := b.emulator.SetAccountStorage(address, path, value)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logAccountEdit(address, block, "✏️   Storage path %s of account %s set", path, address.Hex())
+
+	return block, nil
+}
+
+// RemoveAccountStorage removes the value at a storage path of an account and commits the change as a new block.
+func (b *Backend) RemoveAccountStorage(address sdk.Address, path cadence.Path) (*flowgo.Block, error) {
+	block, err := b.emulator.RemoveAccountStorage(address, path)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logAccountEdit(address, block, "✏️   Storage path %s of account %s removed", path, address.Hex())
+
+	return block, nil
+}
+
+func (b *Backend) logAccountEdit(address sdk.Address, block *flowgo.Block, format string, args ...interface{}) {
+	blockID := block.ID()
+
+	b.logger.WithFields(logrus.Fields{
+		"address":     address.Hex(),
+		"blockHeight": block.Header.Height,
+		"blockID":     hex.EncodeToString(blockID[:]),
+	}).Debugf(format, args...)
+}
+
+// PendingBlockTimestamp returns the timestamp of the pending block.
+func (b *Backend) PendingBlockTimestamp() time.Time {
+	return b.emulator.PendingBlockTimestamp()
+}
+
+// SetNextBlockTimestamp sets the timestamp of the pending block.
+func (b *Backend) SetNextBlockTimestamp(timestamp time.Time) error {
+	err := b.emulator.SetNextBlockTimestamp(timestamp)
+	if err != nil {
+		return err
+	}
+
+	b.logger.
+		WithField("timestamp", timestamp).
+		Debugf("⏰  Next block timestamp set to %s", timestamp)
+
+	return nil
+}
+
+// AdvanceTime moves the clock of the emulated blockchain forward by the given duration.
+func (b *Backend) AdvanceTime(duration time.Duration) error {
+	err := b.emulator.AdvanceTime(duration)
+	if err != nil {
+		return err
+	}
+
+	b.logger.
+		WithField("duration", duration).
+		Debugf("⏰  Advanced time by %s", duration)
+
+	return nil
+}
+
+// SubscribeEvents returns a subscription streaming the events of committed blocks matching a filter.
+func (b *Backend) SubscribeEvents(filter emulator.EventFilter) (*emulator.EventSubscription, error) {
+	for _, eventType := range filter.EventTypes {
+		err := validateEventType(eventType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sub, err := b.emulator.SubscribeEvents(filter)
+	if err != nil {
+		switch err.(type) {
+		case *emulator.InvalidBlockHeightRangeError:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	b.logger.WithFields(logrus.Fields{
+		"eventTypes":  filter.EventTypes,
+		"startHeight": filter.StartHeight,
+		"endHeight":   filter.EndHeight,
+	}).Debugf("📡  SubscribeEvents called")
+
+	return sub, nil
+}
+
+// WaitForTransaction blocks until a transaction is sealed and returns its result.
+func (b *Backend) WaitForTransaction(
+	ctx context.Context,
+	id sdk.Identifier,
+) (*sdk.TransactionResult, error) {
+	b.logger.
+		WithField("txID", id.String()).
+		Debugf("⏳  WaitForTransaction called")
+
+	result, err := b.emulator.WaitForTransaction(ctx, id)
+	if err != nil {
+		switch err {
+		case context.DeadlineExceeded:
+			return nil, status.Error(codes.DeadlineExceeded, err.Error())
+		case context.Canceled:
+			return nil, status.Error(codes.Canceled, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return result, nil
+}
+
+// CoverageReport returns the collected Cadence code coverage, or nil if coverage is disabled.
+func (b *Backend) CoverageReport() *emulator.CoverageReport {
+	return b.emulator.CoverageReport()
+}
+
+// Profiler returns the recorded execution profiles, or nil if profiling is disabled.
+func (b *Backend) Profiler() *emulator.Profiler {
+	return b.emulator.Profiler()
+}
+
+// Debugger returns the debugger of the emulated blockchain, or nil if debugging is disabled.
+func (b *Backend) Debugger() *emulator.Debugger {
+	return b.emulator.Debugger()
+}
+
 // executeScriptAtBlock is a helper for executing a script at a specific block
 func (b *Backend) executeScriptAtBlock(script []byte, arguments [][]byte, blockHeight uint64) ([]byte, error) {
 	result, err := b.emulator.ExecuteScriptAtBlock(script, arguments, blockHeight)
@@ -628,6 +1045,26 @@ func printTransactionResult(logger *logrus.Logger, result *types.TransactionResu
 		)
 	}
 
+	if result.StateDelta != nil {
+		for _, account := range result.StateDelta.Accounts {
+			logger.Debugf(
+				"%s %s: +%d -%d bytes, storage used %d -> %d bytes",
+				logPrefix("DLT", result.TransactionID, aurora.MagentaFg),
+				account.Address.HexWithPrefix(),
+				account.BytesAdded,
+				account.BytesRemoved,
+				account.StorageUsedBefore,
+				account.StorageUsedAfter,
+			)
+		}
+
+		logger.Debugf(
+			"%s %d registers written",
+			logPrefix("DLT", result.TransactionID, aurora.MagentaFg),
+			len(result.StateDelta.Registers),
+		)
+	}
+
 	if !result.Succeeded() {
 		logger.Warnf(
 			"%s %s",
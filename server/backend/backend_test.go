@@ -221,8 +221,7 @@ func TestBackend(t *testing.T) {
 				Return(&latestBlock, nil)
 
 			emu.EXPECT().
-				GetEventsByHeight(gomock.Any(), gomock.Any()).
-				Return([]flow.Event{}, nil).
+				GetEventsByHeightRange(gomock.Any(), gomock.Any(), gomock.Any()).
 				Times(0)
 
 			_, err := backend.GetEventsForHeightRange(context.Background(), eventType, startHeight, endHeight)
@@ -248,14 +247,13 @@ func TestBackend(t *testing.T) {
 				Return(&latestBlock, nil)
 
 			emu.EXPECT().
-				GetBlockByHeight(startBlock.Header.Height).
-				Return(&startBlock, nil).
+				GetEventsByHeightRange(eventType, startBlock.Header.Height, latestBlock.Header.Height).
+				Return(nil, errors.New("dummy")).
 				Times(1)
 
 			emu.EXPECT().
-				GetEventsByHeight(startBlock.Header.Height, gomock.Any()).
-				Return(nil, errors.New("dummy")).
-				Times(1)
+				GetBlockByHeight(gomock.Any()).
+				Times(0)
 
 			_, err := backend.GetEventsForHeightRange(
 				context.Background(),
@@ -307,12 +305,12 @@ func TestBackend(t *testing.T) {
 				Return(&blocks[1], nil)
 
 			emu.EXPECT().
-				GetEventsByHeight(blocks[0].Header.Height, eventType).
-				Return(eventsToReturn, nil)
-
-			emu.EXPECT().
-				GetEventsByHeight(blocks[1].Header.Height, eventType).
-				Return(eventsToReturn, nil)
+				GetEventsByHeightRange(eventType, blocks[0].Header.Height, blocks[1].Header.Height).
+				Return(map[uint64][]flow.Event{
+					blocks[0].Header.Height: eventsToReturn,
+					blocks[1].Header.Height: eventsToReturn,
+				}, nil).
+				Times(1)
 
 			results, err := backend.GetEventsForHeightRange(context.Background(),
 				eventType,
@@ -370,12 +368,12 @@ func TestBackend(t *testing.T) {
 				Return(&blocks[1], nil)
 
 			emu.EXPECT().
-				GetEventsByHeight(blocks[0].Header.Height, eventType).
-				Return(eventsToReturn, nil)
-
-			emu.EXPECT().
-				GetEventsByHeight(blocks[1].Header.Height, eventType).
-				Return(eventsToReturn, nil)
+				GetEventsByHeightRange(eventType, blocks[0].Header.Height, blocks[1].Header.Height).
+				Return(map[uint64][]flow.Event{
+					blocks[0].Header.Height: eventsToReturn,
+					blocks[1].Header.Height: eventsToReturn,
+				}, nil).
+				Times(1)
 
 			results, err := backend.GetEventsForHeightRange(context.Background(),
 				eventType,
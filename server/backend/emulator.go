@@ -19,9 +19,15 @@
 package backend
 
 import (
+	"context"
+	"time"
+
+	"github.com/onflow/cadence"
 	sdk "github.com/onflow/flow-go-sdk"
 	flowgo "github.com/onflow/flow-go/model/flow"
 
+	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/storage"
 	"github.com/onflow/flow-emulator/types"
 )
 
@@ -31,6 +37,7 @@ type Emulator interface {
 	ExecuteNextTransaction() (*types.TransactionResult, error)
 	ExecuteBlock() ([]*types.TransactionResult, error)
 	CommitBlock() (*flowgo.Block, error)
+	CommitEmptyBlocks(count uint64, interval time.Duration) (*flowgo.Block, error)
 	ExecuteAndCommitBlock() (*flowgo.Block, []*types.TransactionResult, error)
 	GetLatestBlock() (*flowgo.Block, error)
 	GetBlockByID(id sdk.Identifier) (*flowgo.Block, error)
@@ -38,9 +45,44 @@ type Emulator interface {
 	GetCollection(colID sdk.Identifier) (*sdk.Collection, error)
 	GetTransaction(txID sdk.Identifier) (*sdk.Transaction, error)
 	GetTransactionResult(txID sdk.Identifier) (*sdk.TransactionResult, error)
+	GetTransactionExecutionResult(txID sdk.Identifier) (*types.TransactionResult, error)
+	GetTransactionBlock(txID sdk.Identifier) (*flowgo.Block, uint32, error)
+	GetTransactionsByBlockHeight(height uint64) ([]emulator.BlockTransaction, error)
+	GetTransactionsByAccount(
+		address sdk.Address,
+		cursor *storage.AccountTransactionCursor,
+		limit int,
+	) ([]storage.AccountTransaction, error)
 	GetAccount(address sdk.Address) (*sdk.Account, error)
 	GetAccountAtBlock(address sdk.Address, blockHeight uint64) (*sdk.Account, error)
+	GetAccountRegisters(address sdk.Address, blockHeight uint64) ([]emulator.AccountRegister, error)
+	GetAccountStorage(address sdk.Address, blockHeight uint64) ([]emulator.StoredValue, error)
+	DiffState(startHeight, endHeight uint64) (*emulator.StateDiff, error)
+	GetTransactionStateDelta(txID sdk.Identifier) (*types.TransactionStateDelta, error)
+	GetTransactionTrace(txID sdk.Identifier) (*types.TransactionTrace, error)
+	ReplayTransaction(txID sdk.Identifier) (*types.TransactionResult, error)
+	SimulateTransaction(tx sdk.Transaction) (*types.TransactionResult, error)
+	EstimateComputation(tx sdk.Transaction, safetyMargin uint64) (*emulator.ComputationEstimate, error)
 	GetEventsByHeight(blockHeight uint64, eventType string) ([]sdk.Event, error)
+	GetEventsByHeightRange(eventType string, startHeight, endHeight uint64) (map[uint64][]sdk.Event, error)
 	ExecuteScript(script []byte, arguments [][]byte) (*types.ScriptResult, error)
 	ExecuteScriptAtBlock(script []byte, arguments [][]byte, blockHeight uint64) (*types.ScriptResult, error)
+	Snapshot(name string) error
+	RollbackToHeight(height uint64) error
+	SubscribeEvents(filter emulator.EventFilter) (*emulator.EventSubscription, error)
+	WaitForTransaction(ctx context.Context, id sdk.Identifier) (*sdk.TransactionResult, error)
+	CoverageReport() *emulator.CoverageReport
+	Profiler() *emulator.Profiler
+	Debugger() *emulator.Debugger
+	PendingBlockTimestamp() time.Time
+	Impersonate(addresses ...sdk.Address)
+	StopImpersonating(addresses ...sdk.Address)
+	ImpersonatedAccounts() []sdk.Address
+	SetNextBlockTimestamp(timestamp time.Time) error
+	AdvanceTime(duration time.Duration) error
+	SetAccountBalance(address sdk.Address, balance cadence.UFix64) (*flowgo.Block, error)
+	AddAccountKey(address sdk.Address, key *sdk.AccountKey) (*flowgo.Block, error)
+	RevokeAccountKey(address sdk.Address, keyIndex int) (*flowgo.Block, error)
+	SetAccountStorage(address sdk.Address, path cadence.Path, value cadence.Value) (*flowgo.Block, error)
+	RemoveAccountStorage(address sdk.Address, path cadence.Path) (*flowgo.Block, error)
 }
@@ -5,11 +5,16 @@
 package mocks
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
+	cadence "github.com/onflow/cadence"
+	emulator "github.com/onflow/flow-emulator"
+	storage "github.com/onflow/flow-emulator/storage"
 	types "github.com/onflow/flow-emulator/types"
 	flow_go_sdk "github.com/onflow/flow-go-sdk"
 	flow "github.com/onflow/flow-go/model/flow"
 	reflect "reflect"
+	time "time"
 )
 
 // MockEmulator is a mock of Emulator interface
@@ -35,6 +40,21 @@ func (m *MockEmulator) EXPECT() *MockEmulatorMockRecorder {
 	return m.recorder
 }
 
+// AddAccountKey mocks base method
+func (m *MockEmulator) AddAccountKey(arg0 flow_go_sdk.Address, arg1 *flow_go_sdk.AccountKey) (*flow.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountKey", arg0, arg1)
+	ret0, _ := ret[0].(*flow.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountKey indicates an expected call of AddAccountKey
+func (mr *MockEmulatorMockRecorder) AddAccountKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountKey", reflect.TypeOf((*MockEmulator)(nil).AddAccountKey), arg0, arg1)
+}
+
 // AddTransaction mocks base method
 func (m *MockEmulator) AddTransaction(arg0 flow_go_sdk.Transaction) error {
 	m.ctrl.T.Helper()
@@ -49,6 +69,20 @@ func (mr *MockEmulatorMockRecorder) AddTransaction(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTransaction", reflect.TypeOf((*MockEmulator)(nil).AddTransaction), arg0)
 }
 
+// AdvanceTime mocks base method
+func (m *MockEmulator) AdvanceTime(arg0 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceTime", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdvanceTime indicates an expected call of AdvanceTime
+func (mr *MockEmulatorMockRecorder) AdvanceTime(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceTime", reflect.TypeOf((*MockEmulator)(nil).AdvanceTime), arg0)
+}
+
 // CommitBlock mocks base method
 func (m *MockEmulator) CommitBlock() (*flow.Block, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +98,79 @@ func (mr *MockEmulatorMockRecorder) CommitBlock() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitBlock", reflect.TypeOf((*MockEmulator)(nil).CommitBlock))
 }
 
+// CommitEmptyBlocks mocks base method
+func (m *MockEmulator) CommitEmptyBlocks(arg0 uint64, arg1 time.Duration) (*flow.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitEmptyBlocks", arg0, arg1)
+	ret0, _ := ret[0].(*flow.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommitEmptyBlocks indicates an expected call of CommitEmptyBlocks
+func (mr *MockEmulatorMockRecorder) CommitEmptyBlocks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitEmptyBlocks", reflect.TypeOf((*MockEmulator)(nil).CommitEmptyBlocks), arg0, arg1)
+}
+
+// CoverageReport mocks base method
+func (m *MockEmulator) CoverageReport() *emulator.CoverageReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CoverageReport")
+	ret0, _ := ret[0].(*emulator.CoverageReport)
+	return ret0
+}
+
+// CoverageReport indicates an expected call of CoverageReport
+func (mr *MockEmulatorMockRecorder) CoverageReport() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CoverageReport", reflect.TypeOf((*MockEmulator)(nil).CoverageReport))
+}
+
+// Debugger mocks base method
+func (m *MockEmulator) Debugger() *emulator.Debugger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Debugger")
+	ret0, _ := ret[0].(*emulator.Debugger)
+	return ret0
+}
+
+// Debugger indicates an expected call of Debugger
+func (mr *MockEmulatorMockRecorder) Debugger() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debugger", reflect.TypeOf((*MockEmulator)(nil).Debugger))
+}
+
+// DiffState mocks base method
+func (m *MockEmulator) DiffState(arg0 uint64, arg1 uint64) (*emulator.StateDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffState", arg0, arg1)
+	ret0, _ := ret[0].(*emulator.StateDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffState indicates an expected call of DiffState
+func (mr *MockEmulatorMockRecorder) DiffState(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffState", reflect.TypeOf((*MockEmulator)(nil).DiffState), arg0, arg1)
+}
+
+// EstimateComputation mocks base method
+func (m *MockEmulator) EstimateComputation(arg0 flow_go_sdk.Transaction, arg1 uint64) (*emulator.ComputationEstimate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimateComputation", arg0, arg1)
+	ret0, _ := ret[0].(*emulator.ComputationEstimate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EstimateComputation indicates an expected call of EstimateComputation
+func (mr *MockEmulatorMockRecorder) EstimateComputation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimateComputation", reflect.TypeOf((*MockEmulator)(nil).EstimateComputation), arg0, arg1)
+}
+
 // ExecuteAndCommitBlock mocks base method
 func (m *MockEmulator) ExecuteAndCommitBlock() (*flow.Block, []*types.TransactionResult, error) {
 	m.ctrl.T.Helper()
@@ -170,6 +277,36 @@ func (mr *MockEmulatorMockRecorder) GetAccountAtBlock(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountAtBlock", reflect.TypeOf((*MockEmulator)(nil).GetAccountAtBlock), arg0, arg1)
 }
 
+// GetAccountRegisters mocks base method
+func (m *MockEmulator) GetAccountRegisters(arg0 flow_go_sdk.Address, arg1 uint64) ([]emulator.AccountRegister, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountRegisters", arg0, arg1)
+	ret0, _ := ret[0].([]emulator.AccountRegister)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountRegisters indicates an expected call of GetAccountRegisters
+func (mr *MockEmulatorMockRecorder) GetAccountRegisters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountRegisters", reflect.TypeOf((*MockEmulator)(nil).GetAccountRegisters), arg0, arg1)
+}
+
+// GetAccountStorage mocks base method
+func (m *MockEmulator) GetAccountStorage(arg0 flow_go_sdk.Address, arg1 uint64) ([]emulator.StoredValue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountStorage", arg0, arg1)
+	ret0, _ := ret[0].([]emulator.StoredValue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountStorage indicates an expected call of GetAccountStorage
+func (mr *MockEmulatorMockRecorder) GetAccountStorage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountStorage", reflect.TypeOf((*MockEmulator)(nil).GetAccountStorage), arg0, arg1)
+}
+
 // GetBlockByHeight mocks base method
 func (m *MockEmulator) GetBlockByHeight(arg0 uint64) (*flow.Block, error) {
 	m.ctrl.T.Helper()
@@ -230,6 +367,21 @@ func (mr *MockEmulatorMockRecorder) GetEventsByHeight(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsByHeight", reflect.TypeOf((*MockEmulator)(nil).GetEventsByHeight), arg0, arg1)
 }
 
+// GetEventsByHeightRange mocks base method
+func (m *MockEmulator) GetEventsByHeightRange(arg0 string, arg1 uint64, arg2 uint64) (map[uint64][]flow_go_sdk.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEventsByHeightRange", arg0, arg1, arg2)
+	ret0, _ := ret[0].(map[uint64][]flow_go_sdk.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEventsByHeightRange indicates an expected call of GetEventsByHeightRange
+func (mr *MockEmulatorMockRecorder) GetEventsByHeightRange(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsByHeightRange", reflect.TypeOf((*MockEmulator)(nil).GetEventsByHeightRange), arg0, arg1, arg2)
+}
+
 // GetLatestBlock mocks base method
 func (m *MockEmulator) GetLatestBlock() (*flow.Block, error) {
 	m.ctrl.T.Helper()
@@ -260,6 +412,37 @@ func (mr *MockEmulatorMockRecorder) GetTransaction(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransaction", reflect.TypeOf((*MockEmulator)(nil).GetTransaction), arg0)
 }
 
+// GetTransactionBlock mocks base method
+func (m *MockEmulator) GetTransactionBlock(arg0 flow_go_sdk.Identifier) (*flow.Block, uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionBlock", arg0)
+	ret0, _ := ret[0].(*flow.Block)
+	ret1, _ := ret[1].(uint32)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTransactionBlock indicates an expected call of GetTransactionBlock
+func (mr *MockEmulatorMockRecorder) GetTransactionBlock(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionBlock", reflect.TypeOf((*MockEmulator)(nil).GetTransactionBlock), arg0)
+}
+
+// GetTransactionExecutionResult mocks base method
+func (m *MockEmulator) GetTransactionExecutionResult(arg0 flow_go_sdk.Identifier) (*types.TransactionResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionExecutionResult", arg0)
+	ret0, _ := ret[0].(*types.TransactionResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionExecutionResult indicates an expected call of GetTransactionExecutionResult
+func (mr *MockEmulatorMockRecorder) GetTransactionExecutionResult(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionExecutionResult", reflect.TypeOf((*MockEmulator)(nil).GetTransactionExecutionResult), arg0)
+}
+
 // GetTransactionResult mocks base method
 func (m *MockEmulator) GetTransactionResult(arg0 flow_go_sdk.Identifier) (*flow_go_sdk.TransactionResult, error) {
 	m.ctrl.T.Helper()
@@ -274,3 +457,301 @@ func (mr *MockEmulatorMockRecorder) GetTransactionResult(arg0 interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionResult", reflect.TypeOf((*MockEmulator)(nil).GetTransactionResult), arg0)
 }
+
+// GetTransactionStateDelta mocks base method
+func (m *MockEmulator) GetTransactionStateDelta(arg0 flow_go_sdk.Identifier) (*types.TransactionStateDelta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionStateDelta", arg0)
+	ret0, _ := ret[0].(*types.TransactionStateDelta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionStateDelta indicates an expected call of GetTransactionStateDelta
+func (mr *MockEmulatorMockRecorder) GetTransactionStateDelta(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionStateDelta", reflect.TypeOf((*MockEmulator)(nil).GetTransactionStateDelta), arg0)
+}
+
+// GetTransactionTrace mocks base method
+func (m *MockEmulator) GetTransactionTrace(arg0 flow_go_sdk.Identifier) (*types.TransactionTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionTrace", arg0)
+	ret0, _ := ret[0].(*types.TransactionTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionTrace indicates an expected call of GetTransactionTrace
+func (mr *MockEmulatorMockRecorder) GetTransactionTrace(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionTrace", reflect.TypeOf((*MockEmulator)(nil).GetTransactionTrace), arg0)
+}
+
+// GetTransactionsByAccount mocks base method
+func (m *MockEmulator) GetTransactionsByAccount(arg0 flow_go_sdk.Address, arg1 *storage.AccountTransactionCursor, arg2 int) ([]storage.AccountTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionsByAccount", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]storage.AccountTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionsByAccount indicates an expected call of GetTransactionsByAccount
+func (mr *MockEmulatorMockRecorder) GetTransactionsByAccount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsByAccount", reflect.TypeOf((*MockEmulator)(nil).GetTransactionsByAccount), arg0, arg1, arg2)
+}
+
+// GetTransactionsByBlockHeight mocks base method
+func (m *MockEmulator) GetTransactionsByBlockHeight(arg0 uint64) ([]emulator.BlockTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionsByBlockHeight", arg0)
+	ret0, _ := ret[0].([]emulator.BlockTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionsByBlockHeight indicates an expected call of GetTransactionsByBlockHeight
+func (mr *MockEmulatorMockRecorder) GetTransactionsByBlockHeight(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsByBlockHeight", reflect.TypeOf((*MockEmulator)(nil).GetTransactionsByBlockHeight), arg0)
+}
+
+// Impersonate mocks base method
+func (m *MockEmulator) Impersonate(arg0 ...flow_go_sdk.Address) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Impersonate", varargs...)
+}
+
+// Impersonate indicates an expected call of Impersonate
+func (mr *MockEmulatorMockRecorder) Impersonate(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{}, arg0...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Impersonate", reflect.TypeOf((*MockEmulator)(nil).Impersonate), varargs...)
+}
+
+// ImpersonatedAccounts mocks base method
+func (m *MockEmulator) ImpersonatedAccounts() []flow_go_sdk.Address {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImpersonatedAccounts")
+	ret0, _ := ret[0].([]flow_go_sdk.Address)
+	return ret0
+}
+
+// ImpersonatedAccounts indicates an expected call of ImpersonatedAccounts
+func (mr *MockEmulatorMockRecorder) ImpersonatedAccounts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImpersonatedAccounts", reflect.TypeOf((*MockEmulator)(nil).ImpersonatedAccounts))
+}
+
+// PendingBlockTimestamp mocks base method
+func (m *MockEmulator) PendingBlockTimestamp() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingBlockTimestamp")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// PendingBlockTimestamp indicates an expected call of PendingBlockTimestamp
+func (mr *MockEmulatorMockRecorder) PendingBlockTimestamp() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingBlockTimestamp", reflect.TypeOf((*MockEmulator)(nil).PendingBlockTimestamp))
+}
+
+// Profiler mocks base method
+func (m *MockEmulator) Profiler() *emulator.Profiler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Profiler")
+	ret0, _ := ret[0].(*emulator.Profiler)
+	return ret0
+}
+
+// Profiler indicates an expected call of Profiler
+func (mr *MockEmulatorMockRecorder) Profiler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Profiler", reflect.TypeOf((*MockEmulator)(nil).Profiler))
+}
+
+// RemoveAccountStorage mocks base method
+func (m *MockEmulator) RemoveAccountStorage(arg0 flow_go_sdk.Address, arg1 cadence.Path) (*flow.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAccountStorage", arg0, arg1)
+	ret0, _ := ret[0].(*flow.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveAccountStorage indicates an expected call of RemoveAccountStorage
+func (mr *MockEmulatorMockRecorder) RemoveAccountStorage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccountStorage", reflect.TypeOf((*MockEmulator)(nil).RemoveAccountStorage), arg0, arg1)
+}
+
+// ReplayTransaction mocks base method
+func (m *MockEmulator) ReplayTransaction(arg0 flow_go_sdk.Identifier) (*types.TransactionResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayTransaction", arg0)
+	ret0, _ := ret[0].(*types.TransactionResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayTransaction indicates an expected call of ReplayTransaction
+func (mr *MockEmulatorMockRecorder) ReplayTransaction(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayTransaction", reflect.TypeOf((*MockEmulator)(nil).ReplayTransaction), arg0)
+}
+
+// RevokeAccountKey mocks base method
+func (m *MockEmulator) RevokeAccountKey(arg0 flow_go_sdk.Address, arg1 int) (*flow.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccountKey", arg0, arg1)
+	ret0, _ := ret[0].(*flow.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAccountKey indicates an expected call of RevokeAccountKey
+func (mr *MockEmulatorMockRecorder) RevokeAccountKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccountKey", reflect.TypeOf((*MockEmulator)(nil).RevokeAccountKey), arg0, arg1)
+}
+
+// RollbackToHeight mocks base method
+func (m *MockEmulator) RollbackToHeight(arg0 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackToHeight", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RollbackToHeight indicates an expected call of RollbackToHeight
+func (mr *MockEmulatorMockRecorder) RollbackToHeight(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackToHeight", reflect.TypeOf((*MockEmulator)(nil).RollbackToHeight), arg0)
+}
+
+// SetAccountBalance mocks base method
+func (m *MockEmulator) SetAccountBalance(arg0 flow_go_sdk.Address, arg1 cadence.UFix64) (*flow.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountBalance", arg0, arg1)
+	ret0, _ := ret[0].(*flow.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetAccountBalance indicates an expected call of SetAccountBalance
+func (mr *MockEmulatorMockRecorder) SetAccountBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountBalance", reflect.TypeOf((*MockEmulator)(nil).SetAccountBalance), arg0, arg1)
+}
+
+// SetAccountStorage mocks base method
+func (m *MockEmulator) SetAccountStorage(arg0 flow_go_sdk.Address, arg1 cadence.Path, arg2 cadence.Value) (*flow.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountStorage", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*flow.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetAccountStorage indicates an expected call of SetAccountStorage
+func (mr *MockEmulatorMockRecorder) SetAccountStorage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountStorage", reflect.TypeOf((*MockEmulator)(nil).SetAccountStorage), arg0, arg1, arg2)
+}
+
+// SetNextBlockTimestamp mocks base method
+func (m *MockEmulator) SetNextBlockTimestamp(arg0 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNextBlockTimestamp", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNextBlockTimestamp indicates an expected call of SetNextBlockTimestamp
+func (mr *MockEmulatorMockRecorder) SetNextBlockTimestamp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNextBlockTimestamp", reflect.TypeOf((*MockEmulator)(nil).SetNextBlockTimestamp), arg0)
+}
+
+// SimulateTransaction mocks base method
+func (m *MockEmulator) SimulateTransaction(arg0 flow_go_sdk.Transaction) (*types.TransactionResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SimulateTransaction", arg0)
+	ret0, _ := ret[0].(*types.TransactionResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SimulateTransaction indicates an expected call of SimulateTransaction
+func (mr *MockEmulatorMockRecorder) SimulateTransaction(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SimulateTransaction", reflect.TypeOf((*MockEmulator)(nil).SimulateTransaction), arg0)
+}
+
+// Snapshot mocks base method
+func (m *MockEmulator) Snapshot(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockEmulatorMockRecorder) Snapshot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockEmulator)(nil).Snapshot), arg0)
+}
+
+// StopImpersonating mocks base method
+func (m *MockEmulator) StopImpersonating(arg0 ...flow_go_sdk.Address) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "StopImpersonating", varargs...)
+}
+
+// StopImpersonating indicates an expected call of StopImpersonating
+func (mr *MockEmulatorMockRecorder) StopImpersonating(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{}, arg0...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopImpersonating", reflect.TypeOf((*MockEmulator)(nil).StopImpersonating), varargs...)
+}
+
+// SubscribeEvents mocks base method
+func (m *MockEmulator) SubscribeEvents(arg0 emulator.EventFilter) (*emulator.EventSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeEvents", arg0)
+	ret0, _ := ret[0].(*emulator.EventSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubscribeEvents indicates an expected call of SubscribeEvents
+func (mr *MockEmulatorMockRecorder) SubscribeEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeEvents", reflect.TypeOf((*MockEmulator)(nil).SubscribeEvents), arg0)
+}
+
+// WaitForTransaction mocks base method
+func (m *MockEmulator) WaitForTransaction(arg0 context.Context, arg1 flow_go_sdk.Identifier) (*flow_go_sdk.TransactionResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForTransaction", arg0, arg1)
+	ret0, _ := ret[0].(*flow_go_sdk.TransactionResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitForTransaction indicates an expected call of WaitForTransaction
+func (mr *MockEmulatorMockRecorder) WaitForTransaction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForTransaction", reflect.TypeOf((*MockEmulator)(nil).WaitForTransaction), arg0, arg1)
+}
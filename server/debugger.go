@@ -0,0 +1,624 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/go-dap"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/sirupsen/logrus"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+// DebuggerServer serves the Debug Adapter Protocol (DAP), which allows editors like VS Code
+// to set breakpoints in transactions, scripts and deployed contracts, to step through them
+// while they execute, and to inspect their local variables.
+//
+// Only one debugging session can be attached at a time.
+type DebuggerServer struct {
+	logger       *logrus.Logger
+	debugger     *emulator.Debugger
+	port         int
+	pauseOnEntry bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	session  *debugSession
+}
+
+func NewDebuggerServer(
+	logger *logrus.Logger,
+	debugger *emulator.Debugger,
+	port int,
+	pauseOnEntry bool,
+) *DebuggerServer {
+	return &DebuggerServer{
+		logger:       logger,
+		debugger:     debugger,
+		port:         port,
+		pauseOnEntry: pauseOnEntry,
+	}
+}
+
+func (d *DebuggerServer) Start() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", d.port))
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.listener = listener
+	d.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		go d.serve(conn)
+	}
+}
+
+func (d *DebuggerServer) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.listener != nil {
+		_ = d.listener.Close()
+	}
+
+	if d.session != nil {
+		_ = d.session.conn.Close()
+	}
+}
+
+func (d *DebuggerServer) serve(conn net.Conn) {
+	d.mu.Lock()
+	if d.session != nil {
+		d.mu.Unlock()
+		d.logger.Warn("🐞  Rejected debugger connection, another debugger is already attached")
+		_ = conn.Close()
+		return
+	}
+
+	session := newDebugSession(d.logger, d.debugger, conn, d.pauseOnEntry)
+	d.session = session
+	d.mu.Unlock()
+
+	d.logger.WithField("address", conn.RemoteAddr()).Info("🐞  Debugger connected")
+
+	session.serve()
+
+	d.mu.Lock()
+	d.session = nil
+	d.mu.Unlock()
+
+	d.logger.Info("🐞  Debugger disconnected")
+}
+
+// debugThreadID is the ID of the only thread reported to the client: the executing transaction.
+const debugThreadID = 1
+
+// debugLocalsReference is the reference of the only scope reported to the client: the local variables.
+const debugLocalsReference = 1
+
+// debugAttachArguments are the arguments of attach and launch requests.
+type debugAttachArguments struct {
+	// StopOnEntry pauses before the first statement of each transaction.
+	StopOnEntry *bool `json:"stopOnEntry"`
+	// Locations maps source file paths to the IDs of the contracts deployed from them,
+	// e.g. "A.f8d6e0586b0a20c7.Foo".
+	//
+	// Source files that are not mapped, and not named after the location ID, e.g. "A.f8d6e0586b0a20c7.Foo.cdc",
+	// are matched with the executed programs by their code.
+	Locations map[string]string `json:"locations"`
+}
+
+// debugSession is a connection of a DAP client to the debugger of the emulated blockchain.
+type debugSession struct {
+	logger       *logrus.Logger
+	debugger     *emulator.Debugger
+	conn         net.Conn
+	pauseOnEntry bool
+	done         chan struct{}
+
+	// mutex protecting writes to the connection
+	sendMu sync.Mutex
+
+	// mutex protecting the session state below
+	mu          sync.Mutex
+	closed      bool
+	stop        *emulator.DebugStop
+	stopReason  string
+	locations   map[string]string
+	sourcePaths map[string]string
+	// breakpoint lines by location ID
+	breakpoints map[string][]int
+}
+
+func newDebugSession(
+	logger *logrus.Logger,
+	debugger *emulator.Debugger,
+	conn net.Conn,
+	pauseOnEntry bool,
+) *debugSession {
+	return &debugSession{
+		logger:       logger,
+		debugger:     debugger,
+		conn:         conn,
+		pauseOnEntry: pauseOnEntry,
+		done:         make(chan struct{}),
+		locations:    make(map[string]string),
+		sourcePaths:  make(map[string]string),
+		breakpoints:  make(map[string][]int),
+	}
+}
+
+// serve handles requests until the client disconnects.
+func (s *debugSession) serve() {
+	defer s.close()
+
+	go s.handleStops()
+
+	reader := bufio.NewReader(s.conn)
+
+	for {
+		message, err := dap.ReadProtocolMessage(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.logger.WithError(err).Error("❗  Failed to read debugger request")
+			}
+			return
+		}
+
+		s.handleRequest(message)
+
+		if _, ok := message.(*dap.DisconnectRequest); ok {
+			return
+		}
+	}
+}
+
+// close detaches the debugger, which removes all breakpoints and resumes a paused program.
+func (s *debugSession) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.breakpoints = nil
+	s.stop = nil
+	s.mu.Unlock()
+
+	s.debugger.Detach()
+
+	close(s.done)
+
+	_ = s.conn.Close()
+}
+
+// handleStops reports the stops of the debugger to the client.
+func (s *debugSession) handleStops() {
+	for {
+		select {
+		case stop := <-s.debugger.Stops():
+			s.onStop(stop)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *debugSession) onStop(stop emulator.DebugStop) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		s.debugger.Continue()
+		return
+	}
+
+	reason := s.stopReason
+	s.stopReason = ""
+
+	if reason == "" {
+		if stop.Breakpoint {
+			reason = "breakpoint"
+		} else {
+			reason = "entry"
+		}
+	}
+
+	s.stop = &stop
+
+	s.send(&dap.StoppedEvent{
+		Event: newDebugEvent("stopped"),
+		Body: dap.StoppedEventBody{
+			Reason:            reason,
+			ThreadId:          debugThreadID,
+			AllThreadsStopped: true,
+		},
+	})
+}
+
+func (s *debugSession) handleRequest(message dap.Message) {
+	switch request := message.(type) {
+	case *dap.InitializeRequest:
+		s.send(&dap.InitializeResponse{
+			Response: newDebugResponse(request.Request),
+			Body: dap.Capabilities{
+				SupportsConfigurationDoneRequest: true,
+			},
+		})
+		s.send(&dap.InitializedEvent{
+			Event: newDebugEvent("initialized"),
+		})
+
+	case *dap.LaunchRequest:
+		// there is nothing to launch, the emulator is already running
+		err := s.attach(request.Arguments)
+		if err != nil {
+			s.sendError(request.Request, err)
+			return
+		}
+		s.send(&dap.LaunchResponse{Response: newDebugResponse(request.Request)})
+
+	case *dap.AttachRequest:
+		err := s.attach(request.Arguments)
+		if err != nil {
+			s.sendError(request.Request, err)
+			return
+		}
+		s.send(&dap.AttachResponse{Response: newDebugResponse(request.Request)})
+
+	case *dap.SetBreakpointsRequest:
+		s.send(&dap.SetBreakpointsResponse{
+			Response: newDebugResponse(request.Request),
+			Body: dap.SetBreakpointsResponseBody{
+				Breakpoints: s.setBreakpoints(request.Arguments),
+			},
+		})
+
+	case *dap.ConfigurationDoneRequest:
+		s.send(&dap.ConfigurationDoneResponse{Response: newDebugResponse(request.Request)})
+
+	case *dap.ThreadsRequest:
+		s.send(&dap.ThreadsResponse{
+			Response: newDebugResponse(request.Request),
+			Body: dap.ThreadsResponseBody{
+				Threads: []dap.Thread{{Id: debugThreadID, Name: "Transaction"}},
+			},
+		})
+
+	case *dap.StackTraceRequest:
+		frames := s.stackFrames()
+		s.send(&dap.StackTraceResponse{
+			Response: newDebugResponse(request.Request),
+			Body: dap.StackTraceResponseBody{
+				StackFrames: frames,
+				TotalFrames: len(frames),
+			},
+		})
+
+	case *dap.ScopesRequest:
+		s.send(&dap.ScopesResponse{
+			Response: newDebugResponse(request.Request),
+			Body:     dap.ScopesResponseBody{Scopes: s.scopes()},
+		})
+
+	case *dap.VariablesRequest:
+		s.send(&dap.VariablesResponse{
+			Response: newDebugResponse(request.Request),
+			Body:     dap.VariablesResponseBody{Variables: s.variables(request.Arguments.VariablesReference)},
+		})
+
+	case *dap.ContinueRequest:
+		s.resume("")
+		s.send(&dap.ContinueResponse{
+			Response: newDebugResponse(request.Request),
+			Body:     dap.ContinueResponseBody{AllThreadsContinued: true},
+		})
+
+	case *dap.NextRequest:
+		s.resume("step")
+		s.send(&dap.NextResponse{Response: newDebugResponse(request.Request)})
+
+	case *dap.StepInRequest:
+		s.resume("step")
+		s.send(&dap.StepInResponse{Response: newDebugResponse(request.Request)})
+
+	case *dap.PauseRequest:
+		s.pause()
+		s.send(&dap.PauseResponse{Response: newDebugResponse(request.Request)})
+
+	case *dap.DisconnectRequest:
+		s.send(&dap.DisconnectResponse{Response: newDebugResponse(request.Request)})
+
+	case dap.RequestMessage:
+		s.sendError(*request.GetRequest(), fmt.Errorf("unsupported request: %s", request.GetRequest().Command))
+	}
+}
+
+func (s *debugSession) attach(rawArguments json.RawMessage) error {
+	var arguments debugAttachArguments
+
+	if len(rawArguments) > 0 {
+		err := json.Unmarshal(rawArguments, &arguments)
+		if err != nil {
+			return fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	pauseOnEntry := s.pauseOnEntry
+	if arguments.StopOnEntry != nil {
+		pauseOnEntry = *arguments.StopOnEntry
+	}
+
+	s.mu.Lock()
+	for path, locationID := range arguments.Locations {
+		s.locations[path] = locationID
+	}
+	s.mu.Unlock()
+
+	s.debugger.Attach(pauseOnEntry)
+
+	return nil
+}
+
+// setBreakpoints replaces the breakpoints of a source file.
+//
+// Breakpoints in source files of deployed contracts are set on their location. Breakpoints in other
+// source files, e.g. of transactions and scripts, are set on the programs with the same code.
+func (s *debugSession) setBreakpoints(arguments dap.SetBreakpointsArguments) []dap.Breakpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]dap.Breakpoint, len(arguments.Breakpoints))
+
+	location, err := s.resolveLocation(arguments.Source.Path)
+	if err != nil {
+		return s.setSourceBreakpoints(arguments)
+	}
+
+	locationID := string(location.ID())
+
+	for _, line := range s.breakpoints[locationID] {
+		s.debugger.RemoveBreakpoint(locationID, line)
+	}
+
+	lines := make([]int, len(arguments.Breakpoints))
+	for i, breakpoint := range arguments.Breakpoints {
+		lines[i] = breakpoint.Line
+		s.debugger.AddBreakpoint(locationID, lines[i])
+
+		result[i] = dap.Breakpoint{
+			Verified: true,
+			Line:     breakpoint.Line,
+		}
+	}
+
+	s.breakpoints[locationID] = lines
+	s.sourcePaths[locationID] = arguments.Source.Path
+
+	return result
+}
+
+// setSourceBreakpoints replaces the breakpoints of a source file that is not deployed as a contract.
+func (s *debugSession) setSourceBreakpoints(arguments dap.SetBreakpointsArguments) []dap.Breakpoint {
+	path := arguments.Source.Path
+	result := make([]dap.Breakpoint, len(arguments.Breakpoints))
+
+	code, err := os.ReadFile(path)
+	if err != nil {
+		s.debugger.SetSourceBreakpoints(path, nil, nil)
+
+		for i, breakpoint := range arguments.Breakpoints {
+			result[i] = dap.Breakpoint{
+				Verified: false,
+				Line:     breakpoint.Line,
+				Message:  fmt.Sprintf("cannot read source file: %s", err),
+			}
+		}
+		return result
+	}
+
+	lines := make([]int, len(arguments.Breakpoints))
+	for i, breakpoint := range arguments.Breakpoints {
+		lines[i] = breakpoint.Line
+
+		result[i] = dap.Breakpoint{
+			Verified: true,
+			Line:     breakpoint.Line,
+		}
+	}
+
+	s.debugger.SetSourceBreakpoints(path, code, lines)
+
+	return result
+}
+
+// resolveLocation returns the location of the contract deployed from the given source file.
+func (s *debugSession) resolveLocation(path string) (common.Location, error) {
+	locationID, ok := s.locations[path]
+	if !ok {
+		locationID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	parts := strings.SplitN(locationID, ".", 3)
+	if len(parts) != 3 || parts[0] != common.AddressLocationPrefix {
+		return nil, fmt.Errorf("unknown contract location for %s", path)
+	}
+
+	address, err := common.HexToAddress(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract location %s: %w", locationID, err)
+	}
+
+	return common.AddressLocation{
+		Address: address,
+		Name:    parts[2],
+	}, nil
+}
+
+func (s *debugSession) stackFrames() []dap.StackFrame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		return []dap.StackFrame{}
+	}
+
+	path := s.stop.Source
+	if path == "" {
+		path = s.sourcePaths[s.stop.Location]
+	}
+
+	return []dap.StackFrame{
+		{
+			Id:   1,
+			Name: s.stop.Location,
+			Source: dap.Source{
+				Name: s.stop.Location,
+				Path: path,
+			},
+			Line:   s.stop.Line,
+			Column: 1,
+		},
+	}
+}
+
+// scopes returns the scopes of the paused program: its local variables.
+func (s *debugSession) scopes() []dap.Scope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		return []dap.Scope{}
+	}
+
+	return []dap.Scope{
+		{
+			Name:               "Locals",
+			PresentationHint:   "locals",
+			VariablesReference: debugLocalsReference,
+			NamedVariables:     len(s.stop.Variables),
+		},
+	}
+}
+
+// variables returns the local variables of the paused program.
+// Values are reported as strings, so they cannot be expanded.
+func (s *debugSession) variables(reference int) []dap.Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil || reference != debugLocalsReference {
+		return []dap.Variable{}
+	}
+
+	variables := make([]dap.Variable, len(s.stop.Variables))
+	for i, variable := range s.stop.Variables {
+		variables[i] = dap.Variable{
+			Name:  variable.Name,
+			Value: variable.Value,
+			Type:  variable.Type,
+		}
+	}
+
+	return variables
+}
+
+// resume continues a paused program, pausing again on the next statement if the reason is set.
+func (s *debugSession) resume(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		return
+	}
+
+	if reason != "" {
+		s.stopReason = reason
+		s.debugger.RequestPause()
+	}
+
+	s.stop = nil
+	s.debugger.Continue()
+}
+
+func (s *debugSession) pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+
+	s.stopReason = "pause"
+	s.debugger.RequestPause()
+}
+
+func (s *debugSession) send(message dap.Message) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	err := dap.WriteProtocolMessage(s.conn, message)
+	if err != nil {
+		s.logger.WithError(err).Error("❗  Failed to send debugger message")
+	}
+}
+
+func (s *debugSession) sendError(request dap.Request, err error) {
+	response := newDebugResponse(request)
+	response.Success = false
+	response.Message = err.Error()
+
+	s.send(&dap.ErrorResponse{Response: response})
+}
+
+func newDebugResponse(request dap.Request) dap.Response {
+	return dap.Response{
+		ProtocolMessage: dap.ProtocolMessage{
+			Type: "response",
+		},
+		Command:    request.Command,
+		RequestSeq: request.Seq,
+		Success:    true,
+	}
+}
+
+func newDebugEvent(event string) dap.Event {
+	return dap.Event{
+		ProtocolMessage: dap.ProtocolMessage{
+			Type: "event",
+		},
+		Event: event,
+	}
+}
@@ -19,12 +19,29 @@
 package server
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	sdk "github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	emulator "github.com/onflow/flow-emulator"
+	convert "github.com/onflow/flow-emulator/convert/sdk"
 	"github.com/onflow/flow-emulator/server/backend"
-	"github.com/onflow/flow-emulator/storage/badger"
+	"github.com/onflow/flow-emulator/storage"
+	"github.com/onflow/flow-emulator/types"
 )
 
 type BlockResponse struct {
@@ -33,6 +50,120 @@ type BlockResponse struct {
 	Context string `json:"context,omitempty"`
 }
 
+type TimeResponse struct {
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type ImpersonationResponse struct {
+	Accounts []string `json:"accounts"`
+}
+
+type RegistersResponse struct {
+	Address   string             `json:"address"`
+	Height    uint64             `json:"height"`
+	Registers []RegisterResponse `json:"registers"`
+}
+
+type RegisterResponse struct {
+	Controller string          `json:"controller"`
+	Key        string          `json:"key"`
+	Value      string          `json:"value"`
+	Decoded    json.RawMessage `json:"decoded,omitempty"`
+}
+
+type StorageResponse struct {
+	Address string                `json:"address"`
+	Height  uint64                `json:"height"`
+	Storage []StoredValueResponse `json:"storage"`
+}
+
+type StoredValueResponse struct {
+	Path  string          `json:"path"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type AccountTransactionsResponse struct {
+	Address      string                       `json:"address"`
+	Transactions []AccountTransactionResponse `json:"transactions"`
+	NextCursor   string                       `json:"nextCursor,omitempty"`
+}
+
+type AccountTransactionResponse struct {
+	TransactionID    string   `json:"transactionId"`
+	BlockHeight      uint64   `json:"blockHeight"`
+	TransactionIndex uint32   `json:"transactionIndex"`
+	Roles            []string `json:"roles"`
+}
+
+type StateDiffResponse struct {
+	From              uint64                   `json:"from"`
+	To                uint64                   `json:"to"`
+	CreatedAccounts   []string                 `json:"createdAccounts"`
+	DeployedContracts []ContractResponse       `json:"deployedContracts"`
+	UpdatedContracts  []ContractResponse       `json:"updatedContracts"`
+	RemovedContracts  []ContractResponse       `json:"removedContracts"`
+	Balances          []BalanceChangeResponse  `json:"balances"`
+	Registers         []RegisterChangeResponse `json:"registers"`
+}
+
+type ContractResponse struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+}
+
+type BalanceChangeResponse struct {
+	Address string `json:"address"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+}
+
+type RegisterChangeResponse struct {
+	Owner      string `json:"owner"`
+	Controller string `json:"controller"`
+	Key        string `json:"key"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+}
+
+type StateDeltaResponse struct {
+	TransactionID string                        `json:"transactionId"`
+	Accounts      []AccountStorageDeltaResponse `json:"accounts"`
+	Registers     []RegisterChangeResponse      `json:"registers"`
+}
+
+type AccountStorageDeltaResponse struct {
+	Address           string `json:"address"`
+	BytesAdded        uint64 `json:"bytesAdded"`
+	BytesRemoved      uint64 `json:"bytesRemoved"`
+	StorageUsedBefore uint64 `json:"storageUsedBefore"`
+	StorageUsedAfter  uint64 `json:"storageUsedAfter"`
+}
+
+type TraceResponse struct {
+	TransactionID string               `json:"transactionId"`
+	Entries       []TraceEntryResponse `json:"entries"`
+}
+
+type TraceEntryResponse struct {
+	Kind       string          `json:"kind"`
+	Depth      int             `json:"depth"`
+	Name       string          `json:"name,omitempty"`
+	Location   string          `json:"location,omitempty"`
+	Address    string          `json:"address,omitempty"`
+	Key        string          `json:"key,omitempty"`
+	Size       *int            `json:"size,omitempty"`
+	Value      string          `json:"value,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	StartNs    int64           `json:"startNs"`
+	DurationNs int64           `json:"durationNs,omitempty"`
+}
+
+type ReplayResponse struct {
+	ExecutionResultResponse
+	Trace []TraceEntryResponse `json:"trace"`
+}
+
 type EmulatorApiServer struct {
 	router  *mux.Router
 	server  *EmulatorServer
@@ -49,7 +180,38 @@ func NewEmulatorApiServer(server *EmulatorServer, backend *backend.Backend, stor
 	}
 
 	router.HandleFunc("/emulator/newBlock", r.CommitBlock)
+	router.HandleFunc("/emulator/mine", r.CommitEmptyBlocks)
 	router.HandleFunc("/emulator/snapshot/{name}", r.Snapshot)
+	router.HandleFunc("/emulator/rollback/{height:[0-9]+}", r.Rollback)
+	router.HandleFunc("/emulator/events/subscribe", r.SubscribeEvents)
+	router.HandleFunc("/emulator/blocks/{height:[0-9]+}/transactions", r.BlockTransactions)
+	router.HandleFunc("/emulator/transactions/{id}/wait", r.WaitForTransaction)
+	router.HandleFunc("/emulator/transactions/{id}/result", r.TransactionExecutionResult)
+	router.HandleFunc("/emulator/transactions/{id}/delta", r.TransactionStateDelta)
+	router.HandleFunc("/emulator/trace/{id}", r.TransactionTrace)
+	router.HandleFunc("/emulator/transactions/{id}/replay", r.ReplayTransaction)
+	router.HandleFunc("/emulator/transactions/simulate", r.SimulateTransaction)
+	router.HandleFunc("/emulator/transactions/estimate", r.EstimateComputation)
+	router.HandleFunc("/emulator/coverage", r.Coverage)
+	router.HandleFunc("/emulator/coverage/reset", r.ResetCoverage)
+	router.HandleFunc("/emulator/impersonation", r.ImpersonatedAccounts)
+	router.HandleFunc("/emulator/impersonation/add/{address}", r.Impersonate)
+	router.HandleFunc("/emulator/impersonation/remove/{address}", r.StopImpersonating)
+	router.HandleFunc("/emulator/time", r.Time)
+	router.HandleFunc("/emulator/time/next", r.SetNextBlockTimestamp)
+	router.HandleFunc("/emulator/time/advance", r.AdvanceTime)
+	router.HandleFunc("/emulator/profiles", r.Profiles)
+	router.HandleFunc("/emulator/profiles/reset", r.ResetProfiles)
+	router.HandleFunc("/emulator/profiles/{id:[0-9a-fA-F]{64}}", r.Profile)
+	router.HandleFunc("/emulator/diff", r.DiffState)
+	router.HandleFunc("/emulator/accounts/{address}/registers", r.AccountRegisters)
+	router.HandleFunc("/emulator/accounts/{address}/storage", r.AccountStorage)
+	router.HandleFunc("/emulator/accounts/{address}/transactions", r.AccountTransactions)
+	router.HandleFunc("/emulator/accounts/{address}/balance", r.SetAccountBalance)
+	router.HandleFunc("/emulator/accounts/{address}/keys/add", r.AddAccountKey)
+	router.HandleFunc("/emulator/accounts/{address}/keys/{index:[0-9]+}/revoke", r.RevokeAccountKey)
+	router.HandleFunc("/emulator/accounts/{address}/storage/{identifier}", r.SetAccountStorage)
+	router.HandleFunc("/emulator/accounts/{address}/storage/{identifier}/remove", r.RemoveAccountStorage)
 
 	return r
 }
@@ -82,50 +244,1036 @@ func (m EmulatorApiServer) CommitBlock(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (m EmulatorApiServer) Snapshot(w http.ResponseWriter, r *http.Request) {
+// maxCommitEmptyBlocksCount is the maximum number of empty blocks committed in one request.
+const maxCommitEmptyBlocksCount = 1000000
+
+func (m EmulatorApiServer) CommitEmptyBlocks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	vars := mux.Vars(r)
-	name := vars["name"]
 
-	switch (*m.storage).Store().(type) {
-	case *badger.Store:
-		badgerStore := (*m.storage).Store().(*badger.Store)
-		err := badgerStore.JumpToContext(name)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+	count := uint64(1)
+	if value := r.URL.Query().Get("count"); value != "" {
+		var err error
+		count, err = strconv.ParseUint(value, 10, 64)
+		if err != nil || count > maxCommitEmptyBlocksCount {
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
+	}
 
-		blockchain, err := configureBlockchain(m.server.config, badgerStore)
+	var interval time.Duration
+	if value := r.URL.Query().Get("interval"); value != "" {
+		var err error
+		interval, err = time.ParseDuration(value)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
+	}
 
-		m.backend.SetEmulator(blockchain)
-		block, err := blockchain.GetLatestBlock()
-		if err != nil {
+	block, err := m.backend.CommitEmptyBlocks(count, interval)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to commit empty blocks")
+
+		switch err.(type) {
+		case *emulator.InvalidTimeAdvanceError:
+			w.WriteHeader(http.StatusBadRequest)
+		case *emulator.PendingBlockNotEmptyError:
+			w.WriteHeader(http.StatusConflict)
+		default:
 			w.WriteHeader(http.StatusInternalServerError)
-			return
 		}
+		return
+	}
+
+	blockResponse := &BlockResponse{
+		Height:  int(block.Header.Height),
+		BlockId: block.ID().String(),
+	}
+
+	err = json.NewEncoder(w).Encode(blockResponse)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m EmulatorApiServer) Snapshot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	err := m.backend.Snapshot(name)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to jump to state snapshot")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	header, err := m.backend.GetLatestBlockHeader(r.Context(), true)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	blockResponse := &BlockResponse{
+		Height:  int(header.Height),
+		BlockId: header.ID().String(),
+		Context: name,
+	}
+
+	err = json.NewEncoder(w).Encode(blockResponse)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m EmulatorApiServer) Rollback(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+
+	height, err := strconv.ParseUint(vars["height"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = m.backend.RollbackToHeight(height)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to roll back state")
 
-		blockResponse := &BlockResponse{
-			Height:  int(block.Header.Height),
-			BlockId: block.Header.ID().String(),
-			Context: name,
+		if _, ok := err.(emulator.NotFoundError); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
 		}
 
-		err = json.NewEncoder(w).Encode(blockResponse)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	header, err := m.backend.GetLatestBlockHeader(r.Context(), true)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	blockResponse := &BlockResponse{
+		Height:  int(header.Height),
+		BlockId: header.ID().String(),
+	}
+
+	err = json.NewEncoder(w).Encode(blockResponse)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m EmulatorApiServer) Coverage(w http.ResponseWriter, r *http.Request) {
+	report := m.backend.CoverageReport()
+	if report == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var err error
+
+	switch r.URL.Query().Get("format") {
+	case "lcov":
+		w.Header().Set("Content-Type", "text/plain")
+		err = report.WriteLCOV(w)
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(report)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to write coverage report")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m EmulatorApiServer) ResetCoverage(w http.ResponseWriter, r *http.Request) {
+	report := m.backend.CoverageReport()
+	if report == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	report.Reset()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m EmulatorApiServer) Profiles(w http.ResponseWriter, r *http.Request) {
+	profiler := m.backend.Profiler()
+	if profiler == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	m.writeProfiles(w, r, profiler.Profiles()...)
+}
+
+func (m EmulatorApiServer) Profile(w http.ResponseWriter, r *http.Request) {
+	profiler := m.backend.Profiler()
+	if profiler == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	id, err := flowgo.HexStringToIdentifier(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	profile, ok := profiler.Profile(id)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	m.writeProfiles(w, r, profile)
+}
+
+func (m EmulatorApiServer) ResetProfiles(w http.ResponseWriter, r *http.Request) {
+	profiler := m.backend.Profiler()
+	if profiler == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	profiler.Reset()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeProfiles writes profiles in the folded stack format, or in the pprof format if requested.
+//
+// Folded stacks are weighted by wall time, or by computation if requested.
+func (m EmulatorApiServer) writeProfiles(w http.ResponseWriter, r *http.Request, profiles ...*emulator.Profile) {
+	var err error
+
+	switch r.URL.Query().Get("format") {
+	case "", "folded":
+		value := emulator.ProfileValue(r.URL.Query().Get("value"))
+		switch value {
+		case "":
+			value = emulator.ProfileValueWallTime
+		case emulator.ProfileValueWallTime, emulator.ProfileValueComputation:
+		default:
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "text/plain")
+		err = emulator.WriteFoldedStacks(w, value, profiles...)
+	case "pprof":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment; filename=profile.pb.gz")
+		err = emulator.WritePprof(w, profiles...)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to write profiles")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m EmulatorApiServer) Time(w http.ResponseWriter, _ *http.Request) {
+	m.writeTime(w)
+}
+
+func (m EmulatorApiServer) SetNextBlockTimestamp(w http.ResponseWriter, r *http.Request) {
+	timestamp, err := parseTimestamp(r.URL.Query().Get("timestamp"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = m.backend.SetNextBlockTimestamp(timestamp)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to set next block timestamp")
+		writeTimeError(w, err)
+		return
+	}
+
+	m.writeTime(w)
+}
+
+func (m EmulatorApiServer) AdvanceTime(w http.ResponseWriter, r *http.Request) {
+	duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = m.backend.AdvanceTime(duration)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to advance time")
+		writeTimeError(w, err)
+		return
+	}
+
+	m.writeTime(w)
+}
+
+// writeTime writes the timestamp of the pending block.
+func (m EmulatorApiServer) writeTime(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(w).Encode(&TimeResponse{
+		Timestamp: m.backend.PendingBlockTimestamp(),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
 
+func writeTimeError(w http.ResponseWriter, err error) {
+	switch err.(type) {
+	case *emulator.InvalidBlockTimestampError, *emulator.InvalidTimeAdvanceError:
+		w.WriteHeader(http.StatusBadRequest)
+	case *emulator.PendingBlockMidExecutionError:
+		w.WriteHeader(http.StatusConflict)
 	default:
-		m.server.logger.Error("State management only available with badger storage")
 		w.WriteHeader(http.StatusInternalServerError)
 	}
+}
+
+// parseTimestamp parses an RFC 3339 timestamp or a Unix timestamp in seconds.
+func parseTimestamp(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func (m EmulatorApiServer) ImpersonatedAccounts(w http.ResponseWriter, _ *http.Request) {
+	m.writeImpersonatedAccounts(w)
+}
+
+func (m EmulatorApiServer) Impersonate(w http.ResponseWriter, r *http.Request) {
+	address, err := parseAddress(mux.Vars(r)["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	m.backend.Impersonate(convert.FlowAddressToSDK(address))
+
+	m.writeImpersonatedAccounts(w)
+}
+
+func (m EmulatorApiServer) StopImpersonating(w http.ResponseWriter, r *http.Request) {
+	address, err := parseAddress(mux.Vars(r)["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	m.backend.StopImpersonating(convert.FlowAddressToSDK(address))
+
+	m.writeImpersonatedAccounts(w)
+}
+
+func (m EmulatorApiServer) writeImpersonatedAccounts(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+
+	addresses := m.backend.ImpersonatedAccounts()
+
+	response := &ImpersonationResponse{
+		Accounts: make([]string, len(addresses)),
+	}
+	for i, address := range addresses {
+		response.Accounts[i] = fmt.Sprintf("0x%s", address.Hex())
+	}
+
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m EmulatorApiServer) AccountRegisters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	address, err := parseAddress(mux.Vars(r)["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	height, err := m.heightParameter(r, "height")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	registers, err := m.backend.GetAccountRegisters(convert.FlowAddressToSDK(address), height)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to get account registers")
 
+		if _, ok := err.(emulator.NotFoundError); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response := &RegistersResponse{
+		Address:   address.HexWithPrefix(),
+		Height:    height,
+		Registers: make([]RegisterResponse, len(registers)),
+	}
+
+	for i, register := range registers {
+		registerResponse := RegisterResponse{
+			Controller: hex.EncodeToString([]byte(register.ID.Controller)),
+			Key:        registerKey(register.ID.Key),
+			Value:      hex.EncodeToString(register.Value),
+		}
+
+		if register.Decoded != nil {
+			registerResponse.Decoded, err = jsoncdc.Encode(register.Decoded)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		response.Registers[i] = registerResponse
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m EmulatorApiServer) AccountStorage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	address, err := parseAddress(mux.Vars(r)["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	height, err := m.heightParameter(r, "height")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	values, err := m.backend.GetAccountStorage(convert.FlowAddressToSDK(address), height)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to get account storage")
+
+		if _, ok := err.(emulator.NotFoundError); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response := &StorageResponse{
+		Address: address.HexWithPrefix(),
+		Height:  height,
+		Storage: make([]StoredValueResponse, len(values)),
+	}
+
+	for i, value := range values {
+		encoded, err := jsoncdc.Encode(value.Value)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response.Storage[i] = StoredValueResponse{
+			Path:  value.Path.String(),
+			Type:  value.Type,
+			Value: encoded,
+		}
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+const (
+	defaultAccountTransactionsLimit = 50
+	maxAccountTransactionsLimit     = 1000
+)
+
+func (m EmulatorApiServer) AccountTransactions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	address, err := parseAddress(mux.Vars(r)["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultAccountTransactionsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if limit > maxAccountTransactionsLimit {
+			limit = maxAccountTransactionsLimit
+		}
+	}
+
+	var cursor *storage.AccountTransactionCursor
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		cursor, err = parseAccountTransactionCursor(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	txs, err := m.backend.GetTransactionsByAccount(convert.FlowAddressToSDK(address), cursor, limit)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to get account transactions")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response := &AccountTransactionsResponse{
+		Address:      address.HexWithPrefix(),
+		Transactions: make([]AccountTransactionResponse, len(txs)),
+	}
+
+	for i, tx := range txs {
+		roles := make([]string, len(tx.Roles))
+		for j, role := range tx.Roles {
+			roles[j] = string(role)
+		}
+
+		response.Transactions[i] = AccountTransactionResponse{
+			TransactionID:    tx.TransactionID.String(),
+			BlockHeight:      tx.BlockHeight,
+			TransactionIndex: tx.TransactionIndex,
+			Roles:            roles,
+		}
+	}
+
+	// a full page may be followed by more transactions
+	if len(txs) == limit {
+		response.NextCursor = accountTransactionCursorString(txs[len(txs)-1].Cursor())
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseAccountTransactionCursor parses a cursor of the form <block height>-<transaction index>.
+func parseAccountTransactionCursor(value string) (*storage.AccountTransactionCursor, error) {
+	parts := strings.SplitN(value, "-", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor %s", value)
+	}
+
+	blockHeight, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	txIndex, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.AccountTransactionCursor{
+		BlockHeight:      blockHeight,
+		TransactionIndex: uint32(txIndex),
+	}, nil
+}
+
+func accountTransactionCursorString(cursor storage.AccountTransactionCursor) string {
+	return fmt.Sprintf("%d-%d", cursor.BlockHeight, cursor.TransactionIndex)
+}
+
+func (m EmulatorApiServer) DiffState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	to, err := m.heightParameter(r, "to")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	diff, err := m.backend.DiffState(from, to)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to diff state")
+
+		switch err.(type) {
+		case emulator.NotFoundError:
+			w.WriteHeader(http.StatusNotFound)
+		case *emulator.InvalidBlockHeightRangeError:
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := &StateDiffResponse{
+		From:              diff.StartHeight,
+		To:                diff.EndHeight,
+		CreatedAccounts:   make([]string, len(diff.CreatedAccounts)),
+		DeployedContracts: contractResponses(diff.DeployedContracts),
+		UpdatedContracts:  contractResponses(diff.UpdatedContracts),
+		RemovedContracts:  contractResponses(diff.RemovedContracts),
+		Balances:          make([]BalanceChangeResponse, len(diff.Balances)),
+		Registers:         make([]RegisterChangeResponse, len(diff.Registers)),
+	}
+
+	for i, address := range diff.CreatedAccounts {
+		response.CreatedAccounts[i] = address.HexWithPrefix()
+	}
+
+	for i, balance := range diff.Balances {
+		response.Balances[i] = BalanceChangeResponse{
+			Address: balance.Address.HexWithPrefix(),
+			Before:  cadence.UFix64(balance.Before).String(),
+			After:   cadence.UFix64(balance.After).String(),
+		}
+	}
+
+	for i, register := range diff.Registers {
+		response.Registers[i] = newRegisterChangeResponse(register.ID, register.Before, register.After)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m EmulatorApiServer) TransactionStateDelta(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := flowgo.HexStringToIdentifier(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	stateDelta, err := m.backend.GetTransactionStateDelta(convert.FlowIdentifierToSDK(id))
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to get transaction state delta")
+
+		if _, ok := err.(emulator.NotFoundError); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// state deltas are not recorded for the transaction
+	if stateDelta == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response := newStateDeltaResponse(convert.FlowIdentifierToSDK(id), stateDelta)
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m EmulatorApiServer) TransactionTrace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := flowgo.HexStringToIdentifier(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	trace, err := m.backend.GetTransactionTrace(convert.FlowIdentifierToSDK(id))
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to get transaction trace")
+
+		if _, ok := err.(emulator.NotFoundError); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// traces are not recorded for the transaction
+	if trace == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response := &TraceResponse{
+		TransactionID: id.String(),
+		Entries:       make([]TraceEntryResponse, len(trace.Entries)),
+	}
+
+	for i, entry := range trace.Entries {
+		response.Entries[i] = newTraceEntryResponse(entry)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m EmulatorApiServer) ReplayTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := flowgo.HexStringToIdentifier(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	result, err := m.backend.ReplayTransaction(convert.FlowIdentifierToSDK(id))
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to replay transaction")
+
+		if _, ok := err.(emulator.NotFoundError); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resultResponse, err := newExecutionResultResponse(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response := &ReplayResponse{
+		ExecutionResultResponse: *resultResponse,
+		Trace:                   make([]TraceEntryResponse, len(result.Trace.Entries)),
+	}
+
+	for i, entry := range result.Trace.Entries {
+		response.Trace[i] = newTraceEntryResponse(entry)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func newTraceEntryResponse(entry types.TraceEntry) TraceEntryResponse {
+	response := TraceEntryResponse{
+		Kind:       string(entry.Kind),
+		Depth:      entry.Depth,
+		Name:       entry.Name,
+		Location:   entry.Location,
+		StartNs:    entry.Start.Nanoseconds(),
+		DurationNs: entry.Duration.Nanoseconds(),
+	}
+
+	switch entry.Kind {
+	case types.TraceEntryEvent:
+		// the payload of events is JSON-Cadence
+		response.Payload = entry.Value
+	case types.TraceEntryStorageRead, types.TraceEntryStorageWrite, types.TraceEntryCapability:
+		response.Address = entry.Address.HexWithPrefix()
+		response.Key = registerKey(entry.Key)
+		response.Size = &entry.Size
+		response.Value = hex.EncodeToString(entry.Value)
+	case types.TraceEntryAccount:
+		response.Address = entry.Address.HexWithPrefix()
+	}
+
+	return response
+}
+
+func newStateDeltaResponse(txID sdk.Identifier, stateDelta *types.TransactionStateDelta) *StateDeltaResponse {
+	response := &StateDeltaResponse{
+		TransactionID: txID.String(),
+		Accounts:      make([]AccountStorageDeltaResponse, len(stateDelta.Accounts)),
+		Registers:     make([]RegisterChangeResponse, len(stateDelta.Registers)),
+	}
+
+	for i, account := range stateDelta.Accounts {
+		response.Accounts[i] = AccountStorageDeltaResponse{
+			Address:           account.Address.HexWithPrefix(),
+			BytesAdded:        account.BytesAdded,
+			BytesRemoved:      account.BytesRemoved,
+			StorageUsedBefore: account.StorageUsedBefore,
+			StorageUsedAfter:  account.StorageUsedAfter,
+		}
+	}
+
+	for i, register := range stateDelta.Registers {
+		response.Registers[i] = newRegisterChangeResponse(register.ID, register.Before, register.After)
+	}
+
+	return response
+}
+
+func newRegisterChangeResponse(id flowgo.RegisterID, before, after flowgo.RegisterValue) RegisterChangeResponse {
+	return RegisterChangeResponse{
+		Owner:      hex.EncodeToString([]byte(id.Owner)),
+		Controller: hex.EncodeToString([]byte(id.Controller)),
+		Key:        registerKey(id.Key),
+		Before:     hex.EncodeToString(before),
+		After:      hex.EncodeToString(after),
+	}
+}
+
+func contractResponses(contracts []emulator.ContractID) []ContractResponse {
+	responses := make([]ContractResponse, len(contracts))
+	for i, contract := range contracts {
+		responses[i] = ContractResponse{
+			Address: contract.Address.HexWithPrefix(),
+			Name:    contract.Name,
+		}
+	}
+	return responses
+}
+
+// heightParameter returns the block height in the query parameter with the given name,
+// or the latest block height if it is not set.
+func (m EmulatorApiServer) heightParameter(r *http.Request, name string) (uint64, error) {
+	if value := r.URL.Query().Get(name); value != "" {
+		return strconv.ParseUint(value, 10, 64)
+	}
+
+	header, err := m.backend.GetLatestBlockHeader(r.Context(), true)
+	if err != nil {
+		return 0, err
+	}
+
+	return header.Height, nil
+}
+
+// registerKey returns a printable register key, hex-encoding keys of storage slabs.
+func registerKey(key string) string {
+	for _, r := range key {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			return "0x" + hex.EncodeToString([]byte(key))
+		}
+	}
+
+	return key
+}
+
+func (m EmulatorApiServer) SetAccountBalance(w http.ResponseWriter, r *http.Request) {
+	address, err := parseAddress(mux.Vars(r)["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	balance, err := cadence.NewUFix64(r.URL.Query().Get("value"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	block, err := m.backend.SetAccountBalance(convert.FlowAddressToSDK(address), balance)
+	m.writeAccountEdit(w, block, err)
+}
+
+func (m EmulatorApiServer) AddAccountKey(w http.ResponseWriter, r *http.Request) {
+	address, err := parseAddress(mux.Vars(r)["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	sigAlgo := crypto.ECDSA_P256
+	if value := query.Get("sigAlgo"); value != "" {
+		sigAlgo = crypto.StringToSignatureAlgorithm(value)
+	}
+
+	hashAlgo := crypto.SHA3_256
+	if value := query.Get("hashAlgo"); value != "" {
+		hashAlgo = crypto.StringToHashAlgorithm(value)
+	}
+
+	weight := sdk.AccountKeyWeightThreshold
+	if value := query.Get("weight"); value != "" {
+		weight, err = strconv.Atoi(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	publicKey, err := crypto.DecodePublicKeyHex(sigAlgo, strings.TrimPrefix(query.Get("publicKey"), "0x"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	key := &sdk.AccountKey{
+		PublicKey: publicKey,
+		SigAlgo:   sigAlgo,
+		HashAlgo:  hashAlgo,
+		Weight:    weight,
+	}
+
+	block, err := m.backend.AddAccountKey(convert.FlowAddressToSDK(address), key)
+	m.writeAccountEdit(w, block, err)
+}
+
+func (m EmulatorApiServer) RevokeAccountKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	address, err := parseAddress(vars["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	block, err := m.backend.RevokeAccountKey(convert.FlowAddressToSDK(address), index)
+	m.writeAccountEdit(w, block, err)
+}
+
+func (m EmulatorApiServer) SetAccountStorage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	address, err := parseAddress(vars["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// the value is encoded as JSON-Cadence in the request body
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	value, err := jsoncdc.Decode(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	block, err := m.backend.SetAccountStorage(
+		convert.FlowAddressToSDK(address),
+		storagePath(vars["identifier"]),
+		value,
+	)
+	m.writeAccountEdit(w, block, err)
+}
+
+func (m EmulatorApiServer) RemoveAccountStorage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	address, err := parseAddress(vars["address"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	block, err := m.backend.RemoveAccountStorage(
+		convert.FlowAddressToSDK(address),
+		storagePath(vars["identifier"]),
+	)
+	m.writeAccountEdit(w, block, err)
+}
+
+func (m EmulatorApiServer) writeAccountEdit(w http.ResponseWriter, block *flowgo.Block, err error) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to edit account state")
+
+		switch err.(type) {
+		case emulator.NotFoundError:
+			w.WriteHeader(http.StatusNotFound)
+		case *emulator.PendingBlockNotEmptyError:
+			w.WriteHeader(http.StatusConflict)
+		case *emulator.ExecutionError:
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	blockResponse := &BlockResponse{
+		Height:  int(block.Header.Height),
+		BlockId: block.ID().String(),
+	}
+
+	err = json.NewEncoder(w).Encode(blockResponse)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func storagePath(identifier string) cadence.Path {
+	return cadence.Path{
+		Domain:     "storage",
+		Identifier: identifier,
+	}
 }
@@ -0,0 +1,310 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	accessproto "github.com/onflow/flow/protobuf/go/flow/access"
+	"github.com/onflow/flow/protobuf/go/flow/entities"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	emulator "github.com/onflow/flow-emulator"
+	emulatorproto "github.com/onflow/flow-emulator/protobuf/go/flow/emulator"
+	"github.com/onflow/flow-emulator/server/backend"
+)
+
+// EventsAPIHandler streams emulator events over gRPC.
+type EventsAPIHandler struct {
+	emulatorproto.UnimplementedEventsAPIServer
+	backend *backend.Backend
+}
+
+var _ emulatorproto.EventsAPIServer = &EventsAPIHandler{}
+
+func NewEventsAPIHandler(backend *backend.Backend) *EventsAPIHandler {
+	return &EventsAPIHandler{backend: backend}
+}
+
+func (h *EventsAPIHandler) SubscribeEvents(
+	req *emulatorproto.SubscribeEventsRequest,
+	stream emulatorproto.EventsAPI_SubscribeEventsServer,
+) error {
+	filter := emulator.EventFilter{
+		EventTypes:  req.GetEventTypes(),
+		StartHeight: req.GetStartHeight(),
+		EndHeight:   req.GetEndHeight(),
+	}
+
+	for _, address := range req.GetAddresses() {
+		if len(address) > flowgo.AddressLength {
+			return status.Errorf(codes.InvalidArgument, "invalid address: %x", address)
+		}
+
+		filter.ContractAddresses = append(filter.ContractAddresses, flowgo.BytesToAddress(address))
+	}
+
+	sub, err := h.backend.SubscribeEvents(filter)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case blockEvents, ok := <-sub.Events():
+			if !ok {
+				return subscriptionStatus(sub.Err())
+			}
+
+			err := stream.Send(blockEventsToMessage(blockEvents))
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// subscriptionStatus converts the error an event subscription was terminated with to a gRPC status.
+func subscriptionStatus(err error) error {
+	switch err.(type) {
+	case nil:
+		return nil
+	case *emulator.EventSubscriptionOverflowError:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case *emulator.EventSubscriptionResetError:
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// subscriptionCloseCode returns the WebSocket close code for the error an event
+// subscription was terminated with.
+func subscriptionCloseCode(err error) int {
+	switch err.(type) {
+	case nil:
+		return websocket.CloseNormalClosure
+	case *emulator.EventSubscriptionOverflowError, *emulator.EventSubscriptionResetError:
+		return websocket.CloseTryAgainLater
+	default:
+		return websocket.CloseInternalServerErr
+	}
+}
+
+func parseAddress(value string) (flowgo.Address, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return flowgo.Address{}, err
+	}
+
+	return flowgo.BytesToAddress(b), nil
+}
+
+func blockEventsToMessage(blockEvents flowgo.BlockEvents) *accessproto.EventsResponse_Result {
+	events := make([]*entities.Event, len(blockEvents.Events))
+	for i, event := range blockEvents.Events {
+		events[i] = &entities.Event{
+			Type:             string(event.Type),
+			TransactionId:    event.TransactionID[:],
+			TransactionIndex: event.TransactionIndex,
+			EventIndex:       event.EventIndex,
+			Payload:          event.Payload,
+		}
+	}
+
+	return &accessproto.EventsResponse_Result{
+		BlockId:        blockEvents.BlockID[:],
+		BlockHeight:    blockEvents.BlockHeight,
+		BlockTimestamp: timestamppb.New(blockEvents.BlockTimestamp),
+		Events:         events,
+	}
+}
+
+type EventResponse struct {
+	Type             string          `json:"type"`
+	TransactionId    string          `json:"transactionId"`
+	TransactionIndex uint32          `json:"transactionIndex"`
+	EventIndex       uint32          `json:"eventIndex"`
+	Payload          json.RawMessage `json:"payload"`
+}
+
+type BlockEventsResponse struct {
+	BlockId        string          `json:"blockId"`
+	BlockHeight    uint64          `json:"blockHeight"`
+	BlockTimestamp time.Time       `json:"blockTimestamp"`
+	Events         []EventResponse `json:"events"`
+}
+
+func newEventResponse(event flowgo.Event) EventResponse {
+	return EventResponse{
+		Type:             string(event.Type),
+		TransactionId:    event.TransactionID.String(),
+		TransactionIndex: event.TransactionIndex,
+		EventIndex:       event.EventIndex,
+		Payload:          event.Payload,
+	}
+}
+
+func newBlockEventsResponse(blockEvents flowgo.BlockEvents) BlockEventsResponse {
+	events := make([]EventResponse, len(blockEvents.Events))
+	for i, event := range blockEvents.Events {
+		events[i] = newEventResponse(event)
+	}
+
+	return BlockEventsResponse{
+		BlockId:        blockEvents.BlockID.String(),
+		BlockHeight:    blockEvents.BlockHeight,
+		BlockTimestamp: blockEvents.BlockTimestamp,
+		Events:         events,
+	}
+}
+
+// checkEventsOrigin accepts WebSocket connections from the same origin, from clients
+// not sending an origin, and from the origins allowed in the configuration.
+func (m EmulatorApiServer) checkEventsOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range m.server.config.EventsAllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SubscribeEvents streams block events as JSON messages over a WebSocket connection.
+//
+// The subscription is filtered by the repeatable `type` and `address` query parameters
+// and the optional `start` and `end` block heights.
+func (m EmulatorApiServer) SubscribeEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := emulator.EventFilter{
+		EventTypes: query["type"],
+	}
+
+	for _, value := range query["address"] {
+		address, err := parseAddress(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		filter.ContractAddresses = append(filter.ContractAddresses, address)
+	}
+
+	var err error
+
+	if start := query.Get("start"); start != "" {
+		filter.StartHeight, err = strconv.ParseUint(start, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	if end := query.Get("end"); end != "" {
+		filter.EndHeight, err = strconv.ParseUint(end, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	sub, err := m.backend.SubscribeEvents(filter)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to subscribe to events")
+
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	defer sub.Close()
+
+	upgrader := websocket.Upgrader{CheckOrigin: m.checkEventsOrigin}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to upgrade event subscription to WebSocket")
+		return
+	}
+	defer conn.Close()
+
+	// detect clients closing the connection
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case blockEvents, ok := <-sub.Events():
+			if !ok {
+				reason := ""
+				if err := sub.Err(); err != nil {
+					reason = err.Error()
+				}
+
+				_ = conn.WriteMessage(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(subscriptionCloseCode(sub.Err()), reason),
+				)
+				return
+			}
+
+			err := conn.WriteJSON(newBlockEventsResponse(blockEvents))
+			if err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
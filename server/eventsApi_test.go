@@ -0,0 +1,287 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	emulator "github.com/onflow/flow-emulator"
+	emulatorproto "github.com/onflow/flow-emulator/protobuf/go/flow/emulator"
+	"github.com/onflow/flow-emulator/server/backend"
+)
+
+const accountCreatedEventType = "flow.AccountCreated"
+
+// createAccount creates an account and returns the block the account creation was committed in.
+func createAccount(t *testing.T, b *emulator.Blockchain) *flowgo.Block {
+	latestBlock, err := b.GetLatestBlock()
+	require.NoError(t, err)
+
+	_, err = b.CreateAccount([]*flow.AccountKey{b.ServiceKey().AccountKey()}, nil)
+	require.NoError(t, err)
+
+	block, err := b.GetBlockByHeight(latestBlock.Header.Height + 1)
+	require.NoError(t, err)
+
+	return block
+}
+
+func startEventsAPI(t *testing.T, b *emulator.Blockchain) emulatorproto.EventsAPIClient {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	emulatorproto.RegisterEventsAPIServer(server, NewEventsAPIHandler(backend.New(logrus.New(), b)))
+
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return emulatorproto.NewEventsAPIClient(conn)
+}
+
+func startEmulatorAPI(t *testing.T, b *emulator.Blockchain, conf *Config) *httptest.Server {
+	emulatorServer := &EmulatorServer{
+		logger: logrus.New(),
+		config: conf,
+	}
+
+	server := httptest.NewServer(NewEmulatorApiServer(emulatorServer, backend.New(logrus.New(), b), nil))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestEventsAPI(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("should stream events of blocks up to end height", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		block := createAccount(t, b)
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		client := startEventsAPI(t, b)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stream, err := client.SubscribeEvents(ctx, &emulatorproto.SubscribeEventsRequest{
+			EventTypes: []string{accountCreatedEventType},
+			EndHeight:  latestBlock.Header.Height,
+		})
+		require.NoError(t, err)
+
+		result, err := stream.Recv()
+		require.NoError(t, err)
+
+		blockID := block.ID()
+		assert.Equal(t, blockID[:], result.GetBlockId())
+		assert.Equal(t, block.Header.Height, result.GetBlockHeight())
+		require.Len(t, result.GetEvents(), 1)
+		assert.Equal(t, accountCreatedEventType, result.GetEvents()[0].GetType())
+
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("should filter events by contract address", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		createAccount(t, b)
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		client := startEventsAPI(t, b)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stream, err := client.SubscribeEvents(ctx, &emulatorproto.SubscribeEventsRequest{
+			Addresses: [][]byte{flowgo.HexToAddress("01").Bytes()},
+			EndHeight: latestBlock.Header.Height,
+		})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("should reject invalid addresses", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		client := startEventsAPI(t, b)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stream, err := client.SubscribeEvents(ctx, &emulatorproto.SubscribeEventsRequest{
+			Addresses: [][]byte{make([]byte, flowgo.AddressLength+1)},
+		})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestSubscribeEventsWebSocket(t *testing.T) {
+
+	t.Parallel()
+
+	subscribeURL := func(server *httptest.Server) string {
+		return "ws" + strings.TrimPrefix(server.URL, "http") +
+			"/emulator/events/subscribe?type=" + accountCreatedEventType
+	}
+
+	originHeader := func(origin string) http.Header {
+		header := http.Header{}
+		header.Set("Origin", origin)
+		return header
+	}
+
+	t.Run("should stream events of committed blocks", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		server := startEmulatorAPI(t, b, &Config{})
+
+		conn, _, err := websocket.DefaultDialer.Dial(subscribeURL(server), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		block := createAccount(t, b)
+
+		err = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		require.NoError(t, err)
+
+		var response BlockEventsResponse
+		err = conn.ReadJSON(&response)
+		require.NoError(t, err)
+
+		assert.Equal(t, block.ID().String(), response.BlockId)
+		assert.Equal(t, block.Header.Height, response.BlockHeight)
+		require.Len(t, response.Events, 1)
+		assert.Equal(t, accountCreatedEventType, response.Events[0].Type)
+	})
+
+	t.Run("should reject invalid height ranges", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		server := startEmulatorAPI(t, b, &Config{})
+
+		_, resp, err := websocket.DefaultDialer.Dial(subscribeURL(server)+"&start=2&end=1", nil)
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("should accept the same origin", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		server := startEmulatorAPI(t, b, &Config{})
+
+		conn, _, err := websocket.DefaultDialer.Dial(subscribeURL(server), originHeader(server.URL))
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	t.Run("should reject other origins by default", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		server := startEmulatorAPI(t, b, &Config{})
+
+		_, resp, err := websocket.DefaultDialer.Dial(subscribeURL(server), originHeader("http://example.com"))
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("should accept allowed origins", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		server := startEmulatorAPI(t, b, &Config{
+			EventsAllowedOrigins: []string{"http://example.com"},
+		})
+
+		conn, _, err := websocket.DefaultDialer.Dial(subscribeURL(server), originHeader("http://example.com"))
+		require.NoError(t, err)
+		conn.Close()
+
+		_, _, err = websocket.DefaultDialer.Dial(subscribeURL(server), originHeader("http://example.org"))
+		assert.Error(t, err)
+	})
+}
@@ -32,6 +32,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
+	emulatorproto "github.com/onflow/flow-emulator/protobuf/go/flow/emulator"
 	"github.com/onflow/flow-emulator/server/backend"
 )
 
@@ -52,6 +53,8 @@ func NewGRPCServer(logger *logrus.Logger, b *backend.Backend, port int, debug bo
 
 	legacyaccessproto.RegisterAccessAPIServer(grpcServer, legacyaccess.NewHandler(adaptedBackend, chain))
 	accessproto.RegisterAccessAPIServer(grpcServer, access.NewHandler(adaptedBackend, chain))
+	emulatorproto.RegisterEventsAPIServer(grpcServer, NewEventsAPIHandler(b))
+	RegisterTransactionsAPIServer(grpcServer, NewTransactionsAPIHandler(b))
 
 	grpcprometheus.Register(grpcServer)
 
@@ -32,6 +32,7 @@ import (
 	emulator "github.com/onflow/flow-emulator"
 	"github.com/onflow/flow-emulator/server/backend"
 	"github.com/onflow/flow-emulator/storage"
+	"github.com/onflow/flow-emulator/storage/remote"
 )
 
 // EmulatorServer is a local server that runs a Flow Emulator instance.
@@ -48,6 +49,7 @@ type EmulatorServer struct {
 	admin    graceland.Routine
 	rest     graceland.Routine
 	wallet   graceland.Routine
+	debugger graceland.Routine
 	blocks   graceland.Routine
 }
 
@@ -56,6 +58,7 @@ const (
 	defaultRESTPort               = 8888
 	defaultAdminPort              = 8080
 	defaultDevWalletPort          = 8701
+	defaultDebuggerPort           = 2345
 	defaultLivenessCheckTolerance = time.Second
 	defaultDBGCInterval           = time.Minute * 5
 	defaultDBGCRatio              = 0.5
@@ -112,6 +115,31 @@ type Config struct {
 	LivenessCheckTolerance time.Duration
 	// Whether to deploy some extra Flow contracts when emulator starts
 	WithContracts bool
+	// ForkHost is the gRPC address of an access node to fork the network state from.
+	ForkHost string
+	// ForkHeight is the upstream block height to fork from, zero for the latest sealed block.
+	ForkHeight uint64
+	// CoverageReportingEnabled enables collecting Cadence code coverage.
+	CoverageReportingEnabled bool
+	// ProfilingEnabled enables recording execution profiles of transactions and scripts.
+	ProfilingEnabled bool
+	// StateDeltasEnabled enables recording the ledger changes of each transaction.
+	StateDeltasEnabled bool
+	// TransactionTracesEnabled enables recording the execution trace of each transaction.
+	TransactionTracesEnabled bool
+	// TraceValuesEnabled enables recording the values of storage accesses in transaction traces.
+	TraceValuesEnabled bool
+	// ClockOffset is a fixed offset from the system time for the timestamps of new blocks.
+	ClockOffset time.Duration
+	// DebuggerEnabled enables the Cadence debugger over the Debug Adapter Protocol.
+	DebuggerEnabled bool
+	// DebuggerPort is the port of the Debug Adapter Protocol server.
+	DebuggerPort int
+	// DebuggerPauseOnEntry pauses the debugger before executing each transaction.
+	DebuggerPauseOnEntry bool
+	// EventsAllowedOrigins are the origins allowed to subscribe to events over WebSocket
+	// in addition to the admin API origin, "*" allows any origin.
+	EventsAllowedOrigins []string
 }
 
 // NewEmulatorServer creates a new instance of a Flow Emulator server.
@@ -192,6 +220,10 @@ func NewEmulatorServer(logger *logrus.Logger, conf *Config) *EmulatorServer {
 		server.wallet = NewWalletServer(walletConfig, conf.DevWalletPort, conf.HTTPHeaders)
 	}
 
+	if conf.DebuggerEnabled {
+		server.debugger = NewDebuggerServer(logger, blockchain.Debugger(), conf.DebuggerPort, conf.DebuggerPauseOnEntry)
+	}
+
 	server.admin = NewAdminServer(server, be, &store, grpcServer, livenessTicker, conf.AdminPort, conf.HTTPHeaders)
 
 	// only create blocks ticker if block time > 0
@@ -235,6 +267,13 @@ func (s *EmulatorServer) Start() {
 		s.group.Add(s.wallet)
 	}
 
+	if s.debugger != nil {
+		s.logger.
+			WithField("port", s.config.DebuggerPort).
+			Infof("🐞  Starting debugger on port %d", s.config.DebuggerPort)
+		s.group.Add(s.debugger)
+	}
+
 	// only start blocks ticker if it exists
 	if s.blocks != nil {
 		s.group.Add(s.blocks)
@@ -263,10 +302,19 @@ func (s *EmulatorServer) Stop() {
 
 func configureStorage(logger *logrus.Logger, conf *Config) (storage Storage, err error) {
 	if conf.Persist {
-		return NewBadgerStorage(logger, conf.DBPath, conf.DBGCInterval, conf.DBGCDiscardRatio)
+		storage, err = NewBadgerStorage(logger, conf.DBPath, conf.DBGCInterval, conf.DBGCDiscardRatio)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		storage = NewMemoryStorage()
 	}
 
-	return NewMemoryStorage(), nil
+	if conf.ForkHost != "" {
+		return NewRemoteStorage(logger, storage, conf.ForkHost, conf.ForkHeight)
+	}
+
+	return storage, nil
 }
 
 func configureBlockchain(conf *Config, store storage.Store) (*emulator.Blockchain, error) {
@@ -282,6 +330,39 @@ func configureBlockchain(conf *Config, store storage.Store) (*emulator.Blockchai
 		emulator.WithTransactionFeesEnabled(conf.TransactionFeesEnabled),
 	}
 
+	// a forked chain keeps the addresses of the upstream network
+	if forked, ok := store.(*remote.Store); ok {
+		options = append(options, emulator.WithChainID(forked.ChainID()))
+	}
+
+	if conf.CoverageReportingEnabled {
+		options = append(options, emulator.WithCoverageReport(emulator.NewCoverageReport()))
+	}
+
+	if conf.ClockOffset != 0 {
+		options = append(options, emulator.WithClockOffset(conf.ClockOffset))
+	}
+
+	if conf.ProfilingEnabled {
+		options = append(options, emulator.WithProfiler(emulator.NewProfiler()))
+	}
+
+	if conf.DebuggerEnabled {
+		options = append(options, emulator.WithDebugger(emulator.NewDebugger()))
+	}
+
+	if conf.StateDeltasEnabled {
+		options = append(options, emulator.WithStateDeltas(true))
+	}
+
+	if conf.TransactionTracesEnabled {
+		options = append(options, emulator.WithTransactionTraces(true))
+	}
+
+	if conf.TraceValuesEnabled {
+		options = append(options, emulator.WithTraceValues(true))
+	}
+
 	if conf.ServicePrivateKey != nil {
 		options = append(
 			options,
@@ -329,6 +410,10 @@ func sanitizeConfig(conf *Config) *Config {
 		conf.DevWalletPort = defaultDevWalletPort
 	}
 
+	if conf.DebuggerPort == 0 {
+		conf.DebuggerPort = defaultDebuggerPort
+	}
+
 	if conf.HTTPHeaders == nil {
 		conf.HTTPHeaders = defaultHTTPHeaders
 	}
@@ -24,10 +24,12 @@ import (
 	"github.com/pkg/errors"
 	"github.com/psiemens/graceland"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 
 	"github.com/onflow/flow-emulator/storage"
 	"github.com/onflow/flow-emulator/storage/badger"
 	"github.com/onflow/flow-emulator/storage/memstore"
+	"github.com/onflow/flow-emulator/storage/remote"
 )
 
 type Storage interface {
@@ -114,3 +116,60 @@ func (s *BadgerStorage) Stop() {
 func (s *BadgerStorage) Store() storage.Store {
 	return s.store
 }
+
+// RemoteStorage forks the state of an upstream network on top of another storage.
+//
+// The forked state is read through the Access API of an access node.
+type RemoteStorage struct {
+	storage Storage
+	store   *remote.Store
+	conn    *grpc.ClientConn
+}
+
+func NewRemoteStorage(
+	logger *logrus.Logger,
+	base Storage,
+	host string,
+	forkHeight uint64,
+) (*RemoteStorage, error) {
+	conn, err := grpc.Dial(host, grpc.WithInsecure())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to upstream access node")
+	}
+
+	store, err := remote.New(
+		base.Store(),
+		remote.NewGRPCClient(conn),
+		remote.WithForkHeight(forkHeight),
+	)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "failed to initialize remote store")
+	}
+
+	logger.
+		WithFields(logrus.Fields{
+			"host":    host,
+			"chainID": store.ChainID(),
+		}).
+		Infof("🍴  Forking state from block #%d", store.ForkHeight())
+
+	return &RemoteStorage{
+		storage: base,
+		store:   store,
+		conn:    conn,
+	}, nil
+}
+
+func (s *RemoteStorage) Start() error {
+	return s.storage.Start()
+}
+
+func (s *RemoteStorage) Stop() {
+	s.storage.Stop()
+	_ = s.conn.Close()
+}
+
+func (s *RemoteStorage) Store() storage.Store {
+	return s.store
+}
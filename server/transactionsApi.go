@@ -0,0 +1,761 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	sdk "github.com/onflow/flow-go-sdk"
+	grpcconvert "github.com/onflow/flow-go-sdk/client/convert"
+	"github.com/onflow/flow-go/access"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	accessproto "github.com/onflow/flow/protobuf/go/flow/access"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	emulator "github.com/onflow/flow-emulator"
+	convert "github.com/onflow/flow-emulator/convert/sdk"
+	"github.com/onflow/flow-emulator/server/backend"
+	"github.com/onflow/flow-emulator/types"
+)
+
+// defaultWaitTimeout is the time a long-poll request waits for a transaction to be sealed.
+const defaultWaitTimeout = 30 * time.Second
+
+// TransactionsAPIServer is the server API of the emulator's transaction service.
+//
+// A subscription is requested with a GetTransactionRequest of the Access API. The
+// current result of the transaction is streamed first and, unless it is already
+// sealed, followed by the sealed result once its block is committed.
+//
+// Simulations and computation estimates are requested with a SendTransactionRequest
+// of the Access API, and respond with the same JSON structures as the corresponding
+// endpoints of the admin API. The safety margin of an estimate is read from the
+// optional `safety-margin` request metadata.
+type TransactionsAPIServer interface {
+	SubscribeTransactionResult(*accessproto.GetTransactionRequest, TransactionsAPI_SubscribeTransactionResultServer) error
+	SimulateTransaction(context.Context, *accessproto.SendTransactionRequest) (*structpb.Struct, error)
+	EstimateComputation(context.Context, *accessproto.SendTransactionRequest) (*structpb.Struct, error)
+}
+
+type TransactionsAPI_SubscribeTransactionResultServer interface {
+	Send(*accessproto.TransactionResultResponse) error
+	grpc.ServerStream
+}
+
+type transactionsAPISubscribeTransactionResultServer struct {
+	grpc.ServerStream
+}
+
+func (s *transactionsAPISubscribeTransactionResultServer) Send(m *accessproto.TransactionResultResponse) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func subscribeTransactionResultHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(accessproto.GetTransactionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TransactionsAPIServer).SubscribeTransactionResult(m, &transactionsAPISubscribeTransactionResultServer{stream})
+}
+
+func simulateTransactionHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(accessproto.SendTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionsAPIServer).SimulateTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/flow.emulator.TransactionsAPI/SimulateTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionsAPIServer).SimulateTransaction(ctx, req.(*accessproto.SendTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func estimateComputationHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(accessproto.SendTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionsAPIServer).EstimateComputation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/flow.emulator.TransactionsAPI/EstimateComputation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionsAPIServer).EstimateComputation(ctx, req.(*accessproto.SendTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var transactionsAPIServiceDesc = grpc.ServiceDesc{
+	ServiceName: "flow.emulator.TransactionsAPI",
+	HandlerType: (*TransactionsAPIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SimulateTransaction",
+			Handler:    simulateTransactionHandler,
+		},
+		{
+			MethodName: "EstimateComputation",
+			Handler:    estimateComputationHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeTransactionResult",
+			Handler:       subscribeTransactionResultHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+// RegisterTransactionsAPIServer registers the transaction service on a gRPC server.
+func RegisterTransactionsAPIServer(s *grpc.Server, srv TransactionsAPIServer) {
+	s.RegisterService(&transactionsAPIServiceDesc, srv)
+}
+
+// TransactionsAPIClient is the client API of the emulator's transaction service.
+type TransactionsAPIClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTransactionsAPIClient(cc grpc.ClientConnInterface) *TransactionsAPIClient {
+	return &TransactionsAPIClient{cc: cc}
+}
+
+// TransactionsAPI_SubscribeTransactionResultClient receives the results of a subscription.
+type TransactionsAPI_SubscribeTransactionResultClient struct {
+	grpc.ClientStream
+}
+
+func (x *TransactionsAPI_SubscribeTransactionResultClient) Recv() (*accessproto.TransactionResultResponse, error) {
+	m := new(accessproto.TransactionResultResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *TransactionsAPIClient) SubscribeTransactionResult(
+	ctx context.Context,
+	in *accessproto.GetTransactionRequest,
+	opts ...grpc.CallOption,
+) (*TransactionsAPI_SubscribeTransactionResultClient, error) {
+	stream, err := c.cc.NewStream(
+		ctx,
+		&transactionsAPIServiceDesc.Streams[0],
+		"/flow.emulator.TransactionsAPI/SubscribeTransactionResult",
+		opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	x := &TransactionsAPI_SubscribeTransactionResultClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (c *TransactionsAPIClient) SimulateTransaction(
+	ctx context.Context,
+	in *accessproto.SendTransactionRequest,
+	opts ...grpc.CallOption,
+) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, "/flow.emulator.TransactionsAPI/SimulateTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *TransactionsAPIClient) EstimateComputation(
+	ctx context.Context,
+	in *accessproto.SendTransactionRequest,
+	opts ...grpc.CallOption,
+) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, "/flow.emulator.TransactionsAPI/EstimateComputation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TransactionsAPIHandler streams and simulates transactions over gRPC.
+type TransactionsAPIHandler struct {
+	backend *backend.Backend
+}
+
+func NewTransactionsAPIHandler(backend *backend.Backend) *TransactionsAPIHandler {
+	return &TransactionsAPIHandler{backend: backend}
+}
+
+func (h *TransactionsAPIHandler) SubscribeTransactionResult(
+	req *accessproto.GetTransactionRequest,
+	stream TransactionsAPI_SubscribeTransactionResultServer,
+) error {
+	id := flowgo.HashToID(req.GetId())
+	sdkID := convert.FlowIdentifierToSDK(id)
+
+	result, err := h.backend.GetTransactionResult(stream.Context(), sdkID)
+	if err != nil {
+		return err
+	}
+
+	flowResult, err := h.backend.TransactionResultToFlow(sdkID, result)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	err = stream.Send(access.TransactionResultToMessage(flowResult))
+	if err != nil {
+		return err
+	}
+
+	if flowResult.Status == flowgo.TransactionStatusSealed {
+		return nil
+	}
+
+	result, err = h.backend.WaitForTransaction(stream.Context(), sdkID)
+	if err != nil {
+		return err
+	}
+
+	flowResult, err = h.backend.TransactionResultToFlow(sdkID, result)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return stream.Send(access.TransactionResultToMessage(flowResult))
+}
+
+func (h *TransactionsAPIHandler) SimulateTransaction(
+	_ context.Context,
+	req *accessproto.SendTransactionRequest,
+) (*structpb.Struct, error) {
+	tx, err := grpcconvert.MessageToTransaction(req.GetTransaction())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := h.backend.SimulateTransaction(tx)
+	if err != nil {
+		if _, ok := err.(emulator.TransactionValidationError); ok {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response, err := newExecutionResultResponse(result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return newStruct(response)
+}
+
+func (h *TransactionsAPIHandler) EstimateComputation(
+	ctx context.Context,
+	req *accessproto.SendTransactionRequest,
+) (*structpb.Struct, error) {
+	tx, err := grpcconvert.MessageToTransaction(req.GetTransaction())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	safetyMargin := uint64(emulator.DefaultComputationSafetyMargin)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("safety-margin"); len(values) > 0 {
+			safetyMargin, err = strconv.ParseUint(values[0], 10, 64)
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+	}
+
+	estimate, err := h.backend.EstimateComputation(tx, safetyMargin)
+	if err != nil {
+		switch err.(type) {
+		case emulator.TransactionValidationError, *emulator.InvalidComputationSafetyMarginError:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return newStruct(newEstimateResponse(estimate))
+}
+
+// newStruct converts a response to a struct through its JSON encoding,
+// so that the gRPC and admin APIs respond with the same structure.
+func newStruct(response interface{}) (*structpb.Struct, error) {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	var fields map[string]interface{}
+	err = json.Unmarshal(encoded, &fields)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	out, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return out, nil
+}
+
+type TransactionResultResponse struct {
+	Status       string          `json:"status"`
+	StatusCode   uint            `json:"statusCode"`
+	ErrorMessage string          `json:"errorMessage,omitempty"`
+	Events       []EventResponse `json:"events"`
+	BlockID      string          `json:"blockId,omitempty"`
+	BlockHeight  uint64          `json:"blockHeight,omitempty"`
+}
+
+// newTransactionResultResponse returns the response for a transaction result, and the block
+// that includes the transaction if it is sealed.
+func newTransactionResultResponse(result *access.TransactionResult, block *flowgo.Block) TransactionResultResponse {
+	events := make([]EventResponse, len(result.Events))
+	for i, event := range result.Events {
+		events[i] = newEventResponse(event)
+	}
+
+	response := TransactionResultResponse{
+		Status:       result.Status.String(),
+		StatusCode:   result.StatusCode,
+		ErrorMessage: result.ErrorMessage,
+		Events:       events,
+	}
+
+	if block != nil {
+		response.BlockID = block.ID().String()
+		response.BlockHeight = block.Header.Height
+	}
+
+	return response
+}
+
+// WaitForTransaction long-polls the result of a transaction.
+//
+// The request returns as soon as the transaction is sealed, or with its current
+// status once the timeout given by the `timeout` query parameter has passed.
+func (m EmulatorApiServer) WaitForTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+
+	id, err := flowgo.HexStringToIdentifier(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	timeout := defaultWaitTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		timeout, err = time.ParseDuration(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	sdkID := convert.FlowIdentifierToSDK(id)
+
+	result, err := m.backend.WaitForTransaction(ctx, sdkID)
+	if status.Code(err) == codes.DeadlineExceeded {
+		// return the current status to the client so it can poll again
+		result, err = m.backend.GetTransactionResult(r.Context(), sdkID)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	flowResult, err := convert.SDKTransactionResultToFlow(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var block *flowgo.Block
+	if flowResult.Status == flowgo.TransactionStatusSealed {
+		block, _, err = m.backend.GetTransactionBlock(sdkID)
+		if err != nil {
+			m.server.logger.WithError(err).Error("Failed to get transaction block")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = json.NewEncoder(w).Encode(newTransactionResultResponse(flowResult, block))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type ExecutionResultResponse struct {
+	TransactionID   string              `json:"transactionId"`
+	ComputationUsed uint64              `json:"computationUsed"`
+	ErrorMessage    string              `json:"errorMessage,omitempty"`
+	Logs            []string            `json:"logs"`
+	Events          []EventResponse     `json:"events"`
+	Debug           *DebugResponse      `json:"debug,omitempty"`
+	BlockID         string              `json:"blockId,omitempty"`
+	BlockHeight     uint64              `json:"blockHeight,omitempty"`
+	StateDelta      *StateDeltaResponse `json:"stateDelta"`
+}
+
+type DebugResponse struct {
+	Message string            `json:"message"`
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+func newExecutionResultResponse(result *types.TransactionResult) (*ExecutionResultResponse, error) {
+	events := make([]EventResponse, len(result.Events))
+	for i, event := range result.Events {
+		flowEvent, err := convert.SDKEventToFlow(event)
+		if err != nil {
+			return nil, err
+		}
+
+		events[i] = newEventResponse(flowEvent)
+	}
+
+	logs := result.Logs
+	if logs == nil {
+		logs = []string{}
+	}
+
+	response := &ExecutionResultResponse{
+		TransactionID:   result.TransactionID.String(),
+		ComputationUsed: result.ComputationUsed,
+		Logs:            logs,
+		Events:          events,
+	}
+
+	if result.Error != nil {
+		response.ErrorMessage = result.Error.Error()
+	}
+
+	if result.Debug != nil {
+		response.Debug = &DebugResponse{
+			Message: result.Debug.Message,
+			Meta:    result.Debug.Meta,
+		}
+	}
+
+	if result.BlockID != sdk.EmptyID {
+		response.BlockID = result.BlockID.String()
+		response.BlockHeight = result.BlockHeight
+	}
+
+	if result.StateDelta != nil {
+		response.StateDelta = newStateDeltaResponse(result.TransactionID, result.StateDelta)
+	}
+
+	return response, nil
+}
+
+type BlockTransactionsResponse struct {
+	Height       uint64                     `json:"height"`
+	BlockID      string                     `json:"blockId"`
+	Transactions []BlockTransactionResponse `json:"transactions"`
+}
+
+type BlockTransactionResponse struct {
+	Index       int                      `json:"index"`
+	Script      string                   `json:"script"`
+	Arguments   []json.RawMessage        `json:"arguments"`
+	Proposer    string                   `json:"proposer"`
+	Payer       string                   `json:"payer"`
+	Authorizers []string                 `json:"authorizers"`
+	GasLimit    uint64                   `json:"gasLimit"`
+	Result      *ExecutionResultResponse `json:"result"`
+}
+
+// BlockTransactions returns the transactions of a committed block with their results,
+// in execution order.
+func (m EmulatorApiServer) BlockTransactions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	height, err := strconv.ParseUint(mux.Vars(r)["height"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	txs, err := m.backend.GetTransactionsByBlockHeight(height)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to get block transactions")
+
+		if _, ok := err.(emulator.NotFoundError); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	block, err := m.backend.GetBlockByHeight(r.Context(), height)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response := &BlockTransactionsResponse{
+		Height:       height,
+		BlockID:      block.ID().String(),
+		Transactions: make([]BlockTransactionResponse, len(txs)),
+	}
+
+	for i, tx := range txs {
+		result, err := newExecutionResultResponse(tx.Result)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		arguments := make([]json.RawMessage, len(tx.Transaction.Arguments))
+		for j, argument := range tx.Transaction.Arguments {
+			arguments[j] = argument
+		}
+
+		authorizers := make([]string, len(tx.Transaction.Authorizers))
+		for j, authorizer := range tx.Transaction.Authorizers {
+			authorizers[j] = fmt.Sprintf("0x%s", authorizer.Hex())
+		}
+
+		response.Transactions[i] = BlockTransactionResponse{
+			Index:       i,
+			Script:      string(tx.Transaction.Script),
+			Arguments:   arguments,
+			Proposer:    fmt.Sprintf("0x%s", tx.Transaction.ProposalKey.Address.Hex()),
+			Payer:       fmt.Sprintf("0x%s", tx.Transaction.Payer.Hex()),
+			Authorizers: authorizers,
+			GasLimit:    tx.Transaction.GasLimit,
+			Result:      result,
+		}
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// TransactionExecutionResult returns the result of an executed transaction,
+// including its logs, computation used and debug information.
+func (m EmulatorApiServer) TransactionExecutionResult(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := flowgo.HexStringToIdentifier(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	result, err := m.backend.GetTransactionExecutionResult(convert.FlowIdentifierToSDK(id))
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to get transaction result")
+
+		if _, ok := err.(emulator.NotFoundError); ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response, err := newExecutionResultResponse(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// SimulateTransaction executes a transaction against the pending block without changing the chain state.
+//
+// The request body is the hex-encoded RLP encoding of the transaction, as produced by
+// `flow transactions build`. Signatures are optional.
+func (m EmulatorApiServer) SimulateTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	tx, err := decodeTransaction(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	result, err := m.backend.SimulateTransaction(*tx)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to simulate transaction")
+
+		if _, ok := err.(emulator.TransactionValidationError); ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response, err := newExecutionResultResponse(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type EstimateResponse struct {
+	ComputationUsed uint64 `json:"computationUsed"`
+	GasLimit        uint64 `json:"gasLimit"`
+	ErrorMessage    string `json:"errorMessage,omitempty"`
+}
+
+func newEstimateResponse(estimate *emulator.ComputationEstimate) *EstimateResponse {
+	response := &EstimateResponse{
+		ComputationUsed: estimate.ComputationUsed,
+		GasLimit:        estimate.GasLimit,
+	}
+
+	if estimate.Error != nil {
+		response.ErrorMessage = estimate.Error.Error()
+	}
+
+	return response
+}
+
+// EstimateComputation returns the computation used by a transaction at the latest state,
+// and a gas limit with the safety margin in percent given by the `margin` query parameter.
+//
+// The request body is the hex-encoded RLP encoding of the transaction. Signatures are optional.
+func (m EmulatorApiServer) EstimateComputation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	safetyMargin := uint64(emulator.DefaultComputationSafetyMargin)
+	if value := r.URL.Query().Get("margin"); value != "" {
+		var err error
+		safetyMargin, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := decodeTransaction(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	estimate, err := m.backend.EstimateComputation(*tx, safetyMargin)
+	if err != nil {
+		m.server.logger.WithError(err).Error("Failed to estimate computation")
+
+		switch err.(type) {
+		case emulator.TransactionValidationError, *emulator.InvalidComputationSafetyMarginError:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(newEstimateResponse(estimate))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// decodeTransaction decodes a transaction from the hex-encoded RLP encoding in the request body,
+// as produced by `flow transactions build`.
+func decodeTransaction(r *http.Request) (*sdk.Transaction, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := hex.DecodeString(strings.TrimSpace(string(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	return sdk.DecodeTransaction(encoded)
+}
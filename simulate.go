@@ -0,0 +1,107 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	sdk "github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/programs"
+	"github.com/onflow/flow-go/fvm/state"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	"github.com/onflow/flow-emulator/convert"
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+	"github.com/onflow/flow-emulator/types"
+)
+
+// SimulateTransaction executes a transaction against the state of the pending block without changing it,
+// and returns the result including its state delta.
+//
+// Signatures are not required and not verified, so transactions can be previewed before they are signed.
+func (b *Blockchain) SimulateTransaction(sdkTx sdk.Transaction) (*types.TransactionResult, error) {
+	b.lock()
+	defer b.unlock()
+
+	tx := sdkconvert.SDKTransactionToFlow(sdkTx)
+
+	if b.debugger != nil {
+		b.debugger.executionStarted(&b.mu, true, false)
+		defer b.debugger.executionFinished()
+	}
+
+	tp, view, err := b.simulateTransaction(b.vm, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := convert.VMTransactionResultToEmulator(tp)
+	if err != nil {
+		return nil, err
+	}
+
+	tr.StateDelta, err = newTransactionStateDelta(b.pendingBlock.ledgerView, view)
+	if err != nil {
+		return nil, err
+	}
+
+	return tr, nil
+}
+
+// simulateTransaction validates and executes a transaction in a child view of the pending block's ledger view,
+// without verifying its signatures. The returned view is not merged into the pending block.
+func (b *Blockchain) simulateTransaction(
+	vm *fvm.VirtualMachine,
+	tx *flowgo.TransactionBody,
+) (*fvm.TransactionProcedure, state.View, error) {
+	err := b.transactionValidator.Validate(withoutSignatures(tx))
+	if err != nil {
+		return nil, nil, convertAccessError(err)
+	}
+
+	ctx := fvm.NewContextFromParent(
+		withoutSignatureVerification(b.vmCtx),
+		fvm.WithBlockHeader(b.pendingBlock.Block().Header),
+	)
+
+	view := b.pendingBlock.ledgerView.NewChild()
+
+	tp := fvm.Transaction(tx, 0)
+
+	err = vm.Run(ctx, tp, view, programs.NewEmptyPrograms())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tp, view, nil
+}
+
+// withoutSignatureVerification removes the signature verifier from the context's transaction processors.
+func withoutSignatureVerification(ctx fvm.Context) fvm.Context {
+	processors := make([]fvm.TransactionProcessor, 0, len(ctx.TransactionProcessors))
+
+	for _, processor := range ctx.TransactionProcessors {
+		switch processor.(type) {
+		case *fvm.TransactionSignatureVerifier, *impersonatingSignatureVerifier:
+			continue
+		}
+
+		processors = append(processors, processor)
+	}
+
+	return fvm.NewContextFromParent(ctx, fvm.WithTransactionProcessors(processors...))
+}
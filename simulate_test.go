@@ -0,0 +1,169 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+func TestSimulateTransaction(t *testing.T) {
+
+	t.Parallel()
+
+	newTransaction := func(b *emulator.Blockchain) *flow.Transaction {
+		return flow.NewTransaction().
+			SetScript([]byte(`
+				transaction {
+					prepare(signer: AuthAccount) {
+						log("saving greeting")
+						signer.save("Hello, World!", to: /storage/greeting)
+					}
+				}
+			`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(b.ServiceKey().Address)
+	}
+
+	readGreeting := func(t *testing.T, b *emulator.Blockchain) cadence.Value {
+		block, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		values, err := b.GetAccountStorage(b.ServiceKey().Address, block.Header.Height)
+		require.NoError(t, err)
+
+		for _, value := range values {
+			if value.Path == (cadence.Path{Domain: "storage", Identifier: "greeting"}) {
+				return value.Value
+			}
+		}
+
+		return nil
+	}
+
+	t.Run("should simulate unsigned transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		tx := newTransaction(b)
+
+		result, err := b.SimulateTransaction(*tx)
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+
+		assert.Equal(t, tx.ID(), result.TransactionID)
+		assert.Equal(t, []string{`"saving greeting"`}, result.Logs)
+		assert.Greater(t, result.ComputationUsed, uint64(0))
+
+		require.NotNil(t, result.StateDelta)
+		assert.NotEmpty(t, result.StateDelta.Registers)
+		assert.NotEmpty(t, result.StateDelta.Accounts)
+
+		// the chain state is unchanged
+		results, err := b.ExecuteBlock()
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		assert.Nil(t, readGreeting(t, b))
+
+		_, err = b.GetTransaction(tx.ID())
+		assert.IsType(t, &emulator.TransactionNotFoundError{}, err)
+	})
+
+	t.Run("should simulate signed transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		tx := newTransaction(b)
+
+		err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		result, err := b.SimulateTransaction(*tx)
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+
+		// the simulated transaction can still be submitted
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err = b.ExecuteNextTransaction()
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+
+		_, err = b.CommitBlock()
+		require.NoError(t, err)
+
+		assert.Equal(t, cadence.String("Hello, World!"), readGreeting(t, b))
+	})
+
+	t.Run("should return failed result", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		tx := newTransaction(b).
+			SetScript([]byte(`
+				transaction {
+					prepare(signer: AuthAccount) {
+						panic("failed")
+					}
+				}
+			`))
+
+		result, err := b.SimulateTransaction(*tx)
+		require.NoError(t, err)
+		assert.True(t, result.Reverted())
+	})
+
+	t.Run("should fail for invalid transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		tx := newTransaction(b).SetScript(nil)
+
+		_, err = b.SimulateTransaction(*tx)
+		assert.IsType(t, &emulator.IncompleteTransactionError{}, err)
+	})
+}
@@ -0,0 +1,117 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("should revert to snapshot", func(t *testing.T) {
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		initialBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		err = b.Snapshot("initial")
+		require.NoError(t, err)
+
+		address, err := b.CreateAccount(nil, nil)
+		require.NoError(t, err)
+
+		_, err = b.GetAccount(address)
+		require.NoError(t, err)
+
+		err = b.Snapshot("initial")
+		require.NoError(t, err)
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+		assert.Equal(t, initialBlock.ID(), latestBlock.ID())
+
+		_, err = b.GetAccount(address)
+		assert.Error(t, err)
+
+		// blocks can be committed on top of the reverted state
+		block, err := b.CommitBlock()
+		require.NoError(t, err)
+		assert.Equal(t, initialBlock.Header.Height+1, block.Header.Height)
+	})
+	t.Run("should create, list, load and delete snapshots", func(t *testing.T) {
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		err = b.CreateSnapshot("first")
+		require.NoError(t, err)
+
+		address, err := b.CreateAccount(nil, nil)
+		require.NoError(t, err)
+
+		err = b.CreateSnapshot("second")
+		require.NoError(t, err)
+
+		err = b.CreateSnapshot("second")
+		assert.IsType(t, &emulator.DuplicateSnapshotError{}, err)
+
+		names, err := b.ListSnapshots()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, names)
+
+		sequenceNumber := b.ServiceKey().SequenceNumber
+
+		err = b.LoadSnapshot("first")
+		require.NoError(t, err)
+
+		_, err = b.GetAccount(address)
+		assert.Error(t, err)
+		assert.Less(t, b.ServiceKey().SequenceNumber, sequenceNumber)
+
+		err = b.LoadSnapshot("second")
+		require.NoError(t, err)
+
+		_, err = b.GetAccount(address)
+		assert.NoError(t, err)
+		assert.Equal(t, sequenceNumber, b.ServiceKey().SequenceNumber)
+
+		err = b.DeleteSnapshot("first")
+		require.NoError(t, err)
+
+		err = b.LoadSnapshot("first")
+		assert.IsType(t, &emulator.SnapshotNotFoundError{}, err)
+
+		err = b.DeleteSnapshot("first")
+		assert.IsType(t, &emulator.SnapshotNotFoundError{}, err)
+
+		names, err = b.ListSnapshots()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"second"}, names)
+	})
+}
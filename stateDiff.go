@@ -0,0 +1,203 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	fvmerrors "github.com/onflow/flow-go/fvm/errors"
+	"github.com/onflow/flow-go/fvm/programs"
+	flowgo "github.com/onflow/flow-go/model/flow"
+)
+
+// A StateDiff describes the changes of the ledger state between two blocks.
+type StateDiff struct {
+	StartHeight uint64
+	EndHeight   uint64
+	// Registers contains the registers whose value changed, ordered by owner, controller and key.
+	Registers         []RegisterChange
+	CreatedAccounts   []flowgo.Address
+	DeployedContracts []ContractID
+	UpdatedContracts  []ContractID
+	RemovedContracts  []ContractID
+	Balances          []BalanceChange
+}
+
+// A RegisterChange is the change of a register value.
+//
+// The previous value of a created register and the new value of a deleted register are empty.
+type RegisterChange struct {
+	ID     flowgo.RegisterID
+	Before flowgo.RegisterValue
+	After  flowgo.RegisterValue
+}
+
+// Created returns true if the register had no value before the change.
+func (c RegisterChange) Created() bool {
+	return len(c.Before) == 0
+}
+
+// Deleted returns true if the register has no value after the change.
+func (c RegisterChange) Deleted() bool {
+	return len(c.After) == 0
+}
+
+// A ContractID identifies a contract deployed to an account.
+type ContractID struct {
+	Address flowgo.Address
+	Name    string
+}
+
+// A BalanceChange is the change of the FLOW balance of an account.
+type BalanceChange struct {
+	Address flowgo.Address
+	Before  uint64
+	After   uint64
+}
+
+// DiffState returns the changes of the ledger state made by the blocks after the start height,
+// up to and including the end height.
+func (b *Blockchain) DiffState(startHeight, endHeight uint64) (*StateDiff, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if startHeight > endHeight {
+		return nil, &InvalidBlockHeightRangeError{StartHeight: startHeight, EndHeight: endHeight}
+	}
+
+	for _, height := range []uint64{startHeight, endHeight} {
+		_, err := b.getBlockByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ids, err := b.storage.RegistersChangedBetween(startHeight, endHeight)
+	if err != nil {
+		return nil, &StorageError{err}
+	}
+
+	startView := b.storage.LedgerViewByHeight(startHeight)
+	endView := b.storage.LedgerViewByHeight(endHeight)
+
+	diff := &StateDiff{
+		StartHeight:       startHeight,
+		EndHeight:         endHeight,
+		Registers:         make([]RegisterChange, 0, len(ids)),
+		CreatedAccounts:   make([]flowgo.Address, 0),
+		DeployedContracts: make([]ContractID, 0),
+		UpdatedContracts:  make([]ContractID, 0),
+		RemovedContracts:  make([]ContractID, 0),
+		Balances:          make([]BalanceChange, 0),
+	}
+
+	changedOwners := make([]flowgo.Address, 0)
+
+	for _, id := range ids {
+		before, err := startView.Get(id.Owner, id.Controller, id.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := endView.Get(id.Owner, id.Controller, id.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		// registers may be written with an unchanged value
+		if bytes.Equal(before, after) {
+			continue
+		}
+
+		change := RegisterChange{
+			ID:     id,
+			Before: before,
+			After:  after,
+		}
+
+		diff.Registers = append(diff.Registers, change)
+
+		address := flowgo.BytesToAddress([]byte(id.Owner))
+
+		// registers are ordered by owner, so an owner is new if it differs from the previous one
+		if len(id.Owner) > 0 && (len(changedOwners) == 0 || changedOwners[len(changedOwners)-1] != address) {
+			changedOwners = append(changedOwners, address)
+		}
+
+		switch {
+		case id.Key == registerKeyExists:
+			if change.Created() {
+				diff.CreatedAccounts = append(diff.CreatedAccounts, address)
+			}
+
+		case strings.HasPrefix(id.Key, registerKeyCodePrefix):
+			contract := ContractID{
+				Address: address,
+				Name:    strings.TrimPrefix(id.Key, registerKeyCodePrefix),
+			}
+
+			switch {
+			case change.Created():
+				diff.DeployedContracts = append(diff.DeployedContracts, contract)
+			case change.Deleted():
+				diff.RemovedContracts = append(diff.RemovedContracts, contract)
+			default:
+				diff.UpdatedContracts = append(diff.UpdatedContracts, contract)
+			}
+		}
+	}
+
+	for _, address := range changedOwners {
+		before, err := b.getBalance(address, startView)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := b.getBalance(address, endView)
+		if err != nil {
+			return nil, err
+		}
+
+		if before != after {
+			diff.Balances = append(diff.Balances, BalanceChange{
+				Address: address,
+				Before:  before,
+				After:   after,
+			})
+		}
+	}
+
+	return diff, nil
+}
+
+// getBalance returns the FLOW balance of an account in the given ledger state,
+// or zero if the account does not exist.
+func (b *Blockchain) getBalance(address flowgo.Address, view *delta.View) (uint64, error) {
+	account, err := b.vm.GetAccount(b.vmCtx, address, view.NewChild(), programs.NewEmptyPrograms())
+	if err != nil {
+		if fvmerrors.IsAccountNotFoundError(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return account.Balance, nil
+}
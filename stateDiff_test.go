@@ -0,0 +1,119 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/templates"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+	convert "github.com/onflow/flow-emulator/convert/sdk"
+)
+
+func TestDiffState(t *testing.T) {
+
+	t.Parallel()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+	)
+	require.NoError(t, err)
+
+	latestHeight := func(t *testing.T) uint64 {
+		block, err := b.GetLatestBlock()
+		require.NoError(t, err)
+		return block.Header.Height
+	}
+
+	startHeight := latestHeight(t)
+
+	address, err := b.CreateAccount(
+		[]*flow.AccountKey{b.ServiceKey().AccountKey()},
+		[]templates.Contract{{Name: "Test", Source: `pub contract Test {}`}},
+	)
+	require.NoError(t, err)
+
+	accountHeight := latestHeight(t)
+
+	balance, err := cadence.NewUFix64("10.0")
+	require.NoError(t, err)
+
+	_, err = b.SetAccountBalance(address, balance)
+	require.NoError(t, err)
+
+	endHeight := latestHeight(t)
+
+	flowAddress := convert.SDKAddressToFlow(address)
+
+	t.Run("should diff account creation", func(t *testing.T) {
+		diff, err := b.DiffState(startHeight, accountHeight)
+		require.NoError(t, err)
+
+		assert.Equal(t, []flowgo.Address{flowAddress}, diff.CreatedAccounts)
+		assert.Equal(t, []emulator.ContractID{{Address: flowAddress, Name: "Test"}}, diff.DeployedContracts)
+		assert.Empty(t, diff.UpdatedContracts)
+		assert.Empty(t, diff.RemovedContracts)
+
+		owners := make(map[flowgo.Address]bool)
+		for _, register := range diff.Registers {
+			assert.NotEqual(t, register.Before, register.After)
+			owners[flowgo.BytesToAddress([]byte(register.ID.Owner))] = true
+		}
+		assert.True(t, owners[flowAddress])
+	})
+
+	t.Run("should diff balance changes", func(t *testing.T) {
+		diff, err := b.DiffState(accountHeight, endHeight)
+		require.NoError(t, err)
+
+		assert.Empty(t, diff.CreatedAccounts)
+		assert.Empty(t, diff.DeployedContracts)
+
+		var change *emulator.BalanceChange
+		for i, balanceChange := range diff.Balances {
+			if balanceChange.Address == flowAddress {
+				change = &diff.Balances[i]
+			}
+		}
+
+		require.NotNil(t, change)
+		assert.Equal(t, uint64(balance), change.After)
+	})
+
+	t.Run("should return empty diff for same height", func(t *testing.T) {
+		diff, err := b.DiffState(endHeight, endHeight)
+		require.NoError(t, err)
+
+		assert.Empty(t, diff.Registers)
+		assert.Empty(t, diff.Balances)
+	})
+
+	t.Run("should fail for invalid range", func(t *testing.T) {
+		_, err := b.DiffState(endHeight, startHeight)
+		assert.IsType(t, &emulator.InvalidBlockHeightRangeError{}, err)
+
+		_, err = b.DiffState(startHeight, endHeight+1)
+		assert.IsType(t, &emulator.BlockNotFoundByHeightError{}, err)
+	})
+}
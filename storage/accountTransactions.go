@@ -0,0 +1,179 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"strings"
+
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	"github.com/onflow/flow-emulator/types"
+)
+
+// AccountRole is the role of an account in a transaction.
+type AccountRole string
+
+const (
+	AccountRoleProposer   AccountRole = "proposer"
+	AccountRolePayer      AccountRole = "payer"
+	AccountRoleAuthorizer AccountRole = "authorizer"
+	// AccountRoleEmitter is the role of an account with a contract that emitted
+	// an event in the transaction.
+	AccountRoleEmitter AccountRole = "emitter"
+)
+
+// An AccountTransaction is a committed transaction involving an account.
+type AccountTransaction struct {
+	TransactionID flowgo.Identifier
+	BlockHeight   uint64
+	// TransactionIndex is the position of the transaction in its block.
+	TransactionIndex uint32
+	// Roles are the roles of the account in the transaction, in the order
+	// proposer, payer, authorizer, emitter.
+	Roles []AccountRole
+}
+
+// Cursor returns a cursor pointing at the transaction.
+func (t AccountTransaction) Cursor() AccountTransactionCursor {
+	return AccountTransactionCursor{
+		BlockHeight:      t.BlockHeight,
+		TransactionIndex: t.TransactionIndex,
+	}
+}
+
+// An AccountTransactionCursor is a position in the transactions of an account,
+// used to page through them.
+type AccountTransactionCursor struct {
+	BlockHeight      uint64
+	TransactionIndex uint32
+}
+
+// Before returns true if the transaction at the given block height and index
+// was committed before the cursor position.
+func (c AccountTransactionCursor) Before(blockHeight uint64, txIndex uint32) bool {
+	if blockHeight != c.BlockHeight {
+		return blockHeight < c.BlockHeight
+	}
+
+	return txIndex < c.TransactionIndex
+}
+
+// AccountTransactionsInBlock returns the transactions in a block grouped by the
+// accounts involved in them, in the order they appear in the block.
+//
+// Transactions are ordered by the collections that include them, and the
+// results are used to find the accounts with contracts that emitted events.
+func AccountTransactionsInBlock(
+	blockHeight uint64,
+	collections []*flowgo.LightCollection,
+	transactions map[flowgo.Identifier]*flowgo.TransactionBody,
+	transactionResults map[flowgo.Identifier]*types.StorableTransactionResult,
+) map[flowgo.Address][]AccountTransaction {
+	accountTransactions := make(map[flowgo.Address][]AccountTransaction)
+
+	var txIndex uint32
+	for _, col := range collections {
+		for _, txID := range col.Transactions {
+			tx, ok := transactions[txID]
+			if !ok {
+				txIndex++
+				continue
+			}
+
+			roles := transactionAccountRoles(tx, transactionResults[txID])
+
+			// roles are collected in order, so the transaction is appended
+			// once per account with all of its roles
+			for _, address := range roles.addresses {
+				accountTransactions[address] = append(accountTransactions[address], AccountTransaction{
+					TransactionID:    txID,
+					BlockHeight:      blockHeight,
+					TransactionIndex: txIndex,
+					Roles:            roles.byAddress[address],
+				})
+			}
+
+			txIndex++
+		}
+	}
+
+	return accountTransactions
+}
+
+// accountRoles holds the roles of the accounts involved in a transaction,
+// keeping track of the order in which the accounts were first seen.
+type accountRoles struct {
+	addresses []flowgo.Address
+	byAddress map[flowgo.Address][]AccountRole
+}
+
+func (r *accountRoles) add(address flowgo.Address, role AccountRole) {
+	roles, ok := r.byAddress[address]
+	if !ok {
+		r.addresses = append(r.addresses, address)
+	}
+
+	for _, existing := range roles {
+		if existing == role {
+			return
+		}
+	}
+
+	r.byAddress[address] = append(roles, role)
+}
+
+func transactionAccountRoles(
+	tx *flowgo.TransactionBody,
+	result *types.StorableTransactionResult,
+) *accountRoles {
+	roles := &accountRoles{
+		byAddress: make(map[flowgo.Address][]AccountRole),
+	}
+
+	roles.add(tx.ProposalKey.Address, AccountRoleProposer)
+	roles.add(tx.Payer, AccountRolePayer)
+
+	for _, authorizer := range tx.Authorizers {
+		roles.add(authorizer, AccountRoleAuthorizer)
+	}
+
+	if result != nil {
+		for _, event := range result.Events {
+			address, ok := eventContractAddress(event.Type)
+			if ok {
+				roles.add(address, AccountRoleEmitter)
+			}
+		}
+	}
+
+	return roles
+}
+
+// eventContractAddress returns the address of the account with the contract
+// that declares the given event type. Events declared by the protocol, such as
+// flow.AccountCreated, are not declared by a contract.
+func eventContractAddress(eventType flowgo.EventType) (flowgo.Address, bool) {
+	// contract event types have the form A.<address>.<contract>.<event>
+	parts := strings.Split(string(eventType), ".")
+	if len(parts) < 4 || parts[0] != "A" {
+		return flowgo.Address{}, false
+	}
+
+	return flowgo.HexToAddress(parts[1]), true
+}
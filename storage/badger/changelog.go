@@ -100,6 +100,11 @@ func (c *changelist) add(n uint64) {
 	c.blocks = append(c.blocks[:index+1], append([]uint64{n}, c.blocks[index+1:]...)...)
 }
 
+// truncate removes all block heights greater than n from the list.
+func (c *changelist) truncate(n uint64) {
+	c.blocks = c.blocks[:c.searchForIndex(n)+1]
+}
+
 // The changelog describes the change history of each register in a ledger.
 // For each register, the changelog contains a list of all the block heights at
 // which the register's value changed. This enables quick lookups of the latest
@@ -156,3 +161,17 @@ func (c *changelog) addChange(registerID flow.RegisterID, blockHeight uint64) {
 	clist.add(blockHeight)
 	c.registers[registerID] = clist
 }
+
+// changesAfter returns the IDs of all registers that changed value at a block
+// height greater than the given height.
+func (c *changelog) changesAfter(blockHeight uint64) []flow.RegisterID {
+	registerIDs := make([]flow.RegisterID, 0)
+
+	for registerID, clist := range c.registers {
+		if clist.Len() > 0 && clist.blocks[clist.Len()-1] > blockHeight {
+			registerIDs = append(registerIDs, registerID)
+		}
+	}
+
+	return registerIDs
+}
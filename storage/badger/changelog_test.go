@@ -111,6 +111,23 @@ func TestChangelist(t *testing.T) {
 		assert.Equal(t, 1, clist.Len())
 	})
 
+	t.Run("should truncate values", func(t *testing.T) {
+		var clist changelist
+
+		clist.add(1)
+		clist.add(3)
+		clist.add(5)
+
+		clist.truncate(3)
+		assert.Equal(t, []uint64{1, 3}, clist.blocks)
+
+		clist.truncate(0)
+		assert.Equal(t, 0, clist.Len())
+
+		val := clist.search(5)
+		assert.EqualValues(t, notFound, val)
+	})
+
 	t.Run("should be sorted after every insertion", func(t *testing.T) {
 		r := rand.New(rand.NewSource(42))
 
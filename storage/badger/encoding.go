@@ -24,6 +24,7 @@ import (
 	"github.com/fxamacker/cbor/v2"
 	flowgo "github.com/onflow/flow-go/model/flow"
 
+	"github.com/onflow/flow-emulator/storage"
 	"github.com/onflow/flow-emulator/types"
 )
 
@@ -63,14 +64,62 @@ func decodeTransaction(tx *flowgo.TransactionBody, from []byte) error {
 	return cbor.Unmarshal(from, tx)
 }
 
+// transactionResultVersion is the version of the encoding of transaction results,
+// stored as the first byte of encoded results.
+//
+// Version 0 results were encoded without a version byte, and do not include
+// the computation used and debug information.
+const transactionResultVersion = 1
+
 func encodeTransactionResult(result types.StorableTransactionResult) ([]byte, error) {
-	return em.Marshal(result)
+	data, err := em.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{transactionResultVersion}, data...), nil
 }
 
 func decodeTransactionResult(result *types.StorableTransactionResult, from []byte) error {
+	if len(from) == 0 {
+		return fmt.Errorf("could not decode empty transaction result")
+	}
+
+	// version 0 results are CBOR maps, which start with a byte of major type 5
+	if from[0]>>5 == 5 {
+		return decodeTransactionResultV0(result, from)
+	}
+
+	switch from[0] {
+	case transactionResultVersion:
+		return cbor.Unmarshal(from[1:], result)
+	default:
+		return fmt.Errorf("unsupported transaction result encoding version %d", from[0])
+	}
+}
+
+// decodeTransactionResultV0 decodes a transaction result of version 0. The fields
+// added since are left empty.
+func decodeTransactionResultV0(result *types.StorableTransactionResult, from []byte) error {
 	return cbor.Unmarshal(from, result)
 }
 
+func encodeTransactionLocation(location storage.TransactionLocation) ([]byte, error) {
+	return em.Marshal(location)
+}
+
+func decodeTransactionLocation(location *storage.TransactionLocation, from []byte) error {
+	return cbor.Unmarshal(from, location)
+}
+
+func encodeAccountTransaction(tx storage.AccountTransaction) ([]byte, error) {
+	return em.Marshal(tx)
+}
+
+func decodeAccountTransaction(tx *storage.AccountTransaction, from []byte) error {
+	return cbor.Unmarshal(from, tx)
+}
+
 func encodeUint64(v uint64) ([]byte, error) {
 	return em.Marshal(v)
 }
@@ -20,6 +20,7 @@ package badger
 
 import (
 	"testing"
+	"time"
 
 	"github.com/onflow/flow-go-sdk/test"
 	flowgo "github.com/onflow/flow-go/model/flow"
@@ -62,6 +63,123 @@ func TestEncodeTransactionResult(t *testing.T) {
 	assert.Equal(t, result, decodedResult)
 }
 
+func TestDecodeTransactionResultV0(t *testing.T) {
+
+	t.Parallel()
+
+	// results of version 0 were encoded without a version byte,
+	// and without the computation used and debug information
+	type storableTransactionResultV0 struct {
+		ErrorCode    int
+		ErrorMessage string
+		Logs         []string
+		Events       []flowgo.Event
+	}
+
+	result := unittest.StorableTransactionResultFixture()
+
+	data, err := em.Marshal(storableTransactionResultV0{
+		ErrorCode:    result.ErrorCode,
+		ErrorMessage: result.ErrorMessage,
+		Logs:         result.Logs,
+		Events:       result.Events,
+	})
+	require.Nil(t, err)
+
+	var decodedResult types.StorableTransactionResult
+	err = decodeTransactionResult(&decodedResult, data)
+	require.Nil(t, err)
+
+	assert.Equal(t, result.ErrorCode, decodedResult.ErrorCode)
+	assert.Equal(t, result.ErrorMessage, decodedResult.ErrorMessage)
+	assert.Equal(t, result.Logs, decodedResult.Logs)
+	assert.Equal(t, result.Events, decodedResult.Events)
+	assert.Zero(t, decodedResult.ComputationUsed)
+	assert.Nil(t, decodedResult.Debug)
+}
+
+func TestDecodeTransactionResultUnsupportedVersion(t *testing.T) {
+
+	t.Parallel()
+
+	data, err := encodeTransactionResult(unittest.StorableTransactionResultFixture())
+	require.Nil(t, err)
+
+	data[0] = transactionResultVersion + 1
+
+	var decodedResult types.StorableTransactionResult
+	err = decodeTransactionResult(&decodedResult, data)
+	assert.Error(t, err)
+}
+
+func TestEncodeTransactionResultWithStateDelta(t *testing.T) {
+
+	t.Parallel()
+
+	result := unittest.StorableTransactionResultFixture()
+	result.StateDelta = &types.TransactionStateDelta{
+		Registers: []types.RegisterDelta{
+			{
+				ID:     flowgo.RegisterID{Owner: "\x01", Key: "foo"},
+				Before: []byte{1},
+				After:  []byte{2, 3},
+			},
+		},
+		Accounts: []types.AccountStorageDelta{
+			{
+				Address:           flowgo.HexToAddress("01"),
+				BytesAdded:        1,
+				StorageUsedBefore: 100,
+				StorageUsedAfter:  101,
+			},
+		},
+	}
+
+	data, err := encodeTransactionResult(result)
+	require.Nil(t, err)
+
+	var decodedResult types.StorableTransactionResult
+	err = decodeTransactionResult(&decodedResult, data)
+	require.Nil(t, err)
+
+	assert.Equal(t, result, decodedResult)
+}
+
+func TestEncodeTransactionResultWithTrace(t *testing.T) {
+
+	t.Parallel()
+
+	result := unittest.StorableTransactionResultFixture()
+	result.Trace = &types.TransactionTrace{
+		Entries: []types.TraceEntry{
+			{
+				Kind:     types.TraceEntryFunction,
+				Name:     "transfer",
+				Location: "A.0000000000000001.Token",
+				Duration: 2 * time.Millisecond,
+			},
+			{
+				Kind:    types.TraceEntryStorageWrite,
+				Depth:   1,
+				Address: flowgo.HexToAddress("01"),
+				Key:     "foo",
+				Size:    2,
+				Value:   []byte{1, 2},
+				Start:   time.Millisecond,
+			},
+		},
+	}
+
+	data, err := encodeTransactionResult(result)
+	require.Nil(t, err)
+
+	var decodedResult types.StorableTransactionResult
+	err = decodeTransactionResult(&decodedResult, data)
+	require.Nil(t, err)
+
+	assert.Equal(t, result, decodedResult)
+}
+
 func TestEncodeBlock(t *testing.T) {
 
 	t.Parallel()
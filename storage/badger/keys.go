@@ -21,21 +21,25 @@ package badger
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 
 	flowgo "github.com/onflow/flow-go/model/flow"
 )
 
 const (
-	blockKeyPrefix             = "block_by_height"
-	blockIDIndexKeyPrefix      = "block_id_to_height"
-	collectionKeyPrefix        = "collection_by_id"
-	transactionKeyPrefix       = "transaction_by_id"
-	transactionResultKeyPrefix = "transaction_result_by_id"
-	ledgerKeyPrefix            = "ledger_by_block_height" // TODO remove
-	eventKeyPrefix             = "event_by_block_height"
-	ledgerChangelogKeyPrefix   = "ledger_changelog_by_register_id"
-	ledgerValueKeyPrefix       = "ledger_value_by_block_height_register_id"
+	blockKeyPrefix               = "block_by_height"
+	blockIDIndexKeyPrefix        = "block_id_to_height"
+	collectionKeyPrefix          = "collection_by_id"
+	transactionKeyPrefix         = "transaction_by_id"
+	transactionResultKeyPrefix   = "transaction_result_by_id"
+	ledgerKeyPrefix              = "ledger_by_block_height" // TODO remove
+	eventKeyPrefix               = "event_by_block_height"
+	eventTypeKeyPrefix           = "event_by_type_block_height"
+	ledgerChangelogKeyPrefix     = "ledger_changelog_by_register_id"
+	ledgerValueKeyPrefix         = "ledger_value_by_block_height_register_id"
+	accountTransactionKeyPrefix  = "transaction_by_account_block_height_index"
+	transactionLocationKeyPrefix = "transaction_location_by_id"
 )
 
 // The following *Key functions return keys to use when reading/writing values
@@ -50,6 +54,11 @@ func latestBlockKey() []byte {
 	return []byte("latest_block_height")
 }
 
+// eventsIndexedByTypeKey marks databases in which all events are indexed by type.
+func eventsIndexedByTypeKey() []byte {
+	return []byte("events_indexed_by_type")
+}
+
 func blockKey(blockHeight uint64) []byte {
 	return []byte(fmt.Sprintf("%s-%032d", blockKeyPrefix, blockHeight))
 }
@@ -70,6 +79,10 @@ func transactionResultKey(txID flowgo.Identifier) []byte {
 	return []byte(fmt.Sprintf("%s-%x", transactionResultKeyPrefix, txID))
 }
 
+func transactionLocationKey(txID flowgo.Identifier) []byte {
+	return []byte(fmt.Sprintf("%s-%x", transactionLocationKeyPrefix, txID))
+}
+
 func eventKey(blockHeight uint64, txIndex, eventIndex uint32, eventType flowgo.EventType) []byte {
 	return []byte(fmt.Sprintf(
 		"%s-%032d-%032d-%032d-%s",
@@ -89,11 +102,66 @@ func eventKeyBlockPrefix(blockHeight uint64) []byte {
 	))
 }
 
+// eventKeyAllPrefix returns the prefix of the keys of all events indexed by block height.
+func eventKeyAllPrefix() []byte {
+	return []byte(fmt.Sprintf("%s-", eventKeyPrefix))
+}
+
+// eventTypeKey returns the key of an event in the index of events by type, which orders
+// events of the same type by block height, so that they can be read in a single pass.
+func eventTypeKey(blockHeight uint64, txIndex, eventIndex uint32, eventType flowgo.EventType) []byte {
+	return []byte(fmt.Sprintf(
+		"%s-%s-%032d-%032d-%032d",
+		eventTypeKeyPrefix,
+		eventType,
+		blockHeight,
+		txIndex,
+		eventIndex,
+	))
+}
+
+func eventTypeKeyTypePrefix(eventType string) []byte {
+	return []byte(fmt.Sprintf("%s-%s-", eventTypeKeyPrefix, eventType))
+}
+
+func eventTypeKeyBlockPrefix(eventType string, blockHeight uint64) []byte {
+	return []byte(fmt.Sprintf(
+		"%s-%s-%032d",
+		eventTypeKeyPrefix,
+		eventType,
+		blockHeight,
+	))
+}
+
+// blockHeightFromKey recovers the zero-padded block height that directly follows
+// the given prefix in a key.
+func blockHeightFromKey(key []byte, prefix []byte) (uint64, error) {
+	if !bytes.HasPrefix(key, prefix) || len(key) < len(prefix)+32 {
+		return 0, fmt.Errorf("failed to parse block height from %s", string(key))
+	}
+
+	return strconv.ParseUint(string(key[len(prefix):len(prefix)+32]), 10, 64)
+}
+
 func eventKeyHasType(key []byte, eventType []byte) bool {
 	// event type is at the end of the key, so we can simply compare suffixes
 	return bytes.HasSuffix(key, eventType)
 }
 
+func accountTransactionKey(address flowgo.Address, blockHeight uint64, txIndex uint32) []byte {
+	return []byte(fmt.Sprintf(
+		"%s-%x-%032d-%032d",
+		accountTransactionKeyPrefix,
+		address,
+		blockHeight,
+		txIndex,
+	))
+}
+
+func accountTransactionKeyAddressPrefix(address flowgo.Address) []byte {
+	return []byte(fmt.Sprintf("%s-%x-", accountTransactionKeyPrefix, address))
+}
+
 // TODO remove this
 func ledgerKey(blockHeight uint64) []byte {
 	return []byte(fmt.Sprintf("%s-%032d", ledgerKeyPrefix, blockHeight))
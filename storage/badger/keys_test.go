@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"testing"
 
+	flowgo "github.com/onflow/flow-go/model/flow"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -82,4 +83,54 @@ func TestKeyOrdering(t *testing.T) {
 			assert.Equal(t, -1, bytes.Compare(keys[i], keys[i+1]))
 		}
 	})
+
+	t.Run("event type key", func(t *testing.T) {
+
+		t.Parallel()
+
+		var keys [][]byte
+		for _, num := range nums {
+			for i := 0; i < 3; i++ {
+				keys = append(keys, eventTypeKey(num, uint32(i), 0, "foo"))
+			}
+		}
+
+		for i := 0; i < len(keys)-1; i++ {
+			// lower index keys should be considered less
+			assert.Equal(t, -1, bytes.Compare(keys[i], keys[i+1]))
+		}
+	})
+
+	t.Run("account transaction key", func(t *testing.T) {
+
+		t.Parallel()
+
+		address := flowgo.HexToAddress("01")
+
+		var keys [][]byte
+		for _, num := range nums {
+			for i := 0; i < 3; i++ {
+				keys = append(keys, accountTransactionKey(address, num, uint32(i)))
+			}
+		}
+
+		for i := 0; i < len(keys)-1; i++ {
+			// lower index keys should be considered less
+			assert.Equal(t, -1, bytes.Compare(keys[i], keys[i+1]))
+		}
+	})
+}
+
+func TestBlockHeightFromKey(t *testing.T) {
+
+	t.Parallel()
+
+	prefix := eventTypeKeyTypePrefix("foo")
+
+	height, err := blockHeightFromKey(eventTypeKey(19825983621301235, 1, 2, "foo"), prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(19825983621301235), height)
+
+	_, err = blockHeightFromKey(eventTypeKey(1, 1, 2, "bar"), prefix)
+	assert.Error(t, err)
 }
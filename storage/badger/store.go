@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -49,19 +50,20 @@ type Store struct {
 }
 
 var _ storage.Store = &Store{}
+var _ storage.RegisterChangelog = &Store{}
 
+// getTag returns the tag with the given name, or nil if there is none.
+//
+// Tags are looked up by reference, as the objects of deleted tags remain in the repository.
 func getTag(r *git.Repository, tag string) *object.Tag {
-	tags, err := r.TagObjects()
+	ref, err := r.Tag(tag)
 	if err != nil {
 		return nil
 	}
-	var res *object.Tag = nil
-	_ = tags.ForEach(func(t *object.Tag) error {
-		if t.Name == tag {
-			res = t
-		}
+	res, err := r.TagObject(ref.Hash())
+	if err != nil {
 		return nil
-	})
+	}
 	return res
 }
 
@@ -103,74 +105,191 @@ func (s *Store) unlockGit() {
 	_ = os.Remove(lockPath)
 }
 
+// JumpToContext creates a snapshot of the current state with the given name if
+// it does not exist, otherwise it reverts the store to the existing snapshot.
 func (s *Store) JumpToContext(context string) error {
-	s.unlockGit()
-	defer s.lockGit()
-	err := s.db.Close()
-	if err != nil {
-		return err
+	if getTag(s.dbGitRepository, context) == nil {
+		return s.CreateSnapshot(context)
 	}
 
-	err = s.newCommit(fmt.Sprintf("Context switching to: %s", context))
-	if err != nil {
-		return err
+	return s.LoadSnapshot(context)
+}
+
+// CreateSnapshot creates a snapshot of the current state with the given name.
+//
+// Snapshots are stored as a git branch and tag, both named after the snapshot.
+func (s *Store) CreateSnapshot(name string) error {
+	if getTag(s.dbGitRepository, name) != nil {
+		return storage.ErrAlreadyExists
 	}
-	w, err := s.dbGitRepository.Worktree()
-	if err != nil {
-		return err
+
+	return s.switchContext(name, func(w *git.Worktree) error {
+		// first branch is named after the snapshot
+		err := w.Checkout(&git.CheckoutOptions{
+			Create: true,
+			Force:  true,
+			Branch: plumbing.NewBranchReferenceName(name),
+		})
+		if err != nil {
+			return err
+		}
+
+		// after we create a tag pointing to start of this context
+		created, err := setTag(s.dbGitRepository, name, defaultSignature("Emulator", "emulator@onflow.org"))
+		if err != nil && !created {
+			return err
+		}
+
+		s.badgerOptions.Logger.Infof("Created a new state snapshot with the name '%s'", name)
+
+		return nil
+	})
+}
+
+// LoadSnapshot reverts the store to the snapshot with the given name.
+//
+// The state is reset on a new branch, so the snapshot itself is left untouched
+// and can be loaded again later.
+func (s *Store) LoadSnapshot(name string) error {
+	tag := getTag(s.dbGitRepository, name)
+	if tag == nil {
+		return storage.ErrNotFound
 	}
-	branch := fmt.Sprintf("refs/heads/%s", context)
-	b := plumbing.ReferenceName(branch)
 
-	// checkout branch ( first branch name is actually context name )
-	err = w.Checkout(&git.CheckoutOptions{Create: false, Force: true, Branch: b})
+	return s.switchContext(name, func(w *git.Worktree) error {
+		err := w.Checkout(&git.CheckoutOptions{
+			Create: true,
+			Force:  true,
+			Branch: newBranchReferenceName(),
+		})
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		// branch doesn't exist, it means we need to create it ( first branch is named after context )
-		err := w.Checkout(&git.CheckoutOptions{Create: true, Force: true, Branch: b})
+		commit, err := tag.Commit()
 		if err != nil {
 			return err
 		}
 
-		//after we create a tag pointing to start of this context
-		created, err := setTag(s.dbGitRepository, context, defaultSignature("Emulator", "emulator@onflow.org"))
-		if err != nil && !created {
+		err = w.Reset(&git.ResetOptions{
+			Mode:   git.HardReset,
+			Commit: commit.Hash,
+		})
+		if err != nil {
 			return err
 		}
 
-		s.badgerOptions.Logger.Infof("Created a new state snapshot with the name '%s'", context)
+		s.badgerOptions.Logger.Infof("Switched to snapshot with name '%s'", name)
 
-	} else {
+		return nil
+	})
+}
+
+// ListSnapshots returns the names of all snapshots in lexicographic order.
+func (s *Store) ListSnapshots() ([]string, error) {
+	tags, err := s.dbGitRepository.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		names = append(names, ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		//create new branch
-		uuidWithHyphen := uuid.New()
-		newBranchUuid := strings.Replace(uuidWithHyphen.String(), "-", "", -1)
+	sort.Strings(names)
 
-		err := w.Checkout(&git.CheckoutOptions{Create: true, Force: true, Branch: plumbing.NewBranchReferenceName(newBranchUuid)})
+	return names, nil
+}
+
+// DeleteSnapshot deletes the tag and branch of the snapshot with the given name.
+//
+// The current state is not changed, even if it was created from the snapshot.
+func (s *Store) DeleteSnapshot(name string) error {
+	if getTag(s.dbGitRepository, name) == nil {
+		return storage.ErrNotFound
+	}
+
+	s.unlockGit()
+	defer s.lockGit()
+
+	err := s.dbGitRepository.DeleteTag(name)
+	if err != nil {
+		return err
+	}
+
+	branch := plumbing.NewBranchReferenceName(name)
+
+	head, err := s.dbGitRepository.Head()
+	if err != nil {
+		return err
+	}
+
+	// move HEAD to a new branch before deleting the snapshot branch,
+	// without touching the database files in the worktree
+	if head.Name() == branch {
+		newBranch := newBranchReferenceName()
+
+		err = s.dbGitRepository.Storer.SetReference(plumbing.NewHashReference(newBranch, head.Hash()))
 		if err != nil {
 			return err
 		}
 
-		//we have new branch but we don't need to create a tag, we just need to reset to tag
-		tag := getTag(s.dbGitRepository, context)
-		if tag != nil && !tag.Hash.IsZero() {
-			commit, _ := tag.Commit()
-			_ = w.Reset(&git.ResetOptions{
-				Mode:   git.HardReset,
-				Commit: commit.Hash,
-			})
+		err = s.dbGitRepository.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, newBranch))
+		if err != nil {
+			return err
 		}
-		s.badgerOptions.Logger.Infof("Switched to snapshot with name '%s'", context)
+	}
+
+	return s.dbGitRepository.Storer.RemoveReference(branch)
+}
+
+// switchContext closes the database, commits its current state and applies
+// the given checkout to the worktree, then reopens the database and reloads
+// the ledger changelog for the new state.
+func (s *Store) switchContext(context string, checkout func(w *git.Worktree) error) error {
+	s.unlockGit()
+	defer s.lockGit()
+	err := s.db.Close()
+	if err != nil {
+		return err
+	}
 
+	err = s.newCommit(fmt.Sprintf("Context switching to: %s", context))
+	if err != nil {
+		return err
 	}
 
+	w, err := s.dbGitRepository.Worktree()
+	if err != nil {
+		return err
+	}
+
+	checkoutErr := checkout(w)
+
+	// reopen the database even if the checkout failed, so the store stays usable
 	s.db, err = badger.Open(s.badgerOptions)
 	if err != nil {
 		return fmt.Errorf("could not open database: %w", err)
 	}
 
-	return nil
+	if checkoutErr != nil {
+		return checkoutErr
+	}
 
+	return s.loadChangelog()
+}
+
+// newBranchReferenceName returns a reference name for a new, uniquely named branch.
+func newBranchReferenceName() plumbing.ReferenceName {
+	uuidWithHyphen := uuid.New()
+	newBranchUuid := strings.Replace(uuidWithHyphen.String(), "-", "", -1)
+
+	return plumbing.NewBranchReferenceName(newBranchUuid)
 }
 
 func (s *Store) newCommit(message string) error {
@@ -277,6 +396,91 @@ func (s *Store) setup() error {
 	}
 	s.lockGit()
 
+	err = s.loadChangelog()
+	if err != nil {
+		return err
+	}
+
+	return s.indexEventTypes()
+}
+
+// indexEventTypes adds the events of databases created before events were indexed
+// by type to the index. It is a no-op once all events are indexed.
+func (s *Store) indexEventTypes() error {
+	indexed := false
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(eventsIndexedByTypeKey())
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		indexed = true
+		return nil
+	})
+	if err != nil || indexed {
+		return err
+	}
+
+	// a write batch splits the writes into as many transactions as needed
+	batch := s.db.NewWriteBatch()
+	defer batch.Cancel()
+
+	iterOpts := badger.DefaultIteratorOptions
+	iterOpts.Prefix = eventKeyAllPrefix()
+
+	err = s.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(iterOpts)
+		defer iter.Close()
+
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			item := iter.Item()
+
+			blockHeight, err := blockHeightFromKey(item.Key(), iterOpts.Prefix)
+			if err != nil {
+				return err
+			}
+
+			encEvent, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			var event flowgo.Event
+			if err := decodeEvent(&event, encEvent); err != nil {
+				return err
+			}
+
+			key := eventTypeKey(blockHeight, event.TransactionIndex, event.EventIndex, event.Type)
+			if err := batch.Set(key, encEvent); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := batch.Set(eventsIndexedByTypeKey(), []byte{1}); err != nil {
+		return err
+	}
+
+	return batch.Flush()
+}
+
+// loadChangelog replaces the in-memory ledger changelog with the changelists
+// stored in the database.
+func (s *Store) loadChangelog() error {
+	s.ledgerChangeLog.Lock()
+	defer s.ledgerChangeLog.Unlock()
+
+	s.ledgerChangeLog.registers = make(map[flowgo.RegisterID]changelist)
+
 	s.db.RLock()
 	defer s.db.RUnlock()
 
@@ -479,6 +683,13 @@ func (s *Store) CommitBlock(
 
 		}
 
+		for txID, location := range storage.TransactionLocationsInBlock(block.Header.Height, collections) {
+			err = insertTransactionLocation(txID, location)(txn)
+			if err != nil {
+				return err
+			}
+		}
+
 		err = s.insertLedgerDelta(block.Header.Height, delta)(txn)
 		if err != nil {
 			return err
@@ -491,7 +702,14 @@ func (s *Store) CommitBlock(
 			}
 		}
 
-		return nil
+		accountTransactions := storage.AccountTransactionsInBlock(
+			block.Header.Height,
+			collections,
+			transactions,
+			transactionResults,
+		)
+
+		return insertAccountTransactions(accountTransactions)(txn)
 	})
 	if err != nil {
 		return err
@@ -500,6 +718,46 @@ func (s *Store) CommitBlock(
 	return err
 }
 
+// emptyBlocksBatchSize is the maximum number of empty blocks written in one database transaction.
+const emptyBlocksBatchSize = 1000
+
+// rollbackBatchSize is the maximum number of blocks removed in one database transaction.
+const rollbackBatchSize = 1000
+
+func (s *Store) CommitEmptyBlocks(blocks []flowgo.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	for start := 0; start < len(blocks); start += emptyBlocksBatchSize {
+		end := start + emptyBlocksBatchSize
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+
+		err := s.db.Update(func(txn *badger.Txn) error {
+			for i := start; i < end; i++ {
+				err := store(&blocks[i])(txn)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.newCommit(fmt.Sprintf(
+		"Committed %d empty blocks: %d to %d\n",
+		len(blocks),
+		blocks[0].Header.Height,
+		blocks[len(blocks)-1].Header.Height,
+	))
+}
+
 func (s *Store) CollectionByID(colID flowgo.Identifier) (col flowgo.LightCollection, err error) {
 	err = s.db.View(func(txn *badger.Txn) error {
 		encCol, err := getTx(txn)(collectionKey(colID))
@@ -578,6 +836,28 @@ func insertTransactionResult(txID flowgo.Identifier, result types.StorableTransa
 	}
 }
 
+func (s *Store) TransactionLocationByID(txID flowgo.Identifier) (location storage.TransactionLocation, err error) {
+	err = s.db.View(func(txn *badger.Txn) error {
+		encLocation, err := getTx(txn)(transactionLocationKey(txID))
+		if err != nil {
+			return err
+		}
+		return decodeTransactionLocation(&location, encLocation)
+	})
+	return
+}
+
+func insertTransactionLocation(txID flowgo.Identifier, location storage.TransactionLocation) func(txn *badger.Txn) error {
+	return func(txn *badger.Txn) error {
+		encLocation, err := encodeTransactionLocation(location)
+		if err != nil {
+			return err
+		}
+
+		return txn.Set(transactionLocationKey(txID), encLocation)
+	}
+}
+
 func (s *Store) LedgerViewByHeight(blockHeight uint64) *delta.View {
 	return delta.NewView(func(owner, controller, key string) (value flowgo.RegisterValue, err error) {
 		id := flowgo.RegisterID{
@@ -613,6 +893,77 @@ func (s *Store) LedgerViewByHeight(blockHeight uint64) *delta.View {
 	})
 }
 
+func (s *Store) RegistersByOwner(owner flowgo.Address, blockHeight uint64) ([]flowgo.RegisterEntry, error) {
+	s.ledgerChangeLog.RLock()
+	defer s.ledgerChangeLog.RUnlock()
+
+	ownerString := string(owner.Bytes())
+
+	registers := make([]flowgo.RegisterEntry, 0)
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		for registerID := range s.ledgerChangeLog.registers {
+			if registerID.Owner != ownerString {
+				continue
+			}
+
+			lastChangedBlock := s.ledgerChangeLog.getMostRecentChange(registerID, blockHeight)
+			if lastChangedBlock == notFound {
+				continue
+			}
+
+			value, err := getTx(txn)(ledgerValueKey(registerID, lastChangedBlock))
+			if err != nil {
+				// deleted registers have no value
+				if errors.Is(err, storage.ErrNotFound) {
+					continue
+				}
+
+				return err
+			}
+
+			registers = append(registers, flowgo.RegisterEntry{
+				Key:   registerID,
+				Value: value,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storage.SortRegisters(registers)
+
+	return registers, nil
+}
+
+func (s *Store) RegisterChanged(id flowgo.RegisterID, blockHeight uint64) (bool, error) {
+	s.ledgerChangeLog.RLock()
+	defer s.ledgerChangeLog.RUnlock()
+
+	return s.ledgerChangeLog.getMostRecentChange(id, blockHeight) != notFound, nil
+}
+
+func (s *Store) RegistersChangedBetween(fromHeight, toHeight uint64) ([]flowgo.RegisterID, error) {
+	s.ledgerChangeLog.RLock()
+	defer s.ledgerChangeLog.RUnlock()
+
+	ids := make([]flowgo.RegisterID, 0)
+
+	for registerID, clist := range s.ledgerChangeLog.registers {
+		lastChangedBlock := clist.search(toHeight)
+		if lastChangedBlock != notFound && lastChangedBlock > fromHeight {
+			ids = append(ids, registerID)
+		}
+	}
+
+	storage.SortRegisterIDs(ids)
+
+	return ids, nil
+}
+
 func (s *Store) InsertLedgerDelta(blockHeight uint64, delta delta.Delta) error {
 	return s.db.Update(s.insertLedgerDelta(blockHeight, delta))
 }
@@ -701,6 +1052,64 @@ func (s *Store) EventsByHeight(blockHeight uint64, eventType string) (events []f
 	return
 }
 
+func (s *Store) EventsByHeightRange(
+	eventType string,
+	startHeight, endHeight uint64,
+) (events map[uint64][]flowgo.Event, err error) {
+	events = make(map[uint64][]flowgo.Event)
+
+	iterOpts := badger.DefaultIteratorOptions
+
+	// events of a type are read from the index by type, which holds them in
+	// block height order, so the whole range is covered in a single pass
+	var startKey []byte
+	if eventType == "" {
+		iterOpts.Prefix = eventKeyAllPrefix()
+		startKey = eventKeyBlockPrefix(startHeight)
+	} else {
+		iterOpts.Prefix = eventTypeKeyTypePrefix(eventType)
+		startKey = eventTypeKeyBlockPrefix(eventType, startHeight)
+	}
+
+	err = s.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(iterOpts)
+		defer iter.Close()
+
+		for iter.Seek(startKey); iter.Valid(); iter.Next() {
+			item := iter.Item()
+
+			blockHeight, err := blockHeightFromKey(item.Key(), iterOpts.Prefix)
+			if err != nil {
+				return err
+			}
+
+			if blockHeight > endHeight {
+				break
+			}
+
+			err = item.Value(func(b []byte) error {
+				var event flowgo.Event
+
+				err := decodeEvent(&event, b)
+				if err != nil {
+					return err
+				}
+
+				events[blockHeight] = append(events[blockHeight], event)
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return
+}
+
 func (s *Store) InsertEvents(blockHeight uint64, events []flowgo.Event) error {
 	return s.db.Update(insertEvents(blockHeight, events))
 }
@@ -719,6 +1128,330 @@ func insertEvents(blockHeight uint64, events []flowgo.Event) func(txn *badger.Tx
 			if err != nil {
 				return err
 			}
+
+			typeKey := eventTypeKey(blockHeight, event.TransactionIndex, event.EventIndex, event.Type)
+
+			err = txn.Set(typeKey, b)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func (s *Store) TransactionsByAccount(
+	address flowgo.Address,
+	cursor *storage.AccountTransactionCursor,
+	limit int,
+) (txs []storage.AccountTransaction, err error) {
+	// set up a reverse iterator over all transactions of the account, to return the newest first
+	iterOpts := badger.DefaultIteratorOptions
+	iterOpts.Prefix = accountTransactionKeyAddressPrefix(address)
+	iterOpts.Reverse = true
+
+	// seeking in reverse finds the highest key at or below the seek key,
+	// so start past the highest possible key for this account
+	startKey := append(accountTransactionKeyAddressPrefix(address), 0xff)
+	if cursor != nil {
+		startKey = accountTransactionKey(address, cursor.BlockHeight, cursor.TransactionIndex)
+	}
+
+	txs = make([]storage.AccountTransaction, 0)
+
+	err = s.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(iterOpts)
+		defer iter.Close()
+
+		for iter.Seek(startKey); iter.Valid(); iter.Next() {
+			if limit > 0 && len(txs) == limit {
+				break
+			}
+
+			err := iter.Item().Value(func(b []byte) error {
+				var tx storage.AccountTransaction
+
+				err := decodeAccountTransaction(&tx, b)
+				if err != nil {
+					return err
+				}
+
+				// the transaction at the cursor itself is excluded
+				if cursor != nil && !cursor.Before(tx.BlockHeight, tx.TransactionIndex) {
+					return nil
+				}
+
+				txs = append(txs, tx)
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func insertAccountTransactions(
+	accountTransactions map[flowgo.Address][]storage.AccountTransaction,
+) func(txn *badger.Txn) error {
+	return func(txn *badger.Txn) error {
+		for address, txs := range accountTransactions {
+			for _, tx := range txs {
+				b, err := encodeAccountTransaction(tx)
+				if err != nil {
+					return err
+				}
+
+				key := accountTransactionKey(address, tx.BlockHeight, tx.TransactionIndex)
+
+				err = txn.Set(key, b)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// RollbackToHeight removes all blocks above the given height, along with their collections,
+// transactions, transaction results, events and ledger changes.
+//
+// The data is removed in batches to stay within the transaction size limit of the database.
+// If the rollback fails, the blocks that were removed stay removed, and it can be retried.
+func (s *Store) RollbackToHeight(blockHeight uint64) error {
+	s.ledgerChangeLog.Lock()
+	defer s.ledgerChangeLog.Unlock()
+
+	var latestBlockHeight uint64
+	err := s.db.View(func(txn *badger.Txn) (err error) {
+		latestBlockHeight, err = getLatestBlockHeightTx(txn)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if blockHeight > latestBlockHeight {
+		return storage.ErrNotFound
+	}
+
+	// blocks are removed from the top in batches, and each batch lowers the latest block height,
+	// so the remaining blocks stay consistent if a later batch fails
+	for top := latestBlockHeight; top > blockHeight; {
+		bottom := blockHeight
+		if top-blockHeight > rollbackBatchSize {
+			bottom = top - rollbackBatchSize
+		}
+
+		err := s.db.Update(func(txn *badger.Txn) error {
+			for height := top; height > bottom; height-- {
+				err := removeBlock(height)(txn)
+				if err != nil {
+					return err
+				}
+			}
+
+			encBlockHeight, err := encodeUint64(bottom)
+			if err != nil {
+				return err
+			}
+
+			return txn.Set(latestBlockKey(), encBlockHeight)
+		})
+		if err != nil {
+			return err
+		}
+
+		top = bottom
+	}
+
+	// changelists of registers changed above the given height, truncated to that height
+	changelists := make(map[flowgo.RegisterID]changelist)
+
+	// a write batch splits the writes into as many transactions as needed
+	batch := s.db.NewWriteBatch()
+	defer batch.Cancel()
+
+	for _, registerID := range s.ledgerChangeLog.changesAfter(blockHeight) {
+		clist := s.ledgerChangeLog.getChangelist(registerID)
+
+		for _, height := range clist.blocks {
+			if height <= blockHeight {
+				continue
+			}
+
+			// deleted registers have no value written, which is a no-op here
+			if err := batch.Delete(ledgerValueKey(registerID, height)); err != nil {
+				return err
+			}
+		}
+
+		// copy before truncating, so the in-memory changelog is only
+		// updated if the writes succeed
+		truncated := changelist{blocks: append([]uint64{}, clist.blocks...)}
+		truncated.truncate(blockHeight)
+		changelists[registerID] = truncated
+
+		if truncated.Len() == 0 {
+			if err := batch.Delete(ledgerChangelogKey(registerID)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		encChangelist, err := encodeChangelist(truncated)
+		if err != nil {
+			return err
+		}
+
+		if err := batch.Set(ledgerChangelogKey(registerID), encChangelist); err != nil {
+			return err
+		}
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return err
+	}
+
+	for registerID, clist := range changelists {
+		s.ledgerChangeLog.setChangelist(registerID, clist)
+	}
+
+	return s.newCommit(fmt.Sprintf("Rolled back to block height: %d", blockHeight))
+}
+
+// removeBlock removes the block at the given height, along with its
+// collections, transactions, transaction results and locations, account transactions and events.
+func removeBlock(blockHeight uint64) func(txn *badger.Txn) error {
+	return func(txn *badger.Txn) error {
+		encBlock, err := getTx(txn)(blockKey(blockHeight))
+		if err != nil {
+			return err
+		}
+
+		var block flowgo.Block
+		if err := decodeBlock(&block, encBlock); err != nil {
+			return err
+		}
+
+		if block.Payload != nil {
+			collections := make([]*flowgo.LightCollection, 0, len(block.Payload.Guarantees))
+			transactions := make(map[flowgo.Identifier]*flowgo.TransactionBody)
+			transactionResults := make(map[flowgo.Identifier]*types.StorableTransactionResult)
+
+			for _, guarantee := range block.Payload.Guarantees {
+				encCol, err := getTx(txn)(collectionKey(guarantee.CollectionID))
+				if err != nil {
+					return err
+				}
+
+				var col flowgo.LightCollection
+				if err := decodeCollection(&col, encCol); err != nil {
+					return err
+				}
+
+				collections = append(collections, &col)
+
+				for _, txID := range col.Transactions {
+					// the transaction and result are needed to find the accounts involved
+					tx, result, err := getTransactionAndResultTx(txn, txID)
+					if err != nil && !errors.Is(err, storage.ErrNotFound) {
+						return err
+					}
+
+					if err == nil {
+						transactions[txID] = tx
+						transactionResults[txID] = result
+					}
+
+					if err := txn.Delete(transactionKey(txID)); err != nil {
+						return err
+					}
+					if err := txn.Delete(transactionResultKey(txID)); err != nil {
+						return err
+					}
+					if err := txn.Delete(transactionLocationKey(txID)); err != nil {
+						return err
+					}
+				}
+
+				if err := txn.Delete(collectionKey(guarantee.CollectionID)); err != nil {
+					return err
+				}
+			}
+
+			accountTransactions := storage.AccountTransactionsInBlock(
+				blockHeight,
+				collections,
+				transactions,
+				transactionResults,
+			)
+
+			for address, txs := range accountTransactions {
+				for _, tx := range txs {
+					key := accountTransactionKey(address, tx.BlockHeight, tx.TransactionIndex)
+					if err := txn.Delete(key); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		if err := txn.Delete(blockIDIndexKey(block.ID())); err != nil {
+			return err
+		}
+
+		if err := txn.Delete(blockKey(blockHeight)); err != nil {
+			return err
+		}
+
+		return removeEvents(blockHeight)(txn)
+	}
+}
+
+// removeEvents removes all events in the block at the given height, and their
+// entries in the index of events by type.
+func removeEvents(blockHeight uint64) func(txn *badger.Txn) error {
+	return func(txn *badger.Txn) error {
+		iterOpts := badger.DefaultIteratorOptions
+		iterOpts.Prefix = eventKeyBlockPrefix(blockHeight)
+
+		keys := make([][]byte, 0)
+
+		iter := txn.NewIterator(iterOpts)
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			item := iter.Item()
+
+			var event flowgo.Event
+			err := item.Value(func(b []byte) error {
+				return decodeEvent(&event, b)
+			})
+			if err != nil {
+				iter.Close()
+				return err
+			}
+
+			keys = append(
+				keys,
+				item.KeyCopy(nil),
+				eventTypeKey(blockHeight, event.TransactionIndex, event.EventIndex, event.Type),
+			)
+		}
+		iter.Close()
+
+		for _, key := range keys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
 		}
 
 		return nil
@@ -780,6 +1513,35 @@ func getTx(txn *badger.Txn) func([]byte) ([]byte, error) {
 	}
 }
 
+// getTransactionAndResultTx retrieves the transaction with the given ID and its result.
+// Must be called from within a Badger transaction.
+func getTransactionAndResultTx(
+	txn *badger.Txn,
+	txID flowgo.Identifier,
+) (*flowgo.TransactionBody, *types.StorableTransactionResult, error) {
+	encTx, err := getTx(txn)(transactionKey(txID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tx flowgo.TransactionBody
+	if err := decodeTransaction(&tx, encTx); err != nil {
+		return nil, nil, err
+	}
+
+	encResult, err := getTx(txn)(transactionResultKey(txID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result types.StorableTransactionResult
+	if err := decodeTransactionResult(&result, encResult); err != nil {
+		return nil, nil, err
+	}
+
+	return &tx, &result, nil
+}
+
 // getLatestBlockHeightTx retrieves the latest block height and returns it.
 // Must be called from within a Badger transaction.
 func getLatestBlockHeightTx(txn *badger.Txn) (uint64, error) {
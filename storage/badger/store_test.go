@@ -34,6 +34,7 @@ import (
 	convert "github.com/onflow/flow-emulator/convert/sdk"
 	"github.com/onflow/flow-emulator/storage"
 	"github.com/onflow/flow-emulator/storage/badger"
+	"github.com/onflow/flow-emulator/types"
 	"github.com/onflow/flow-emulator/utils/unittest"
 )
 
@@ -448,6 +449,78 @@ func TestEventsByHeight(t *testing.T) {
 	})
 }
 
+func TestEventsByHeightRange(t *testing.T) {
+
+	t.Parallel()
+
+	store, dir := setupStore(t)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	events := test.EventGenerator()
+
+	newEvent := func(eventType flowgo.EventType, txIndex uint32) flowgo.Event {
+		event, _ := convert.SDKEventToFlow(events.New())
+		event.Type = eventType
+		event.TransactionIndex = txIndex
+		return event
+	}
+
+	// blocks 1 to 5, where block 3 has no events and type "A.1.C.E2"
+	// shares a prefix with type "A.1.C.E"
+	eventsByHeight := map[uint64][]flowgo.Event{
+		1: {newEvent("A.1.C.E", 0), newEvent("A.1.C.F", 0)},
+		2: {newEvent("A.1.C.E2", 0)},
+		4: {newEvent("A.1.C.E", 0), newEvent("A.1.C.E", 1)},
+		5: {newEvent("A.1.C.F", 0)},
+	}
+
+	for height, blockEvents := range eventsByHeight {
+		err := store.InsertEvents(height, blockEvents)
+		require.NoError(t, err)
+	}
+
+	t.Run("should return events of all types by height", func(t *testing.T) {
+		events, err := store.EventsByHeightRange("", 2, 4)
+		require.NoError(t, err)
+
+		assert.Equal(
+			t,
+			map[uint64][]flowgo.Event{
+				2: eventsByHeight[2],
+				4: eventsByHeight[4],
+			},
+			events,
+		)
+	})
+
+	t.Run("should return events of type by height", func(t *testing.T) {
+		events, err := store.EventsByHeightRange("A.1.C.E", 1, 5)
+		require.NoError(t, err)
+
+		assert.Equal(
+			t,
+			map[uint64][]flowgo.Event{
+				1: eventsByHeight[1][:1],
+				4: eventsByHeight[4],
+			},
+			events,
+		)
+	})
+
+	t.Run("should return no events for empty range", func(t *testing.T) {
+		events, err := store.EventsByHeightRange("A.1.C.F", 2, 4)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		events, err = store.EventsByHeightRange("", 6, 10)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+}
+
 func TestPersistence(t *testing.T) {
 
 	t.Parallel()
@@ -664,3 +737,558 @@ func dirSize(path string) (int64, error) {
 	})
 	return size, err
 }
+
+func TestRollbackToHeight(t *testing.T) {
+
+	t.Parallel()
+
+	store, dir := setupStore(t)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	const owner = ""
+	const controller = ""
+	const key = "foo"
+
+	eventGenerator := test.EventGenerator()
+	txGenerator := test.TransactionGenerator()
+
+	commitBlock := func(height uint64, value string) (flowgo.Block, flowgo.TransactionBody) {
+		tx := *convert.SDKTransactionToFlow(*txGenerator.New())
+		// the generated transactions have the same ID, so they are made unique by their gas limit
+		tx.GasLimit = height
+		result := unittest.StorableTransactionResultFixture()
+		col := flowgo.LightCollection{Transactions: []flowgo.Identifier{tx.ID()}}
+
+		block := flowgo.Block{
+			Header: &flowgo.Header{
+				Height: height,
+			},
+			Payload: &flowgo.Payload{
+				Guarantees: []*flowgo.CollectionGuarantee{{CollectionID: col.ID()}},
+			},
+		}
+
+		d := delta.NewDelta()
+		d.Set(owner, controller, key, []byte(value))
+
+		event, err := convert.SDKEventToFlow(eventGenerator.New())
+		require.NoError(t, err)
+
+		err = store.CommitBlock(
+			block,
+			[]*flowgo.LightCollection{&col},
+			map[flowgo.Identifier]*flowgo.TransactionBody{tx.ID(): &tx},
+			map[flowgo.Identifier]*types.StorableTransactionResult{tx.ID(): &result},
+			d,
+			[]flowgo.Event{event},
+		)
+		require.NoError(t, err)
+
+		return block, tx
+	}
+
+	block1, tx1 := commitBlock(1, "bar")
+	block2, tx2 := commitBlock(2, "baz")
+
+	t.Run("should return error for height above latest block", func(t *testing.T) {
+		err := store.RollbackToHeight(3)
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("should remove blocks above height", func(t *testing.T) {
+		err := store.RollbackToHeight(1)
+		require.NoError(t, err)
+
+		latestBlock, err := store.LatestBlock()
+		require.NoError(t, err)
+		assert.Equal(t, block1.ID(), latestBlock.ID())
+
+		_, err = store.BlockByID(block2.ID())
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		_, err = store.CollectionByID(block2.Payload.Guarantees[0].CollectionID)
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		_, err = store.TransactionByID(tx2.ID())
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		_, err = store.TransactionResultByID(tx2.ID())
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		_, err = store.TransactionByID(tx1.ID())
+		assert.NoError(t, err)
+
+		_, err = store.TransactionLocationByID(tx2.ID())
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		location, err := store.TransactionLocationByID(tx1.ID())
+		require.NoError(t, err)
+		assert.Equal(t, storage.TransactionLocation{BlockHeight: 1, TransactionIndex: 0}, location)
+
+		events, err := store.EventsByHeight(2, "")
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		events, err = store.EventsByHeight(1, "")
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		eventsByHeight, err := store.EventsByHeightRange(string(events[0].Type), 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, map[uint64][]flowgo.Event{1: events}, eventsByHeight)
+	})
+
+	t.Run("should remove ledger changes above height", func(t *testing.T) {
+		val, err := store.LedgerViewByHeight(2).Get(owner, controller, key)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("bar"), val)
+	})
+}
+
+func TestRollbackToHeightInBatches(t *testing.T) {
+
+	t.Parallel()
+
+	store, dir := setupStore(t)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	const owner = ""
+	const controller = ""
+	const key = "foo"
+
+	d := delta.NewDelta()
+	d.Set(owner, controller, key, []byte("bar"))
+
+	err := store.CommitBlock(
+		flowgo.Block{Header: &flowgo.Header{Height: 0}},
+		nil,
+		nil,
+		nil,
+		d,
+		nil,
+	)
+	require.NoError(t, err)
+
+	// more blocks than are removed in one batch
+	blocks := make([]flowgo.Block, 2500)
+	for i := range blocks {
+		blocks[i] = flowgo.Block{
+			Header: &flowgo.Header{
+				Height: uint64(i + 1),
+			},
+		}
+	}
+
+	err = store.CommitEmptyBlocks(blocks)
+	require.NoError(t, err)
+
+	d = delta.NewDelta()
+	d.Set(owner, controller, key, []byte("baz"))
+
+	err = store.CommitBlock(
+		flowgo.Block{Header: &flowgo.Header{Height: 2501}},
+		nil,
+		nil,
+		nil,
+		d,
+		nil,
+	)
+	require.NoError(t, err)
+
+	err = store.RollbackToHeight(0)
+	require.NoError(t, err)
+
+	latestBlock, err := store.LatestBlock()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), latestBlock.Header.Height)
+
+	for _, height := range []uint64{1, 1000, 1001, 2500, 2501} {
+		_, err := store.BlockByHeight(height)
+		assert.Equal(t, storage.ErrNotFound, err)
+	}
+
+	_, err = store.BlockByID(blocks[0].ID())
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	val, err := store.LedgerViewByHeight(2501).Get(owner, controller, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bar"), val)
+}
+
+func TestCommitEmptyBlocks(t *testing.T) {
+
+	t.Parallel()
+
+	store, dir := setupStore(t)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	const owner = ""
+	const controller = ""
+	const key = "foo"
+
+	genesis := flowgo.Block{
+		Header: &flowgo.Header{
+			Height: 0,
+		},
+	}
+
+	d := delta.NewDelta()
+	d.Set(owner, controller, key, []byte("bar"))
+
+	err := store.CommitBlock(
+		genesis,
+		nil,
+		nil,
+		nil,
+		d,
+		nil,
+	)
+	require.NoError(t, err)
+
+	// more blocks than fit in one batch
+	blocks := make([]flowgo.Block, 2500)
+	for i := range blocks {
+		blocks[i] = flowgo.Block{
+			Header: &flowgo.Header{
+				Height: uint64(i + 1),
+			},
+		}
+	}
+
+	err = store.CommitEmptyBlocks(blocks)
+	require.NoError(t, err)
+
+	t.Run("should store blocks", func(t *testing.T) {
+		latestBlock, err := store.LatestBlock()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2500), latestBlock.Header.Height)
+
+		for _, height := range []uint64{1, 1000, 1001, 2500} {
+			block, err := store.BlockByHeight(height)
+			require.NoError(t, err)
+			assert.Equal(t, blocks[height-1].ID(), block.ID())
+
+			block, err = store.BlockByID(blocks[height-1].ID())
+			require.NoError(t, err)
+			assert.Equal(t, height, block.Header.Height)
+		}
+	})
+
+	t.Run("should keep ledger state", func(t *testing.T) {
+		val, err := store.LedgerViewByHeight(2500).Get(owner, controller, key)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("bar"), val)
+	})
+}
+
+func TestRegistersByOwner(t *testing.T) {
+
+	t.Parallel()
+
+	store, dir := setupStore(t)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	owner := flowgo.HexToAddress("01")
+	otherOwner := flowgo.HexToAddress("02")
+
+	d1 := delta.NewDelta()
+	d1.Set(string(owner.Bytes()), "", "b", []byte{1})
+	d1.Set(string(owner.Bytes()), "", "a", []byte{2})
+	d1.Set(string(otherOwner.Bytes()), "", "c", []byte{3})
+
+	err := store.InsertLedgerDelta(1, d1)
+	require.NoError(t, err)
+
+	d2 := delta.NewDelta()
+	d2.Set(string(owner.Bytes()), "", "b", nil)
+	d2.Set(string(owner.Bytes()), "", "a", []byte{4})
+
+	err = store.InsertLedgerDelta(2, d2)
+	require.NoError(t, err)
+
+	registerEntry := func(key string, value []byte) flowgo.RegisterEntry {
+		return flowgo.RegisterEntry{
+			Key: flowgo.RegisterID{
+				Owner: string(owner.Bytes()),
+				Key:   key,
+			},
+			Value: value,
+		}
+	}
+
+	t.Run("should return sorted registers of owner", func(t *testing.T) {
+		registers, err := store.RegistersByOwner(owner, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []flowgo.RegisterEntry{
+			registerEntry("a", []byte{2}),
+			registerEntry("b", []byte{1}),
+		}, registers)
+	})
+
+	t.Run("should omit deleted registers", func(t *testing.T) {
+		registers, err := store.RegistersByOwner(owner, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []flowgo.RegisterEntry{
+			registerEntry("a", []byte{4}),
+		}, registers)
+	})
+
+	t.Run("should return no registers before first change", func(t *testing.T) {
+		registers, err := store.RegistersByOwner(owner, 0)
+		require.NoError(t, err)
+		assert.Empty(t, registers)
+	})
+}
+
+func TestRegistersChangedBetween(t *testing.T) {
+
+	t.Parallel()
+
+	store, dir := setupStore(t)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	registerID := func(key string) flowgo.RegisterID {
+		return flowgo.RegisterID{Key: key}
+	}
+
+	d1 := delta.NewDelta()
+	d1.Set("", "", "a", []byte{1})
+	d1.Set("", "", "b", []byte{1})
+
+	d2 := delta.NewDelta()
+	d2.Set("", "", "b", nil)
+	d2.Set("", "", "c", []byte{2})
+
+	d3 := delta.NewDelta()
+	d3.Set("", "", "d", []byte{3})
+
+	for i, d := range []delta.Delta{d1, d2, d3} {
+		err := store.InsertLedgerDelta(uint64(i+1), d)
+		require.NoError(t, err)
+	}
+
+	ids, err := store.RegistersChangedBetween(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []flowgo.RegisterID{registerID("b"), registerID("c")}, ids)
+
+	ids, err = store.RegistersChangedBetween(0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []flowgo.RegisterID{registerID("a"), registerID("b"), registerID("c"), registerID("d")}, ids)
+
+	ids, err = store.RegistersChangedBetween(3, 3)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestTransactionsByAccount(t *testing.T) {
+
+	t.Parallel()
+
+	store, dir := setupStore(t)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	alice := flowgo.HexToAddress("01")
+	bob := flowgo.HexToAddress("02")
+	contract := flowgo.HexToAddress("03")
+
+	newTransaction := func(script string, proposer, payer flowgo.Address, authorizers ...flowgo.Address) flowgo.TransactionBody {
+		return flowgo.TransactionBody{
+			Script:      []byte(script),
+			ProposalKey: flowgo.ProposalKey{Address: proposer},
+			Payer:       payer,
+			Authorizers: authorizers,
+		}
+	}
+
+	commitBlock := func(height uint64, txs []flowgo.TransactionBody, events [][]flowgo.Event) {
+		col := flowgo.LightCollection{}
+		transactions := make(map[flowgo.Identifier]*flowgo.TransactionBody)
+		results := make(map[flowgo.Identifier]*types.StorableTransactionResult)
+
+		for i := range txs {
+			tx := txs[i]
+			col.Transactions = append(col.Transactions, tx.ID())
+			transactions[tx.ID()] = &tx
+			results[tx.ID()] = &types.StorableTransactionResult{Events: events[i]}
+		}
+
+		block := flowgo.Block{
+			Header: &flowgo.Header{
+				Height: height,
+			},
+			Payload: &flowgo.Payload{
+				Guarantees: []*flowgo.CollectionGuarantee{{CollectionID: col.ID()}},
+			},
+		}
+
+		err := store.CommitBlock(
+			block,
+			[]*flowgo.LightCollection{&col},
+			transactions,
+			results,
+			delta.NewDelta(),
+			nil,
+		)
+		require.NoError(t, err)
+	}
+
+	tx1 := newTransaction("1", alice, alice, bob)
+	tx2 := newTransaction("2", bob, bob, bob)
+	tx3 := newTransaction("3", alice, bob, alice)
+
+	commitBlock(1,
+		[]flowgo.TransactionBody{tx1},
+		[][]flowgo.Event{{
+			{Type: flowgo.EventType("A." + contract.Hex() + ".Test.Foo")},
+			{Type: flowgo.EventAccountCreated},
+		}},
+	)
+	commitBlock(2,
+		[]flowgo.TransactionBody{tx2, tx3},
+		[][]flowgo.Event{nil, nil},
+	)
+
+	tx1Alice := storage.AccountTransaction{
+		TransactionID:    tx1.ID(),
+		BlockHeight:      1,
+		TransactionIndex: 0,
+		Roles:            []storage.AccountRole{storage.AccountRoleProposer, storage.AccountRolePayer},
+	}
+	tx3Alice := storage.AccountTransaction{
+		TransactionID:    tx3.ID(),
+		BlockHeight:      2,
+		TransactionIndex: 1,
+		Roles:            []storage.AccountRole{storage.AccountRoleProposer, storage.AccountRoleAuthorizer},
+	}
+
+	t.Run("should return transactions newest first", func(t *testing.T) {
+		txs, err := store.TransactionsByAccount(alice, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []storage.AccountTransaction{tx3Alice, tx1Alice}, txs)
+	})
+
+	t.Run("should page transactions with cursor", func(t *testing.T) {
+		txs, err := store.TransactionsByAccount(alice, nil, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []storage.AccountTransaction{tx3Alice}, txs)
+
+		cursor := txs[0].Cursor()
+
+		txs, err = store.TransactionsByAccount(alice, &cursor, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []storage.AccountTransaction{tx1Alice}, txs)
+
+		cursor = txs[0].Cursor()
+
+		txs, err = store.TransactionsByAccount(alice, &cursor, 1)
+		require.NoError(t, err)
+		assert.Empty(t, txs)
+	})
+
+	t.Run("should index authorizers and event-emitting contracts", func(t *testing.T) {
+		txs, err := store.TransactionsByAccount(bob, nil, 0)
+		require.NoError(t, err)
+		require.Len(t, txs, 3)
+		assert.Equal(t, []storage.AccountRole{storage.AccountRolePayer}, txs[0].Roles)
+		assert.Equal(t, tx1.ID(), txs[2].TransactionID)
+		assert.Equal(t, []storage.AccountRole{storage.AccountRoleAuthorizer}, txs[2].Roles)
+
+		txs, err = store.TransactionsByAccount(contract, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []storage.AccountTransaction{{
+			TransactionID:    tx1.ID(),
+			BlockHeight:      1,
+			TransactionIndex: 0,
+			Roles:            []storage.AccountRole{storage.AccountRoleEmitter},
+		}}, txs)
+	})
+
+	t.Run("should remove transactions above rollback height", func(t *testing.T) {
+		err := store.RollbackToHeight(1)
+		require.NoError(t, err)
+
+		txs, err := store.TransactionsByAccount(alice, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []storage.AccountTransaction{tx1Alice}, txs)
+	})
+}
+
+func TestSnapshots(t *testing.T) {
+
+	t.Parallel()
+
+	store, dir := setupStore(t)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	const owner = ""
+	const controller = ""
+	const key = "foo"
+
+	d := delta.NewDelta()
+	d.Set(owner, controller, key, []byte("bar"))
+
+	err := store.InsertLedgerDelta(1, d)
+	require.NoError(t, err)
+
+	err = store.CreateSnapshot("first")
+	require.NoError(t, err)
+
+	err = store.CreateSnapshot("first")
+	assert.Equal(t, storage.ErrAlreadyExists, err)
+
+	d = delta.NewDelta()
+	d.Set(owner, controller, key, []byte("baz"))
+
+	err = store.InsertLedgerDelta(2, d)
+	require.NoError(t, err)
+
+	names, err := store.ListSnapshots()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, names)
+
+	t.Run("should revert ledger changes made after the snapshot", func(t *testing.T) {
+		err := store.LoadSnapshot("first")
+		require.NoError(t, err)
+
+		// overwrite block 2 without touching the register
+		err = store.InsertLedgerDelta(2, delta.NewDelta())
+		require.NoError(t, err)
+
+		val, err := store.LedgerViewByHeight(2).Get(owner, controller, key)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("bar"), val)
+	})
+
+	t.Run("should delete snapshot", func(t *testing.T) {
+		err := store.DeleteSnapshot("first")
+		require.NoError(t, err)
+
+		err = store.LoadSnapshot("first")
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		names, err := store.ListSnapshots()
+		require.NoError(t, err)
+		assert.Empty(t, names)
+	})
+}
@@ -22,3 +22,6 @@ import "errors"
 
 // ErrNotFound is an error returned when an entity cannot be found.
 var ErrNotFound = errors.New("could not find entity")
+
+// ErrAlreadyExists is an error returned when an entity to be created already exists.
+var ErrAlreadyExists = errors.New("entity already exists")
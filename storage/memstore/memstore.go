@@ -19,7 +19,10 @@
 package memstore
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/onflow/flow-go/engine/execution/state/delta"
@@ -43,29 +46,42 @@ type Store struct {
 	transactions map[flowgo.Identifier]flowgo.TransactionBody
 	// Transaction results by ID
 	transactionResults map[flowgo.Identifier]types.StorableTransactionResult
+	// block heights and indices of transactions by ID
+	transactionLocations map[flowgo.Identifier]storage.TransactionLocation
 	// Ledger states by block height
 	ledger map[uint64]*utils.MapLedger
 	// events by block height
 	eventsByBlockHeight map[uint64][]flowgo.Event
+	// heights of the blocks with events of a type by event type, in ascending order
+	eventHeightsByType map[flowgo.EventType][]uint64
+	// transactions involving an account by address, in commit order
+	transactionsByAccount map[flowgo.Address][]storage.AccountTransaction
 	// highest block height
 	blockHeight uint64
+	// named snapshots of the store state
+	snapshots map[string]*Store
 }
 
 // New returns a new in-memory Store implementation.
 func New() *Store {
 	return &Store{
-		mu:                  sync.RWMutex{},
-		blockIDToHeight:     make(map[flowgo.Identifier]uint64),
-		blocks:              make(map[uint64]flowgo.Block),
-		collections:         make(map[flowgo.Identifier]flowgo.LightCollection),
-		transactions:        make(map[flowgo.Identifier]flowgo.TransactionBody),
-		transactionResults:  make(map[flowgo.Identifier]types.StorableTransactionResult),
-		ledger:              make(map[uint64]*utils.MapLedger),
-		eventsByBlockHeight: make(map[uint64][]flowgo.Event),
+		mu:                    sync.RWMutex{},
+		blockIDToHeight:       make(map[flowgo.Identifier]uint64),
+		blocks:                make(map[uint64]flowgo.Block),
+		collections:           make(map[flowgo.Identifier]flowgo.LightCollection),
+		transactions:          make(map[flowgo.Identifier]flowgo.TransactionBody),
+		transactionResults:    make(map[flowgo.Identifier]types.StorableTransactionResult),
+		transactionLocations:  make(map[flowgo.Identifier]storage.TransactionLocation),
+		ledger:                make(map[uint64]*utils.MapLedger),
+		eventsByBlockHeight:   make(map[uint64][]flowgo.Event),
+		eventHeightsByType:    make(map[flowgo.EventType][]uint64),
+		transactionsByAccount: make(map[flowgo.Address][]storage.AccountTransaction),
+		snapshots:             make(map[string]*Store),
 	}
 }
 
 var _ storage.Store = &Store{}
+var _ storage.RegisterChangelog = &Store{}
 
 func (s *Store) BlockByID(id flowgo.Identifier) (*flowgo.Block, error) {
 	s.mu.RLock()
@@ -170,6 +186,10 @@ func (s *Store) CommitBlock(
 		}
 	}
 
+	for txID, location := range storage.TransactionLocationsInBlock(block.Header.Height, collections) {
+		s.transactionLocations[txID] = location
+	}
+
 	err = s.insertLedgerDelta(block.Header.Height, delta)
 	if err != nil {
 		return err
@@ -180,6 +200,40 @@ func (s *Store) CommitBlock(
 		return err
 	}
 
+	accountTransactions := storage.AccountTransactionsInBlock(
+		block.Header.Height,
+		collections,
+		transactions,
+		transactionResults,
+	)
+
+	for address, txs := range accountTransactions {
+		s.transactionsByAccount[address] = append(s.transactionsByAccount[address], txs...)
+	}
+
+	return nil
+}
+
+func (s *Store) CommitEmptyBlocks(blocks []flowgo.Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range blocks {
+		block := &blocks[i]
+
+		err := s.storeBlock(block)
+		if err != nil {
+			return err
+		}
+
+		// the ledger is unchanged, so share it with the parent block instead of copying it
+		if block.Header.Height > 0 {
+			if ledger, ok := s.ledger[block.Header.Height-1]; ok {
+				s.ledger[block.Header.Height] = ledger
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -231,6 +285,17 @@ func (s *Store) insertTransactionResult(txID flowgo.Identifier, result types.Sto
 	return nil
 }
 
+func (s *Store) TransactionLocationByID(txID flowgo.Identifier) (storage.TransactionLocation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	location, ok := s.transactionLocations[txID]
+	if !ok {
+		return storage.TransactionLocation{}, storage.ErrNotFound
+	}
+	return location, nil
+}
+
 func (s *Store) LedgerViewByHeight(blockHeight uint64) *delta.View {
 	return delta.NewView(func(owner, controller, key string) (value flowgo.RegisterValue, err error) {
 
@@ -247,6 +312,83 @@ func (s *Store) LedgerViewByHeight(blockHeight uint64) *delta.View {
 	})
 }
 
+func (s *Store) RegistersByOwner(owner flowgo.Address, blockHeight uint64) ([]flowgo.RegisterEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ledger, ok := s.ledger[blockHeight]
+	if !ok {
+		return nil, nil
+	}
+
+	ownerString := string(owner.Bytes())
+
+	registers := make([]flowgo.RegisterEntry, 0)
+	for _, register := range ledger.Registers {
+		if register.Key.Owner != ownerString || len(register.Value) == 0 {
+			continue
+		}
+
+		registers = append(registers, register)
+	}
+
+	storage.SortRegisters(registers)
+
+	return registers, nil
+}
+
+func (s *Store) RegisterChanged(id flowgo.RegisterID, blockHeight uint64) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ledger, ok := s.ledger[blockHeight]
+	if !ok {
+		return false, nil
+	}
+
+	// deleted registers are kept in the ledger with an empty value
+	_, ok = ledger.Registers[ledgerKey(id)]
+
+	return ok, nil
+}
+
+// ledgerKey returns the key of a register in a map ledger.
+func ledgerKey(id flowgo.RegisterID) string {
+	return strings.Join([]string{id.Owner, id.Controller, id.Key}, "\x1F")
+}
+
+func (s *Store) RegistersChangedBetween(fromHeight, toHeight uint64) ([]flowgo.RegisterID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fromLedger := s.ledger[fromHeight]
+	toLedger := s.ledger[toHeight]
+
+	if fromLedger == nil || toLedger == nil || fromLedger == toLedger {
+		return []flowgo.RegisterID{}, nil
+	}
+
+	// ledgers are snapshots, so compare the register values at both heights
+	ids := make([]flowgo.RegisterID, 0)
+
+	for keyString, toRegister := range toLedger.Registers {
+		fromRegister, ok := fromLedger.Registers[keyString]
+		if !ok || !bytes.Equal(fromRegister.Value, toRegister.Value) {
+			ids = append(ids, toRegister.Key)
+		}
+	}
+
+	for keyString, fromRegister := range fromLedger.Registers {
+		if _, ok := toLedger.Registers[keyString]; !ok {
+			ids = append(ids, fromRegister.Key)
+		}
+	}
+
+	storage.SortRegisterIDs(ids)
+
+	return ids, nil
+}
+
 func (s *Store) UnsafeInsertLedgerDelta(blockHeight uint64, delta delta.Delta) error {
 	return s.insertLedgerDelta(blockHeight, delta)
 }
@@ -308,6 +450,76 @@ func (s *Store) EventsByHeight(blockHeight uint64, eventType string) ([]flowgo.E
 	return events, nil
 }
 
+func (s *Store) EventsByHeightRange(
+	eventType string,
+	startHeight, endHeight uint64,
+) (map[uint64][]flowgo.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make(map[uint64][]flowgo.Event)
+
+	if eventType == "" {
+		for height, blockEvents := range s.eventsByBlockHeight {
+			if height >= startHeight && height <= endHeight && len(blockEvents) > 0 {
+				events[height] = append([]flowgo.Event{}, blockEvents...)
+			}
+		}
+
+		return events, nil
+	}
+
+	heights := s.eventHeightsByType[flowgo.EventType(eventType)]
+
+	start := sort.Search(len(heights), func(i int) bool {
+		return heights[i] >= startHeight
+	})
+
+	for _, height := range heights[start:] {
+		if height > endHeight {
+			break
+		}
+
+		for _, event := range s.eventsByBlockHeight[height] {
+			if string(event.Type) == eventType {
+				events[height] = append(events[height], event)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+func (s *Store) TransactionsByAccount(
+	address flowgo.Address,
+	cursor *storage.AccountTransactionCursor,
+	limit int,
+) ([]storage.AccountTransaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := s.transactionsByAccount[address]
+
+	txs := make([]storage.AccountTransaction, 0)
+
+	// transactions are stored in commit order, so walk backwards to return the newest first
+	for i := len(all) - 1; i >= 0; i-- {
+		if limit > 0 && len(txs) == limit {
+			break
+		}
+
+		tx := all[i]
+
+		if cursor != nil && !cursor.Before(tx.BlockHeight, tx.TransactionIndex) {
+			continue
+		}
+
+		txs = append(txs, tx)
+	}
+
+	return txs, nil
+}
+
 func (s *Store) insertEvents(blockHeight uint64, events []flowgo.Event) error {
 	if s.eventsByBlockHeight[blockHeight] == nil {
 		s.eventsByBlockHeight[blockHeight] = events
@@ -315,5 +527,246 @@ func (s *Store) insertEvents(blockHeight uint64, events []flowgo.Event) error {
 		s.eventsByBlockHeight[blockHeight] = append(s.eventsByBlockHeight[blockHeight], events...)
 	}
 
+	for _, event := range events {
+		heights := s.eventHeightsByType[event.Type]
+		if len(heights) == 0 || heights[len(heights)-1] != blockHeight {
+			s.eventHeightsByType[event.Type] = append(heights, blockHeight)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) RollbackToHeight(blockHeight uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if blockHeight > s.blockHeight {
+		return storage.ErrNotFound
+	}
+
+	for height := s.blockHeight; height > blockHeight; height-- {
+		block, ok := s.blocks[height]
+		if ok {
+			delete(s.blockIDToHeight, block.ID())
+
+			if block.Payload != nil {
+				for _, guarantee := range block.Payload.Guarantees {
+					for _, txID := range s.collections[guarantee.CollectionID].Transactions {
+						delete(s.transactions, txID)
+						delete(s.transactionResults, txID)
+						delete(s.transactionLocations, txID)
+					}
+
+					delete(s.collections, guarantee.CollectionID)
+				}
+			}
+		}
+
+		delete(s.blocks, height)
+		delete(s.ledger, height)
+		delete(s.eventsByBlockHeight, height)
+	}
+
+	for eventType, heights := range s.eventHeightsByType {
+		// heights are stored in ascending order, so drop those above the height from the end
+		n := len(heights)
+		for n > 0 && heights[n-1] > blockHeight {
+			n--
+		}
+
+		if n == 0 {
+			delete(s.eventHeightsByType, eventType)
+		} else {
+			s.eventHeightsByType[eventType] = heights[:n]
+		}
+	}
+
+	for address, txs := range s.transactionsByAccount {
+		// transactions are stored in commit order, so drop those above the height from the end
+		n := len(txs)
+		for n > 0 && txs[n-1].BlockHeight > blockHeight {
+			n--
+		}
+
+		if n == 0 {
+			delete(s.transactionsByAccount, address)
+		} else {
+			s.transactionsByAccount[address] = txs[:n]
+		}
+	}
+
+	s.blockHeight = blockHeight
+
 	return nil
 }
+
+// JumpToContext creates a snapshot of the current state with the given name if
+// it does not exist, otherwise it reverts the store to the existing snapshot.
+func (s *Store) JumpToContext(context string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.snapshots[context]; !ok {
+		return s.createSnapshot(context)
+	}
+
+	return s.loadSnapshot(context)
+}
+
+func (s *Store) CreateSnapshot(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createSnapshot(name)
+}
+
+func (s *Store) createSnapshot(name string) error {
+	if _, ok := s.snapshots[name]; ok {
+		return storage.ErrAlreadyExists
+	}
+
+	s.snapshots[name] = s.copyState()
+
+	return nil
+}
+
+func (s *Store) LoadSnapshot(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loadSnapshot(name)
+}
+
+func (s *Store) loadSnapshot(name string) error {
+	snapshot, ok := s.snapshots[name]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	// copy the snapshot again so that it can be reverted to more than once
+	state := snapshot.copyState()
+
+	s.blockIDToHeight = state.blockIDToHeight
+	s.blocks = state.blocks
+	s.collections = state.collections
+	s.transactions = state.transactions
+	s.transactionResults = state.transactionResults
+	s.transactionLocations = state.transactionLocations
+	s.ledger = state.ledger
+	s.eventsByBlockHeight = state.eventsByBlockHeight
+	s.eventHeightsByType = state.eventHeightsByType
+	s.transactionsByAccount = state.transactionsByAccount
+	s.blockHeight = state.blockHeight
+
+	return nil
+}
+
+func (s *Store) ListSnapshots() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.snapshots))
+	for name := range s.snapshots {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s *Store) DeleteSnapshot(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.snapshots[name]; !ok {
+		return storage.ErrNotFound
+	}
+
+	delete(s.snapshots, name)
+
+	return nil
+}
+
+// copyState returns a store holding a copy of the chain state, without any
+// snapshots. The caller must hold the lock.
+//
+// Ledgers are copied, as reading a ledger records the touched registers in it.
+// Ledgers shared by several block heights stay shared in the copy.
+func (s *Store) copyState() *Store {
+	state := New()
+
+	for id, height := range s.blockIDToHeight {
+		state.blockIDToHeight[id] = height
+	}
+
+	for height, block := range s.blocks {
+		state.blocks[height] = block
+	}
+
+	for id, col := range s.collections {
+		state.collections[id] = col
+	}
+
+	for id, tx := range s.transactions {
+		state.transactions[id] = tx
+	}
+
+	for id, result := range s.transactionResults {
+		state.transactionResults[id] = result
+	}
+
+	for id, location := range s.transactionLocations {
+		state.transactionLocations[id] = location
+	}
+
+	ledgerCopies := make(map[*utils.MapLedger]*utils.MapLedger)
+
+	for height, ledger := range s.ledger {
+		ledgerCopy, ok := ledgerCopies[ledger]
+		if !ok {
+			ledgerCopy = copyLedger(ledger)
+			ledgerCopies[ledger] = ledgerCopy
+		}
+
+		state.ledger[height] = ledgerCopy
+	}
+
+	for height, events := range s.eventsByBlockHeight {
+		state.eventsByBlockHeight[height] = append([]flowgo.Event{}, events...)
+	}
+
+	for eventType, heights := range s.eventHeightsByType {
+		state.eventHeightsByType[eventType] = append([]uint64{}, heights...)
+	}
+
+	for address, txs := range s.transactionsByAccount {
+		state.transactionsByAccount[address] = append([]storage.AccountTransaction{}, txs...)
+	}
+
+	state.blockHeight = s.blockHeight
+
+	return state
+}
+
+func copyLedger(ledger *utils.MapLedger) *utils.MapLedger {
+	ledgerCopy := utils.NewMapLedger()
+
+	for keyString, register := range ledger.Registers {
+		ledgerCopy.Registers[keyString] = flowgo.RegisterEntry{
+			Key:   register.Key,
+			Value: append(flowgo.RegisterValue{}, register.Value...),
+		}
+	}
+
+	for keyString, touched := range ledger.RegisterTouches {
+		ledgerCopy.RegisterTouches[keyString] = touched
+	}
+
+	for keyString, updated := range ledger.RegisterUpdated {
+		ledgerCopy.RegisterUpdated[keyString] = updated
+	}
+
+	return ledgerCopy
+}
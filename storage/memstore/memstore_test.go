@@ -26,6 +26,9 @@ import (
 	flowgo "github.com/onflow/flow-go/model/flow"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-emulator/storage"
+	"github.com/onflow/flow-emulator/types"
 )
 
 func TestMemstore(t *testing.T) {
@@ -120,3 +123,428 @@ func TestMemstoreSetValueToNil(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, string(nilValue.Value), string(register))
 }
+
+func TestMemstoreRegistersByOwner(t *testing.T) {
+
+	t.Parallel()
+
+	store := New()
+
+	owner := flowgo.HexToAddress("01")
+	otherOwner := flowgo.HexToAddress("02")
+
+	registerEntry := func(owner flowgo.Address, key string, value []byte) flowgo.RegisterEntry {
+		return flowgo.RegisterEntry{
+			Key: flowgo.RegisterID{
+				Owner: string(owner.Bytes()),
+				Key:   key,
+			},
+			Value: value,
+		}
+	}
+
+	entries := []flowgo.RegisterEntry{
+		registerEntry(owner, "b", []byte{1}),
+		registerEntry(owner, "a", []byte{2}),
+		registerEntry(otherOwner, "c", []byte{3}),
+	}
+
+	d := delta.Delta{Data: make(map[string]flowgo.RegisterEntry)}
+	for _, entry := range entries {
+		d.Data[entry.Key.String()] = entry
+	}
+
+	err := store.insertLedgerDelta(0, d)
+	require.NoError(t, err)
+
+	registers, err := store.RegistersByOwner(owner, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []flowgo.RegisterEntry{entries[1], entries[0]}, registers)
+}
+
+func TestMemstoreRegistersChangedBetween(t *testing.T) {
+
+	t.Parallel()
+
+	store := New()
+
+	registerEntry := func(key string, value []byte) flowgo.RegisterEntry {
+		return flowgo.RegisterEntry{
+			Key:   flowgo.RegisterID{Key: key},
+			Value: value,
+		}
+	}
+
+	deltas := [][]flowgo.RegisterEntry{
+		{registerEntry("a", []byte{1}), registerEntry("b", []byte{1})},
+		{registerEntry("b", nil), registerEntry("c", []byte{2})},
+		{registerEntry("a", []byte{1})},
+	}
+
+	for height, entries := range deltas {
+		d := delta.Delta{Data: make(map[string]flowgo.RegisterEntry)}
+		for _, entry := range entries {
+			d.Data[entry.Key.String()] = entry
+		}
+
+		err := store.insertLedgerDelta(uint64(height), d)
+		require.NoError(t, err)
+	}
+
+	ids, err := store.RegistersChangedBetween(0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []flowgo.RegisterID{{Key: "b"}, {Key: "c"}}, ids)
+
+	// rewriting a register with the same value is not a change
+	ids, err = store.RegistersChangedBetween(1, 2)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestMemstoreJumpToContext(t *testing.T) {
+
+	t.Parallel()
+
+	store := New()
+	key := flowgo.RegisterID{
+		Owner:      "",
+		Controller: "",
+		Key:        "foo",
+	}
+
+	commit := func(height uint64, value string) {
+		block := flowgo.Block{
+			Header: &flowgo.Header{
+				Height: height,
+			},
+		}
+
+		err := store.CommitBlock(
+			block,
+			nil,
+			nil,
+			nil,
+			delta.Delta{
+				Data: map[string]flowgo.RegisterEntry{
+					key.String(): {
+						Key:   key,
+						Value: []byte(value),
+					},
+				},
+			},
+			nil,
+		)
+		require.NoError(t, err)
+	}
+
+	commit(0, "bar")
+
+	// create the snapshot at height 0
+	err := store.JumpToContext("initial")
+	require.NoError(t, err)
+
+	commit(1, "baz")
+
+	latestBlock, err := store.LatestBlock()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), latestBlock.Header.Height)
+
+	// revert to the snapshot
+	err = store.JumpToContext("initial")
+	require.NoError(t, err)
+
+	latestBlock, err = store.LatestBlock()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), latestBlock.Header.Height)
+
+	_, err = store.BlockByHeight(1)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	register, err := store.LedgerViewByHeight(0).Get(key.Owner, key.Controller, key.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(register))
+
+	// changes after reverting must not affect the snapshot
+	commit(1, "qux")
+
+	err = store.JumpToContext("initial")
+	require.NoError(t, err)
+
+	_, err = store.BlockByHeight(1)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestMemstoreSnapshots(t *testing.T) {
+
+	t.Parallel()
+
+	store := New()
+
+	err := store.CreateSnapshot("b")
+	require.NoError(t, err)
+
+	err = store.CreateSnapshot("a")
+	require.NoError(t, err)
+
+	err = store.CreateSnapshot("a")
+	assert.Equal(t, storage.ErrAlreadyExists, err)
+
+	names, err := store.ListSnapshots()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	err = store.DeleteSnapshot("a")
+	require.NoError(t, err)
+
+	err = store.DeleteSnapshot("a")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	err = store.LoadSnapshot("a")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	err = store.LoadSnapshot("b")
+	require.NoError(t, err)
+}
+
+func TestMemstoreSnapshotLedgers(t *testing.T) {
+
+	t.Parallel()
+
+	const owner = "owner"
+	const controller = ""
+	const key = "key"
+
+	store := New()
+
+	d := delta.NewDelta()
+	d.Set(owner, controller, key, []byte("snapshot"))
+
+	err := store.CommitBlock(
+		flowgo.Block{Header: &flowgo.Header{Height: 0}},
+		nil,
+		nil,
+		nil,
+		d,
+		nil,
+	)
+	require.NoError(t, err)
+
+	err = store.CreateSnapshot("snapshot")
+	require.NoError(t, err)
+
+	// write to the ledger of the live state in place
+	err = store.ledger[0].Set(owner, controller, key, []byte("live"))
+	require.NoError(t, err)
+
+	err = store.LoadSnapshot("snapshot")
+	require.NoError(t, err)
+
+	value, err := store.LedgerViewByHeight(0).Get(owner, controller, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("snapshot"), value)
+}
+
+func TestMemstoreTransactionsByAccount(t *testing.T) {
+
+	t.Parallel()
+
+	store := New()
+
+	err := store.CommitBlock(
+		flowgo.Block{Header: &flowgo.Header{Height: 0}},
+		nil,
+		nil,
+		nil,
+		delta.NewDelta(),
+		nil,
+	)
+	require.NoError(t, err)
+
+	alice := flowgo.HexToAddress("01")
+	bob := flowgo.HexToAddress("02")
+
+	commit := func(height uint64, tx flowgo.TransactionBody) storage.AccountTransaction {
+		col := flowgo.LightCollection{Transactions: []flowgo.Identifier{tx.ID()}}
+
+		block := flowgo.Block{
+			Header: &flowgo.Header{
+				Height: height,
+			},
+			Payload: &flowgo.Payload{
+				Guarantees: []*flowgo.CollectionGuarantee{{CollectionID: col.ID()}},
+			},
+		}
+
+		err := store.CommitBlock(
+			block,
+			[]*flowgo.LightCollection{&col},
+			map[flowgo.Identifier]*flowgo.TransactionBody{tx.ID(): &tx},
+			map[flowgo.Identifier]*types.StorableTransactionResult{tx.ID(): {}},
+			delta.NewDelta(),
+			nil,
+		)
+		require.NoError(t, err)
+
+		return storage.AccountTransaction{
+			TransactionID: tx.ID(),
+			BlockHeight:   height,
+			Roles:         []storage.AccountRole{storage.AccountRoleProposer, storage.AccountRolePayer},
+		}
+	}
+
+	tx1 := commit(1, flowgo.TransactionBody{
+		Script:      []byte("1"),
+		ProposalKey: flowgo.ProposalKey{Address: alice},
+		Payer:       alice,
+		Authorizers: []flowgo.Address{bob},
+	})
+	tx2 := commit(2, flowgo.TransactionBody{
+		Script:      []byte("2"),
+		ProposalKey: flowgo.ProposalKey{Address: alice},
+		Payer:       alice,
+	})
+
+	err = store.JumpToContext("initial")
+	require.NoError(t, err)
+
+	txs, err := store.TransactionsByAccount(alice, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.AccountTransaction{tx2, tx1}, txs)
+
+	cursor := tx2.Cursor()
+	txs, err = store.TransactionsByAccount(alice, &cursor, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.AccountTransaction{tx1}, txs)
+
+	txs, err = store.TransactionsByAccount(bob, nil, 0)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, []storage.AccountRole{storage.AccountRoleAuthorizer}, txs[0].Roles)
+
+	err = store.RollbackToHeight(1)
+	require.NoError(t, err)
+
+	txs, err = store.TransactionsByAccount(alice, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.AccountTransaction{tx1}, txs)
+
+	// reverting to the snapshot restores the rolled back transactions
+	err = store.JumpToContext("initial")
+	require.NoError(t, err)
+
+	txs, err = store.TransactionsByAccount(alice, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.AccountTransaction{tx2, tx1}, txs)
+}
+
+func TestMemstoreTransactionLocations(t *testing.T) {
+
+	t.Parallel()
+
+	store := New()
+
+	err := store.CommitBlock(
+		flowgo.Block{Header: &flowgo.Header{Height: 0}},
+		nil,
+		nil,
+		nil,
+		delta.NewDelta(),
+		nil,
+	)
+	require.NoError(t, err)
+
+	tx1 := flowgo.TransactionBody{Script: []byte("1")}
+	tx2 := flowgo.TransactionBody{Script: []byte("2")}
+	tx3 := flowgo.TransactionBody{Script: []byte("3")}
+
+	col1 := flowgo.LightCollection{Transactions: []flowgo.Identifier{tx1.ID(), tx2.ID()}}
+	col2 := flowgo.LightCollection{Transactions: []flowgo.Identifier{tx3.ID()}}
+
+	block := flowgo.Block{
+		Header: &flowgo.Header{
+			Height: 1,
+		},
+		Payload: &flowgo.Payload{
+			Guarantees: []*flowgo.CollectionGuarantee{
+				{CollectionID: col1.ID()},
+				{CollectionID: col2.ID()},
+			},
+		},
+	}
+
+	err = store.CommitBlock(
+		block,
+		[]*flowgo.LightCollection{&col1, &col2},
+		map[flowgo.Identifier]*flowgo.TransactionBody{tx1.ID(): &tx1, tx2.ID(): &tx2, tx3.ID(): &tx3},
+		map[flowgo.Identifier]*types.StorableTransactionResult{tx1.ID(): {}, tx2.ID(): {}, tx3.ID(): {}},
+		delta.NewDelta(),
+		nil,
+	)
+	require.NoError(t, err)
+
+	// transactions are indexed across collections
+	location, err := store.TransactionLocationByID(tx3.ID())
+	require.NoError(t, err)
+	assert.Equal(t, storage.TransactionLocation{BlockHeight: 1, TransactionIndex: 2}, location)
+
+	err = store.RollbackToHeight(0)
+	require.NoError(t, err)
+
+	_, err = store.TransactionLocationByID(tx3.ID())
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestMemstoreEventsByHeightRange(t *testing.T) {
+
+	t.Parallel()
+
+	store := New()
+
+	eventsByHeight := map[uint64][]flowgo.Event{
+		1: {{Type: "A.1.C.E"}, {Type: "A.1.C.F", EventIndex: 1}},
+		2: {{Type: "A.1.C.F"}},
+		3: {{Type: "A.1.C.E"}},
+	}
+
+	// the ledger of each block is derived from the previous block, starting at genesis
+	for height := uint64(0); height <= 3; height++ {
+		err := store.CommitBlock(
+			flowgo.Block{Header: &flowgo.Header{Height: height}},
+			nil,
+			nil,
+			nil,
+			delta.NewDelta(),
+			eventsByHeight[height],
+		)
+		require.NoError(t, err)
+	}
+
+	events, err := store.EventsByHeightRange("A.1.C.E", 1, 3)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[uint64][]flowgo.Event{
+			1: eventsByHeight[1][:1],
+			3: eventsByHeight[3],
+		},
+		events,
+	)
+
+	events, err = store.EventsByHeightRange("", 2, 10)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[uint64][]flowgo.Event{
+			2: eventsByHeight[2],
+			3: eventsByHeight[3],
+		},
+		events,
+	)
+
+	err = store.RollbackToHeight(2)
+	require.NoError(t, err)
+
+	events, err = store.EventsByHeightRange("A.1.C.E", 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64][]flowgo.Event{1: eventsByHeight[1][:1]}, events)
+}
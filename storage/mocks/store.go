@@ -6,6 +6,7 @@ package mocks
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	storage "github.com/onflow/flow-emulator/storage"
 	types "github.com/onflow/flow-emulator/types"
 	delta "github.com/onflow/flow-go/engine/execution/state/delta"
 	flow "github.com/onflow/flow-go/model/flow"
@@ -94,6 +95,48 @@ func (mr *MockStoreMockRecorder) CommitBlock(arg0, arg1, arg2, arg3, arg4, arg5
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitBlock", reflect.TypeOf((*MockStore)(nil).CommitBlock), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// CommitEmptyBlocks mocks base method
+func (m *MockStore) CommitEmptyBlocks(arg0 []flow.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitEmptyBlocks", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitEmptyBlocks indicates an expected call of CommitEmptyBlocks
+func (mr *MockStoreMockRecorder) CommitEmptyBlocks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitEmptyBlocks", reflect.TypeOf((*MockStore)(nil).CommitEmptyBlocks), arg0)
+}
+
+// CreateSnapshot mocks base method
+func (m *MockStore) CreateSnapshot(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSnapshot", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSnapshot indicates an expected call of CreateSnapshot
+func (mr *MockStoreMockRecorder) CreateSnapshot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSnapshot", reflect.TypeOf((*MockStore)(nil).CreateSnapshot), arg0)
+}
+
+// DeleteSnapshot mocks base method
+func (m *MockStore) DeleteSnapshot(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSnapshot", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSnapshot indicates an expected call of DeleteSnapshot
+func (mr *MockStoreMockRecorder) DeleteSnapshot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshot", reflect.TypeOf((*MockStore)(nil).DeleteSnapshot), arg0)
+}
+
 // EventsByHeight mocks base method
 func (m *MockStore) EventsByHeight(arg0 uint64, arg1 string) ([]flow.Event, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +152,35 @@ func (mr *MockStoreMockRecorder) EventsByHeight(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventsByHeight", reflect.TypeOf((*MockStore)(nil).EventsByHeight), arg0, arg1)
 }
 
+// EventsByHeightRange mocks base method
+func (m *MockStore) EventsByHeightRange(arg0 string, arg1 uint64, arg2 uint64) (map[uint64][]flow.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EventsByHeightRange", arg0, arg1, arg2)
+	ret0, _ := ret[0].(map[uint64][]flow.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EventsByHeightRange indicates an expected call of EventsByHeightRange
+func (mr *MockStoreMockRecorder) EventsByHeightRange(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventsByHeightRange", reflect.TypeOf((*MockStore)(nil).EventsByHeightRange), arg0, arg1, arg2)
+}
+
+// JumpToContext mocks base method
+func (m *MockStore) JumpToContext(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JumpToContext", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// JumpToContext indicates an expected call of JumpToContext
+func (mr *MockStoreMockRecorder) JumpToContext(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JumpToContext", reflect.TypeOf((*MockStore)(nil).JumpToContext), arg0)
+}
+
 // LatestBlock mocks base method
 func (m *MockStore) LatestBlock() (flow.Block, error) {
 	m.ctrl.T.Helper()
@@ -138,6 +210,79 @@ func (mr *MockStoreMockRecorder) LedgerViewByHeight(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LedgerViewByHeight", reflect.TypeOf((*MockStore)(nil).LedgerViewByHeight), arg0)
 }
 
+// ListSnapshots mocks base method
+func (m *MockStore) ListSnapshots() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSnapshots")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSnapshots indicates an expected call of ListSnapshots
+func (mr *MockStoreMockRecorder) ListSnapshots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshots", reflect.TypeOf((*MockStore)(nil).ListSnapshots))
+}
+
+// LoadSnapshot mocks base method
+func (m *MockStore) LoadSnapshot(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadSnapshot", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadSnapshot indicates an expected call of LoadSnapshot
+func (mr *MockStoreMockRecorder) LoadSnapshot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadSnapshot", reflect.TypeOf((*MockStore)(nil).LoadSnapshot), arg0)
+}
+
+// RegistersByOwner mocks base method
+func (m *MockStore) RegistersByOwner(arg0 flow.Address, arg1 uint64) ([]flow.RegisterEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegistersByOwner", arg0, arg1)
+	ret0, _ := ret[0].([]flow.RegisterEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegistersByOwner indicates an expected call of RegistersByOwner
+func (mr *MockStoreMockRecorder) RegistersByOwner(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegistersByOwner", reflect.TypeOf((*MockStore)(nil).RegistersByOwner), arg0, arg1)
+}
+
+// RegistersChangedBetween mocks base method
+func (m *MockStore) RegistersChangedBetween(arg0 uint64, arg1 uint64) ([]flow.RegisterID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegistersChangedBetween", arg0, arg1)
+	ret0, _ := ret[0].([]flow.RegisterID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegistersChangedBetween indicates an expected call of RegistersChangedBetween
+func (mr *MockStoreMockRecorder) RegistersChangedBetween(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegistersChangedBetween", reflect.TypeOf((*MockStore)(nil).RegistersChangedBetween), arg0, arg1)
+}
+
+// RollbackToHeight mocks base method
+func (m *MockStore) RollbackToHeight(arg0 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackToHeight", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RollbackToHeight indicates an expected call of RollbackToHeight
+func (mr *MockStoreMockRecorder) RollbackToHeight(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackToHeight", reflect.TypeOf((*MockStore)(nil).RollbackToHeight), arg0)
+}
+
 // StoreBlock mocks base method
 func (m *MockStore) StoreBlock(arg0 *flow.Block) error {
 	m.ctrl.T.Helper()
@@ -167,6 +312,21 @@ func (mr *MockStoreMockRecorder) TransactionByID(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionByID", reflect.TypeOf((*MockStore)(nil).TransactionByID), arg0)
 }
 
+// TransactionLocationByID mocks base method
+func (m *MockStore) TransactionLocationByID(arg0 flow.Identifier) (error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransactionLocationByID", arg0)
+	ret0, _ := ret[0].(error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransactionLocationByID indicates an expected call of TransactionLocationByID
+func (mr *MockStoreMockRecorder) TransactionLocationByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionLocationByID", reflect.TypeOf((*MockStore)(nil).TransactionLocationByID), arg0)
+}
+
 // TransactionResultByID mocks base method
 func (m *MockStore) TransactionResultByID(arg0 flow.Identifier) (types.StorableTransactionResult, error) {
 	m.ctrl.T.Helper()
@@ -181,3 +341,18 @@ func (mr *MockStoreMockRecorder) TransactionResultByID(arg0 interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionResultByID", reflect.TypeOf((*MockStore)(nil).TransactionResultByID), arg0)
 }
+
+// TransactionsByAccount mocks base method
+func (m *MockStore) TransactionsByAccount(arg0 flow.Address, arg1 *storage.AccountTransactionCursor, arg2 int) ([]storage.AccountTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransactionsByAccount", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]storage.AccountTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransactionsByAccount indicates an expected call of TransactionsByAccount
+func (mr *MockStoreMockRecorder) TransactionsByAccount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionsByAccount", reflect.TypeOf((*MockStore)(nil).TransactionsByAccount), arg0, arg1, arg2)
+}
@@ -0,0 +1,51 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package storage
+
+import (
+	"sort"
+
+	flowgo "github.com/onflow/flow-go/model/flow"
+)
+
+// SortRegisters sorts registers by owner, controller and key, the order in which
+// implementations of Store return them.
+func SortRegisters(registers []flowgo.RegisterEntry) {
+	sort.Slice(registers, func(i, j int) bool {
+		return registerIDLess(registers[i].Key, registers[j].Key)
+	})
+}
+
+// SortRegisterIDs sorts register IDs by owner, controller and key.
+func SortRegisterIDs(ids []flowgo.RegisterID) {
+	sort.Slice(ids, func(i, j int) bool {
+		return registerIDLess(ids[i], ids[j])
+	})
+}
+
+func registerIDLess(a, b flowgo.RegisterID) bool {
+	if a.Owner != b.Owner {
+		return a.Owner < b.Owner
+	}
+
+	if a.Controller != b.Controller {
+		return a.Controller < b.Controller
+	}
+
+	return a.Key < b.Key
+}
@@ -0,0 +1,332 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	"github.com/onflow/flow-go/fvm/state"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow/protobuf/go/flow/access"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// keyAddressState is the key of the global register holding the state of the address generator.
+const keyAddressState = "account_address_state"
+
+// addressStateLength is the length of the encoded address generator state.
+const addressStateLength = 6
+
+// addressStateID is the ID of the global register holding the state of the address generator.
+var addressStateID = flowgo.RegisterID{Key: keyAddressState}
+
+// Client fetches execution state from an upstream Flow network.
+type Client interface {
+	// LatestBlockHeight returns the height of the latest sealed upstream block.
+	LatestBlockHeight(ctx context.Context) (uint64, error)
+	// ChainID returns the ID of the upstream chain.
+	ChainID(ctx context.Context) (flowgo.ChainID, error)
+	// RegisterAtHeight returns the value of a register at the given upstream block height.
+	//
+	// A nil value is returned if the register does not exist.
+	RegisterAtHeight(ctx context.Context, blockHeight uint64, id flowgo.RegisterID) (flowgo.RegisterValue, error)
+}
+
+// GRPCClient implements the Client interface on top of the gRPC Access API.
+//
+// The Access API does not serve registers, so the registers of an account are rebuilt
+// from the account returned by GetAccountAtBlockHeight: its existence, public keys and
+// contract code are available, but the values stored by Cadence in the account storage,
+// including contract instances, are not, and are read as missing. The state of the address generator is recovered by searching
+// for the last account created at the block height.
+type GRPCClient struct {
+	access access.AccessAPIClient
+
+	mu       sync.RWMutex
+	chainID  flowgo.ChainID
+	accounts map[accountAtHeight]map[flowgo.RegisterID]flowgo.RegisterValue
+}
+
+// accountAtHeight identifies an account at an upstream block height.
+type accountAtHeight struct {
+	height  uint64
+	address flowgo.Address
+}
+
+var _ Client = &GRPCClient{}
+
+// NewGRPCClient returns a new client using the given Access API connection.
+func NewGRPCClient(conn grpc.ClientConnInterface) *GRPCClient {
+	return &GRPCClient{
+		access:   access.NewAccessAPIClient(conn),
+		accounts: make(map[accountAtHeight]map[flowgo.RegisterID]flowgo.RegisterValue),
+	}
+}
+
+func (c *GRPCClient) LatestBlockHeight(ctx context.Context) (uint64, error) {
+	res, err := c.access.GetLatestBlockHeader(ctx, &access.GetLatestBlockHeaderRequest{IsSealed: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block header: %w", err)
+	}
+
+	return res.GetBlock().GetHeight(), nil
+}
+
+func (c *GRPCClient) ChainID(ctx context.Context) (flowgo.ChainID, error) {
+	c.mu.RLock()
+	chainID := c.chainID
+	c.mu.RUnlock()
+
+	if chainID != "" {
+		return chainID, nil
+	}
+
+	res, err := c.access.GetNetworkParameters(ctx, &access.GetNetworkParametersRequest{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get network parameters: %w", err)
+	}
+
+	chainID = flowgo.ChainID(res.GetChainId())
+
+	switch chainID {
+	case flowgo.Mainnet, flowgo.Testnet, flowgo.Canary, flowgo.Benchnet, flowgo.Localnet, flowgo.Emulator,
+		flowgo.MonotonicEmulator:
+	default:
+		return "", fmt.Errorf("unsupported upstream chain: %s", chainID)
+	}
+
+	c.mu.Lock()
+	c.chainID = chainID
+	c.mu.Unlock()
+
+	return chainID, nil
+}
+
+func (c *GRPCClient) RegisterAtHeight(
+	ctx context.Context,
+	blockHeight uint64,
+	id flowgo.RegisterID,
+) (flowgo.RegisterValue, error) {
+	if id == addressStateID {
+		return c.addressState(ctx, blockHeight)
+	}
+
+	if len(id.Owner) != flowgo.AddressLength {
+		return nil, nil
+	}
+
+	registers, err := c.accountRegisters(ctx, blockHeight, flowgo.BytesToAddress([]byte(id.Owner)))
+	if err != nil {
+		return nil, err
+	}
+
+	return registers[id], nil
+}
+
+// addressState returns the state of the upstream address generator at the given block height,
+// which is the index of the last account created.
+//
+// Accounts are never removed, so the index is found with an exponential search
+// followed by a binary search over the existence of the address at each index.
+func (c *GRPCClient) addressState(ctx context.Context, blockHeight uint64) (flowgo.RegisterValue, error) {
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := chainID.Chain()
+
+	exists := func(index uint64) (bool, error) {
+		address, err := chain.AddressAtIndex(index)
+		if err != nil {
+			return false, err
+		}
+
+		registers, err := c.accountRegisters(ctx, blockHeight, address)
+		if err != nil {
+			return false, err
+		}
+
+		return registers != nil, nil
+	}
+
+	// the last index known to exist, and the first index known to be missing
+	var last uint64
+	missing := uint64(1)
+
+	for {
+		ok, err := exists(missing)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		last = missing
+		missing *= 2
+	}
+
+	for missing-last > 1 {
+		index := last + (missing-last)/2
+
+		ok, err := exists(index)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			last = index
+		} else {
+			missing = index
+		}
+	}
+
+	if last == 0 {
+		return nil, nil
+	}
+
+	return encodeAddressState(last), nil
+}
+
+// encodeAddressState encodes an address generator index the way the FVM stores it.
+func encodeAddressState(index uint64) flowgo.RegisterValue {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], index)
+	return b[8-addressStateLength:]
+}
+
+// decodeAddressState decodes an address generator index stored by the FVM.
+func decodeAddressState(value flowgo.RegisterValue) uint64 {
+	var b [8]byte
+	if len(value) > addressStateLength {
+		value = value[len(value)-addressStateLength:]
+	}
+	copy(b[8-len(value):], value)
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// accountRegisters returns the registers of an upstream account at the given block height,
+// or nil if the account does not exist.
+func (c *GRPCClient) accountRegisters(
+	ctx context.Context,
+	blockHeight uint64,
+	address flowgo.Address,
+) (map[flowgo.RegisterID]flowgo.RegisterValue, error) {
+	key := accountAtHeight{height: blockHeight, address: address}
+
+	c.mu.RLock()
+	registers, ok := c.accounts[key]
+	c.mu.RUnlock()
+
+	if ok {
+		return registers, nil
+	}
+
+	res, err := c.access.GetAccountAtBlockHeight(ctx, &access.GetAccountAtBlockHeightRequest{
+		Address:     address.Bytes(),
+		BlockHeight: blockHeight,
+	})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, fmt.Errorf("failed to get account %s at block %d: %w", address, blockHeight, err)
+	}
+
+	if err == nil {
+		account, err := convert.MessageToAccount(res.GetAccount())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode account %s: %w", address, err)
+		}
+
+		registers, err = accountToRegisters(account)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode registers of account %s: %w", address, err)
+		}
+	}
+
+	c.mu.Lock()
+	c.accounts[key] = registers
+	c.mu.Unlock()
+
+	return registers, nil
+}
+
+// accountToRegisters encodes the registers the FVM stores for the given account.
+//
+// The storage used by the account only accounts for these registers.
+func accountToRegisters(account *flowgo.Account) (map[flowgo.RegisterID]flowgo.RegisterValue, error) {
+	owner := string(account.Address.Bytes())
+
+	registers := map[flowgo.RegisterID]flowgo.RegisterValue{
+		flowgo.NewRegisterID(owner, "", state.KeyExists): {1},
+	}
+
+	if len(account.Keys) > 0 {
+		count := new(big.Int).SetUint64(uint64(len(account.Keys)))
+		registers[flowgo.NewRegisterID(owner, owner, state.KeyPublicKeyCount)] = count.Bytes()
+	}
+
+	for i, key := range account.Keys {
+		encoded, err := flowgo.EncodeAccountPublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		registers[flowgo.NewRegisterID(owner, owner, fmt.Sprintf("public_key_%d", i))] = encoded
+	}
+
+	if len(account.Contracts) > 0 {
+		names := make([]string, 0, len(account.Contracts))
+		for name, code := range account.Contracts {
+			names = append(names, name)
+			registers[flowgo.NewRegisterID(owner, owner, state.ContractKey(name))] = code
+		}
+
+		sort.Strings(names)
+
+		encoded, err := cbor.Marshal(names)
+		if err != nil {
+			return nil, err
+		}
+
+		registers[flowgo.NewRegisterID(owner, owner, state.KeyContractNames)] = encoded
+	}
+
+	storageUsedID := flowgo.NewRegisterID(owner, "", state.KeyStorageUsed)
+	storageUsed := make([]byte, 8)
+	registers[storageUsedID] = storageUsed
+
+	var used uint64
+	for id, value := range registers {
+		used += uint64(state.RegisterSize(account.Address, id.Controller != "", id.Key, value))
+	}
+
+	binary.BigEndian.PutUint64(storageUsed, used)
+
+	return registers, nil
+}
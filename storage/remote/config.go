@@ -0,0 +1,37 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+// Config defines the configurable parameters of the remote storage implementation.
+type Config struct {
+	// ForkHeight is the upstream block height whose state is forked.
+	// A zero value forks from the latest sealed block.
+	ForkHeight uint64
+}
+
+// The default config to use when instantiating a remote store.
+var defaultConfig = Config{}
+
+type Opt func(*Config)
+
+func WithForkHeight(height uint64) Opt {
+	return func(c *Config) {
+		c.ForkHeight = height
+	}
+}
@@ -0,0 +1,299 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package remote implements a store that forks execution state from a live Flow network.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	"github.com/onflow/flow-emulator/storage"
+)
+
+// Store implements the Store interface on top of a local store, lazily
+// reading registers that are missing locally from an upstream network.
+//
+// Registers are fetched at the fork height the first time they are read and
+// then cached, so the upstream network is only queried once per register.
+// Registers that have been written or deleted locally are never fetched,
+// and the upstream network is not queried until the local genesis block
+// is committed, so the chain is bootstrapped from local state only.
+// All blocks, transactions and events are kept in the local store.
+type Store struct {
+	storage.Store
+	client     Client
+	forkHeight uint64
+	chainID    flowgo.ChainID
+
+	mu           sync.RWMutex
+	cache        map[flowgo.RegisterID]flowgo.RegisterValue
+	bootstrapped bool
+}
+
+var _ storage.Store = &Store{}
+
+// New returns a new remote Store implementation wrapping the given local store.
+//
+// If no fork height is configured, the latest sealed upstream block is used.
+func New(local storage.Store, client Client, opts ...Opt) (*Store, error) {
+	conf := defaultConfig
+	for _, applyOption := range opts {
+		applyOption(&conf)
+	}
+
+	forkHeight := conf.ForkHeight
+	if forkHeight == 0 {
+		height, err := client.LatestBlockHeight(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve fork height: %w", err)
+		}
+
+		forkHeight = height
+	}
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve chain ID: %w", err)
+	}
+
+	return &Store{
+		Store:      local,
+		client:     client,
+		forkHeight: forkHeight,
+		chainID:    chainID,
+		cache:      make(map[flowgo.RegisterID]flowgo.RegisterValue),
+	}, nil
+}
+
+// ForkHeight returns the upstream block height the state is forked from.
+func (s *Store) ForkHeight() uint64 {
+	return s.forkHeight
+}
+
+// ChainID returns the ID of the upstream chain, which the forked chain must be bootstrapped with.
+func (s *Store) ChainID() flowgo.ChainID {
+	return s.chainID
+}
+
+func (s *Store) LedgerViewByHeight(blockHeight uint64) *delta.View {
+	localView := s.Store.LedgerViewByHeight(blockHeight)
+
+	return delta.NewView(func(owner, controller, key string) (flowgo.RegisterValue, error) {
+		value, err := localView.Get(owner, controller, key)
+		if err != nil {
+			return nil, err
+		}
+
+		id := flowgo.RegisterID{
+			Owner:      owner,
+			Controller: controller,
+			Key:        key,
+		}
+
+		bootstrapped, err := s.isBootstrapped()
+		if err != nil {
+			return nil, err
+		}
+
+		if !bootstrapped {
+			return value, nil
+		}
+
+		if id == addressStateID {
+			return s.addressState(value)
+		}
+
+		if len(value) > 0 {
+			return value, nil
+		}
+
+		// a register without a local value may have been deleted locally
+		changed, err := s.changedLocally(id, blockHeight)
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			return nil, nil
+		}
+
+		return s.remoteValue(id)
+	})
+}
+
+// isBootstrapped returns true once the local genesis block is committed.
+func (s *Store) isBootstrapped() (bool, error) {
+	s.mu.RLock()
+	bootstrapped := s.bootstrapped
+	s.mu.RUnlock()
+
+	if bootstrapped {
+		return true, nil
+	}
+
+	_, err := s.Store.LatestBlock()
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.bootstrapped = true
+	s.mu.Unlock()
+
+	return true, nil
+}
+
+// addressState returns the later of the local and upstream address generator states,
+// so accounts created locally never take the address of an upstream account.
+func (s *Store) addressState(localValue flowgo.RegisterValue) (flowgo.RegisterValue, error) {
+	remoteValue, err := s.remoteValue(addressStateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if decodeAddressState(remoteValue) > decodeAddressState(localValue) {
+		return remoteValue, nil
+	}
+
+	return localValue, nil
+}
+
+// changedLocally returns true if the register was written or deleted in a local
+// block at or below the given height.
+func (s *Store) changedLocally(id flowgo.RegisterID, blockHeight uint64) (bool, error) {
+	changelog, ok := s.Store.(storage.RegisterChangelog)
+	if !ok {
+		return false, nil
+	}
+
+	return changelog.RegisterChanged(id, blockHeight)
+}
+
+// RegistersByOwner returns the registers owned by the given address at a given block.
+//
+// Upstream registers are only included once they have been read, as the upstream
+// network does not support listing the registers of an account.
+func (s *Store) RegistersByOwner(owner flowgo.Address, blockHeight uint64) ([]flowgo.RegisterEntry, error) {
+	registers, err := s.Store.RegistersByOwner(owner, blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	localView := s.Store.LedgerViewByHeight(blockHeight)
+	ownerString := string(owner.Bytes())
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, value := range s.cache {
+		if id.Owner != ownerString || len(value) == 0 {
+			continue
+		}
+
+		// registers written or deleted locally take precedence
+		localValue, err := localView.Get(id.Owner, id.Controller, id.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(localValue) > 0 {
+			continue
+		}
+
+		changed, err := s.changedLocally(id, blockHeight)
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			continue
+		}
+
+		registers = append(registers, flowgo.RegisterEntry{
+			Key:   id,
+			Value: value,
+		})
+	}
+
+	storage.SortRegisters(registers)
+
+	return registers, nil
+}
+
+// JumpToContext forwards to the local store, and clears the cached upstream registers
+// if the state is reverted to an existing snapshot.
+func (s *Store) JumpToContext(name string) error {
+	err := s.Store.JumpToContext(name)
+	if err != nil {
+		return err
+	}
+
+	s.clearCache()
+
+	return nil
+}
+
+// LoadSnapshot reverts the local store to the snapshot with the given name, and clears
+// the cached upstream registers, which are fetched again when they are next read.
+func (s *Store) LoadSnapshot(name string) error {
+	err := s.Store.LoadSnapshot(name)
+	if err != nil {
+		return err
+	}
+
+	s.clearCache()
+
+	return nil
+}
+
+func (s *Store) clearCache() {
+	s.mu.Lock()
+	s.cache = make(map[flowgo.RegisterID]flowgo.RegisterValue)
+	s.mu.Unlock()
+}
+
+// remoteValue returns the upstream value of a register, fetching it on first access.
+func (s *Store) remoteValue(id flowgo.RegisterID) (flowgo.RegisterValue, error) {
+	s.mu.RLock()
+	value, ok := s.cache[id]
+	s.mu.RUnlock()
+
+	if ok {
+		return value, nil
+	}
+
+	value, err := s.client.RegisterAtHeight(context.Background(), s.forkHeight, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[id] = value
+	s.mu.Unlock()
+
+	return value, nil
+}
@@ -0,0 +1,464 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/onflow/flow-go-sdk"
+	sdkcrypto "github.com/onflow/flow-go-sdk/crypto"
+	"github.com/onflow/flow-go/crypto"
+	"github.com/onflow/flow-go/crypto/hash"
+	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow/protobuf/go/flow/access"
+	"github.com/onflow/flow/protobuf/go/flow/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/storage/memstore"
+	"github.com/onflow/flow-emulator/storage/remote"
+)
+
+// upstream is a local stand-in for an access node serving accounts at a single block height.
+type upstream struct {
+	height     uint64
+	chainID    flowgo.ChainID
+	accounts   map[flowgo.Address]*flowgo.Account
+	privateKey crypto.PrivateKey
+
+	mu       sync.Mutex
+	requests int
+}
+
+// newUpstream returns an upstream network with the given number of accounts,
+// each holding the same single key.
+func newUpstream(t *testing.T, chainID flowgo.ChainID, height uint64, accountCount uint64) *upstream {
+	privateKey, err := crypto.GeneratePrivateKey(
+		crypto.ECDSAP256,
+		[]byte("upstream account key upstream account key upstream account key"),
+	)
+	require.NoError(t, err)
+
+	u := &upstream{
+		height:     height,
+		chainID:    chainID,
+		accounts:   make(map[flowgo.Address]*flowgo.Account),
+		privateKey: privateKey,
+	}
+
+	for i := uint64(1); i <= accountCount; i++ {
+		address, err := chainID.Chain().AddressAtIndex(i)
+		require.NoError(t, err)
+
+		u.accounts[address] = &flowgo.Account{
+			Address: address,
+			Keys: []flowgo.AccountPublicKey{
+				{
+					PublicKey: privateKey.PublicKey(),
+					SignAlgo:  crypto.ECDSAP256,
+					HashAlgo:  hash.SHA3_256,
+					Weight:    flow.AccountKeyWeightThreshold,
+				},
+			},
+			Contracts: make(map[string][]byte),
+		}
+	}
+
+	return u
+}
+
+func (u *upstream) account(t *testing.T, index uint64) *flowgo.Account {
+	address, err := u.chainID.Chain().AddressAtIndex(index)
+	require.NoError(t, err)
+
+	return u.accounts[address]
+}
+
+func (u *upstream) requestCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.requests
+}
+
+type accessServer struct {
+	access.UnimplementedAccessAPIServer
+	upstream *upstream
+}
+
+func (s *accessServer) GetLatestBlockHeader(
+	_ context.Context,
+	_ *access.GetLatestBlockHeaderRequest,
+) (*access.BlockHeaderResponse, error) {
+	return &access.BlockHeaderResponse{
+		Block: &entities.BlockHeader{Height: s.upstream.height},
+	}, nil
+}
+
+func (s *accessServer) GetNetworkParameters(
+	_ context.Context,
+	_ *access.GetNetworkParametersRequest,
+) (*access.GetNetworkParametersResponse, error) {
+	return &access.GetNetworkParametersResponse{
+		ChainId: string(s.upstream.chainID),
+	}, nil
+}
+
+func (s *accessServer) GetAccountAtBlockHeight(
+	_ context.Context,
+	req *access.GetAccountAtBlockHeightRequest,
+) (*access.AccountResponse, error) {
+	u := s.upstream
+
+	u.mu.Lock()
+	u.requests++
+	u.mu.Unlock()
+
+	if req.GetBlockHeight() != u.height {
+		return nil, status.Error(codes.InvalidArgument, "unknown block")
+	}
+
+	account, ok := u.accounts[flowgo.BytesToAddress(req.GetAddress())]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "account not found")
+	}
+
+	message, err := convert.AccountToMessage(account)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &access.AccountResponse{Account: message}, nil
+}
+
+func startUpstream(t *testing.T, u *upstream) *grpc.ClientConn {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	access.RegisterAccessAPIServer(server, &accessServer{upstream: u})
+
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// commitGenesis commits a genesis block with the given changes to the local store.
+func commitGenesis(t *testing.T, local *memstore.Store, d delta.Delta) {
+	err := local.CommitBlock(
+		flowgo.Block{Header: &flowgo.Header{Height: 0}},
+		nil,
+		nil,
+		nil,
+		d,
+		nil,
+	)
+	require.NoError(t, err)
+}
+
+func TestRemoteStore(t *testing.T) {
+
+	t.Parallel()
+
+	u := newUpstream(t, flowgo.Testnet, 42, 5)
+
+	account := u.account(t, 5)
+	account.Contracts["Remote"] = []byte("remote")
+	account.Contracts["Shadowed"] = []byte("upstream")
+
+	owner := string(account.Address.Bytes())
+
+	conn := startUpstream(t, u)
+
+	local := memstore.New()
+
+	d := delta.NewDelta()
+	d.Set(owner, owner, "code.Shadowed", []byte("local"))
+	commitGenesis(t, local, d)
+
+	store, err := remote.New(local, remote.NewGRPCClient(conn))
+	require.NoError(t, err)
+
+	t.Run("should fork from latest upstream block by default", func(t *testing.T) {
+		assert.Equal(t, u.height, store.ForkHeight())
+	})
+
+	t.Run("should use the upstream chain ID", func(t *testing.T) {
+		assert.Equal(t, flowgo.Testnet, store.ChainID())
+	})
+
+	t.Run("should prefer local values", func(t *testing.T) {
+		value, err := store.LedgerViewByHeight(0).Get(owner, owner, "code.Shadowed")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("local"), value)
+	})
+
+	t.Run("should fetch accounts from upstream and cache them", func(t *testing.T) {
+		before := u.requestCount()
+
+		value, err := store.LedgerViewByHeight(0).Get(owner, owner, "code.Remote")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("remote"), value)
+
+		value, err = store.LedgerViewByHeight(0).Get(owner, owner, "contract_names")
+		require.NoError(t, err)
+
+		var names []string
+		err = cbor.Unmarshal(value, &names)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Remote", "Shadowed"}, names)
+
+		value, err = store.LedgerViewByHeight(0).Get(owner, "", "exists")
+		require.NoError(t, err)
+		assert.Equal(t, []byte{1}, value)
+
+		value, err = store.LedgerViewByHeight(0).Get(owner, owner, "public_key_0")
+		require.NoError(t, err)
+
+		key, err := flowgo.DecodeAccountPublicKey(value, 0)
+		require.NoError(t, err)
+		assert.True(t, account.Keys[0].PublicKey.Equals(key.PublicKey))
+
+		assert.Equal(t, before+1, u.requestCount())
+	})
+
+	t.Run("should return nil for registers missing upstream", func(t *testing.T) {
+		value, err := store.LedgerViewByHeight(0).Get(owner, "", "storage_index")
+		require.NoError(t, err)
+		assert.Empty(t, value)
+
+		missing, err := flowgo.Testnet.Chain().AddressAtIndex(6)
+		require.NoError(t, err)
+
+		value, err = store.LedgerViewByHeight(0).Get(string(missing.Bytes()), "", "exists")
+		require.NoError(t, err)
+		assert.Empty(t, value)
+	})
+
+	t.Run("should continue address generation after the last upstream account", func(t *testing.T) {
+		value, err := store.LedgerViewByHeight(0).Get("", "", "account_address_state")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), flowgo.Testnet.Chain().BytesToAddressGenerator(value).AddressCount())
+	})
+
+	t.Run("should not fetch registers deleted locally", func(t *testing.T) {
+		d := delta.NewDelta()
+		d.Set(owner, owner, "code.Remote", nil)
+
+		err := local.CommitBlock(
+			flowgo.Block{Header: &flowgo.Header{Height: 1}},
+			nil,
+			nil,
+			nil,
+			d,
+			nil,
+		)
+		require.NoError(t, err)
+
+		value, err := store.LedgerViewByHeight(1).Get(owner, owner, "code.Remote")
+		require.NoError(t, err)
+		assert.Empty(t, value)
+
+		// the register is still read from upstream before it was deleted
+		value, err = store.LedgerViewByHeight(0).Get(owner, owner, "code.Remote")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("remote"), value)
+	})
+
+	t.Run("should fork from configured height", func(t *testing.T) {
+		store, err := remote.New(local, remote.NewGRPCClient(conn), remote.WithForkHeight(41))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(41), store.ForkHeight())
+
+		_, err = store.LedgerViewByHeight(0).Get(owner, "", "exists")
+		assert.Error(t, err)
+	})
+}
+
+func TestRemoteStoreBootstrap(t *testing.T) {
+
+	t.Parallel()
+
+	u := newUpstream(t, flowgo.Testnet, 42, 5)
+	owner := string(u.account(t, 1).Address.Bytes())
+
+	conn := startUpstream(t, u)
+
+	local := memstore.New()
+
+	store, err := remote.New(local, remote.NewGRPCClient(conn))
+	require.NoError(t, err)
+
+	before := u.requestCount()
+
+	// the chain is bootstrapped from local state only
+	value, err := store.LedgerViewByHeight(0).Get(owner, "", "exists")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	assert.Equal(t, before, u.requestCount())
+
+	commitGenesis(t, local, delta.NewDelta())
+
+	value, err = store.LedgerViewByHeight(0).Get(owner, "", "exists")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+}
+
+func TestRemoteStoreSnapshots(t *testing.T) {
+
+	t.Parallel()
+
+	u := newUpstream(t, flowgo.Testnet, 42, 1)
+	owner := string(u.account(t, 1).Address.Bytes())
+
+	conn := startUpstream(t, u)
+
+	local := memstore.New()
+	commitGenesis(t, local, delta.NewDelta())
+
+	store, err := remote.New(local, remote.NewGRPCClient(conn))
+	require.NoError(t, err)
+
+	err = store.CreateSnapshot("forked")
+	require.NoError(t, err)
+
+	names, err := store.ListSnapshots()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"forked"}, names)
+
+	value, err := store.LedgerViewByHeight(0).Get(owner, "", "exists")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+
+	err = store.LoadSnapshot("forked")
+	require.NoError(t, err)
+
+	value, err = store.LedgerViewByHeight(0).Get(owner, "", "exists")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+
+	err = store.DeleteSnapshot("forked")
+	require.NoError(t, err)
+
+	names, err = store.ListSnapshots()
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestForkedBlockchain(t *testing.T) {
+
+	t.Parallel()
+
+	// the system accounts are bootstrapped locally at the first indices
+	u := newUpstream(t, flowgo.Testnet, 42, 5)
+
+	contractAccount := u.account(t, 5)
+	contractAccount.Contracts["Hello"] = []byte(`
+      pub contract Hello {
+        pub fun hello(): String {
+          return "Hello, forked world!"
+        }
+      }
+    `)
+
+	conn := startUpstream(t, u)
+
+	store, err := remote.New(memstore.New(), remote.NewGRPCClient(conn))
+	require.NoError(t, err)
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStore(store),
+		emulator.WithChainID(store.ChainID()),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, flowgo.Testnet, b.GetChain().ChainID())
+
+	t.Run("should execute transactions against forked state", func(t *testing.T) {
+		address := flow.BytesToAddress(contractAccount.Address.Bytes())
+
+		// the transaction is signed with the upstream key of the account
+		tx := flow.NewTransaction().
+			SetScript([]byte(fmt.Sprintf(
+				`
+                  import Hello from 0x%s
+
+                  transaction {
+                    prepare(signer: AuthAccount) {
+                      log(signer.contracts.names)
+                    }
+                  }
+                `,
+				address.Hex(),
+			))).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(address, 0, 0).
+			SetPayer(address).
+			AddAuthorizer(address)
+
+		err := tx.SignEnvelope(address, 0, sdkcrypto.NewInMemorySigner(u.privateKey, hash.SHA3_256))
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err := b.ExecuteNextTransaction()
+		require.NoError(t, err)
+		require.NoError(t, result.Error)
+
+		assert.Equal(t, []string{`["Hello"]`}, result.Logs)
+
+		_, err = b.CommitBlock()
+		require.NoError(t, err)
+
+		// the sequence number of the forked key is incremented locally
+		account, err := b.GetAccount(address)
+		require.NoError(t, err)
+		require.Len(t, account.Keys, 1)
+		assert.Equal(t, uint64(1), account.Keys[0].SequenceNumber)
+	})
+
+	t.Run("should not reuse upstream addresses", func(t *testing.T) {
+		address, err := b.CreateAccount([]*flow.AccountKey{b.ServiceKey().AccountKey()}, nil)
+		require.NoError(t, err)
+
+		expected, err := flowgo.Testnet.Chain().AddressAtIndex(6)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected.Hex(), address.Hex())
+	})
+}
@@ -64,6 +64,10 @@ type Store interface {
 		events []flowgo.Event,
 	) error
 
+	// CommitEmptyBlocks saves a batch of consecutive blocks without collections, transactions,
+	// events or ledger changes, following the latest block.
+	CommitEmptyBlocks(blocks []flowgo.Block) error
+
 	// CollectionByID gets the collection (transaction IDs only) with the given ID.
 	CollectionByID(flowgo.Identifier) (flowgo.LightCollection, error)
 
@@ -73,9 +77,72 @@ type Store interface {
 	// TransactionResultByID gets the transaction result with the given ID.
 	TransactionResultByID(flowgo.Identifier) (types.StorableTransactionResult, error)
 
+	// TransactionLocationByID gets the height of the block including the transaction with
+	// the given ID, and the position of the transaction in the block.
+	TransactionLocationByID(flowgo.Identifier) (TransactionLocation, error)
+
 	// LedgerViewByHeight returns a view into the ledger state at a given block.
 	LedgerViewByHeight(blockHeight uint64) *delta.View
 
+	// RegistersByOwner returns the registers owned by the given address at a given block,
+	// ordered by controller and key. Registers without a value are omitted.
+	RegistersByOwner(owner flowgo.Address, blockHeight uint64) ([]flowgo.RegisterEntry, error)
+
+	// RegistersChangedBetween returns the IDs of the registers written in the blocks after
+	// the first and up to the second height, ordered by owner, controller and key.
+	RegistersChangedBetween(fromHeight, toHeight uint64) ([]flowgo.RegisterID, error)
+
 	// EventsByHeight returns the events in the block at the given height, optionally filtered by type.
 	EventsByHeight(blockHeight uint64, eventType string) ([]flowgo.Event, error)
+
+	// EventsByHeightRange returns the events in the blocks from the start height up to and
+	// including the end height by block height, optionally filtered by type. Blocks without
+	// matching events are omitted.
+	EventsByHeightRange(eventType string, startHeight, endHeight uint64) (map[uint64][]flowgo.Event, error)
+
+	// TransactionsByAccount returns the committed transactions involving the given address as
+	// proposer, payer, authorizer or event-emitting contract, newest first. If a cursor is given,
+	// only transactions before it are returned. At most limit transactions are returned, or all
+	// of them if limit is zero.
+	TransactionsByAccount(
+		address flowgo.Address,
+		cursor *AccountTransactionCursor,
+		limit int,
+	) ([]AccountTransaction, error)
+
+	// RollbackToHeight removes all blocks above the given height, along with their
+	// collections, transactions, transaction results, events and ledger changes.
+	RollbackToHeight(blockHeight uint64) error
+
+	// JumpToContext creates a snapshot with the given name from the current
+	// state if it does not exist yet, otherwise it reverts the state to the
+	// existing snapshot.
+	JumpToContext(context string) error
+
+	// CreateSnapshot creates a snapshot with the given name from the current state.
+	// It returns ErrAlreadyExists if a snapshot with this name exists.
+	CreateSnapshot(name string) error
+
+	// LoadSnapshot reverts the state to the snapshot with the given name.
+	// It returns ErrNotFound if the snapshot does not exist.
+	LoadSnapshot(name string) error
+
+	// ListSnapshots returns the names of all snapshots in lexicographic order.
+	ListSnapshots() ([]string, error)
+
+	// DeleteSnapshot deletes the snapshot with the given name, leaving the
+	// current state untouched. It returns ErrNotFound if the snapshot does not exist.
+	DeleteSnapshot(name string) error
+}
+
+// RegisterChangelog is implemented by stores that keep track of which registers
+// have been written, including registers that have been deleted.
+//
+// Changelog support is optional, so callers should check for it with a type
+// assertion on the Store.
+type RegisterChangelog interface {
+
+	// RegisterChanged returns true if the register was set or deleted in a block
+	// at or below the given height.
+	RegisterChanged(id flowgo.RegisterID, blockHeight uint64) (bool, error)
 }
@@ -0,0 +1,54 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	flowgo "github.com/onflow/flow-go/model/flow"
+)
+
+// A TransactionLocation is the position of a committed transaction in the chain.
+type TransactionLocation struct {
+	BlockHeight uint64
+	// TransactionIndex is the position of the transaction in its block, in execution order.
+	TransactionIndex uint32
+}
+
+// TransactionLocationsInBlock returns the locations of the transactions in a block by ID.
+//
+// Transactions are executed in the order of the collections that include them.
+func TransactionLocationsInBlock(
+	blockHeight uint64,
+	collections []*flowgo.LightCollection,
+) map[flowgo.Identifier]TransactionLocation {
+	locations := make(map[flowgo.Identifier]TransactionLocation)
+
+	var txIndex uint32
+	for _, col := range collections {
+		for _, txID := range col.Transactions {
+			locations[txID] = TransactionLocation{
+				BlockHeight:      blockHeight,
+				TransactionIndex: txIndex,
+			}
+
+			txIndex++
+		}
+	}
+
+	return locations
+}
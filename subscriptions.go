@@ -0,0 +1,455 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emulator
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	sdk "github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+)
+
+// EventFilter selects the events delivered to an event subscription.
+type EventFilter struct {
+	// EventTypes restricts events to the given types. All types match if empty.
+	EventTypes []string
+	// ContractAddresses restricts events to those emitted by contracts deployed
+	// at the given addresses. All addresses match if empty.
+	ContractAddresses []flowgo.Address
+	// StartHeight is the first block height to deliver events for.
+	StartHeight uint64
+	// EndHeight is the last block height to deliver events for, or zero to keep
+	// the subscription open for all future blocks.
+	EndHeight uint64
+}
+
+// Filter returns the events matching the filter type and address constraints.
+func (f EventFilter) Filter(events []flowgo.Event) []flowgo.Event {
+	filtered := make([]flowgo.Event, 0, len(events))
+
+	for _, event := range events {
+		if f.matches(event) {
+			filtered = append(filtered, event)
+		}
+	}
+
+	return filtered
+}
+
+func (f EventFilter) matches(event flowgo.Event) bool {
+	if len(f.EventTypes) > 0 {
+		found := false
+		for _, eventType := range f.EventTypes {
+			if string(event.Type) == eventType {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.ContractAddresses) > 0 {
+		// contract event types have the format A.{address}.{contract}.{event}
+		parts := strings.Split(string(event.Type), ".")
+		if len(parts) < 4 || parts[0] != "A" {
+			return false
+		}
+
+		address := flowgo.HexToAddress(parts[1])
+
+		found := false
+		for _, contractAddress := range f.ContractAddresses {
+			if address == contractAddress {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// includesHeight returns true if the given block height lies within the filter range.
+func (f EventFilter) includesHeight(blockHeight uint64) bool {
+	if blockHeight < f.StartHeight {
+		return false
+	}
+
+	return f.EndHeight == 0 || blockHeight <= f.EndHeight
+}
+
+// defaultEventSubscriptionQueueLimit is the default maximum number of blocks with matching
+// events queued for a subscription before it is terminated for falling behind.
+const defaultEventSubscriptionQueueLimit = 1000
+
+// EventSubscription streams the events of committed blocks matching a filter.
+//
+// Only blocks containing at least one matching event are delivered. The events
+// channel is closed once the end height of the filter has been delivered or the
+// subscription is terminated. A subscription is terminated with an error if more
+// blocks than the queue limit of the blockchain are waiting for its consumer, or if
+// the chain state is rewound by a rollback or by reverting to a snapshot.
+type EventSubscription struct {
+	blockchain *Blockchain
+	filter     EventFilter
+	events     chan flowgo.BlockEvents
+
+	// backfillHeight is the height of the latest block committed when the subscription
+	// was created, the events of blocks up to it are read from storage on delivery
+	backfillHeight uint64
+
+	mu       sync.Mutex
+	queue    []flowgo.BlockEvents
+	finished bool
+	err      error
+
+	notify   chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newEventSubscription(blockchain *Blockchain, filter EventFilter, backfillHeight uint64) *EventSubscription {
+	return &EventSubscription{
+		blockchain:     blockchain,
+		filter:         filter,
+		events:         make(chan flowgo.BlockEvents),
+		backfillHeight: backfillHeight,
+		finished:       filter.EndHeight != 0 && filter.EndHeight <= backfillHeight,
+		notify:         make(chan struct{}, 1),
+		done:           make(chan struct{}),
+	}
+}
+
+// Events returns the channel on which matching block events are delivered.
+func (s *EventSubscription) Events() <-chan flowgo.BlockEvents {
+	return s.events
+}
+
+// Err returns the error the subscription was terminated with, or nil if it finished
+// or was closed. It should be checked once the events channel is closed.
+func (s *EventSubscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Close cancels the subscription and closes the events channel.
+func (s *EventSubscription) Close() {
+	s.blockchain.removeEventSubscription(s)
+	s.stop(nil)
+}
+
+// stop terminates the delivery of events with the given error.
+//
+// It does not remove the subscription from the blockchain.
+func (s *EventSubscription) stop(err error) {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+
+		close(s.done)
+	})
+}
+
+// push queues the matching events of a committed block for delivery.
+//
+// It returns true if the subscription has reached the end of its range or was
+// terminated because its queue is full.
+func (s *EventSubscription) push(block *flowgo.Block, events []flowgo.Event) bool {
+	height := block.Header.Height
+
+	s.mu.Lock()
+
+	if s.filter.includesHeight(height) {
+		filtered := s.filter.Filter(events)
+		if len(filtered) > 0 {
+			limit := s.blockchain.eventQueueLimit
+			if len(s.queue) >= limit {
+				s.mu.Unlock()
+				s.stop(&EventSubscriptionOverflowError{MaxQueuedBlocks: limit})
+				return true
+			}
+
+			s.queue = append(s.queue, flowgo.BlockEvents{
+				BlockID:        block.ID(),
+				BlockHeight:    height,
+				BlockTimestamp: block.Header.Timestamp,
+				Events:         filtered,
+			})
+		}
+	}
+
+	if s.filter.EndHeight != 0 && height >= s.filter.EndHeight {
+		s.finished = true
+	}
+
+	finished := s.finished
+
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return finished
+}
+
+// run delivers the events of the blocks committed before the subscription was created,
+// followed by the queued block events, until the subscription finishes or is terminated.
+func (s *EventSubscription) run() {
+	defer close(s.events)
+
+	if !s.backfill() {
+		return
+	}
+
+	for {
+		s.mu.Lock()
+
+		if len(s.queue) == 0 {
+			finished := s.finished
+			s.mu.Unlock()
+
+			if finished {
+				return
+			}
+
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		next := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.events <- next:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// backfill delivers the matching events of the blocks committed before the subscription
+// was created, reading one block at a time from storage.
+//
+// It returns false if the subscription was terminated.
+func (s *EventSubscription) backfill() bool {
+	endHeight := s.backfillHeight
+	if s.filter.EndHeight != 0 && s.filter.EndHeight < endHeight {
+		endHeight = s.filter.EndHeight
+	}
+
+	for height := s.filter.StartHeight; height <= endHeight; height++ {
+		blockEvents, err := s.blockchain.blockEventsByHeight(height, s.filter)
+		if err != nil {
+			s.stop(err)
+			return false
+		}
+
+		if len(blockEvents.Events) == 0 {
+			continue
+		}
+
+		select {
+		case s.events <- blockEvents:
+		case <-s.done:
+			return false
+		}
+	}
+
+	return true
+}
+
+// SubscribeEvents creates a subscription delivering the events matching the filter.
+//
+// Events of already committed blocks within the filter range are delivered first,
+// followed by the events of blocks as they are committed.
+func (b *Blockchain) SubscribeEvents(filter EventFilter) (*EventSubscription, error) {
+	if filter.EndHeight != 0 && filter.StartHeight > filter.EndHeight {
+		return nil, &InvalidBlockHeightRangeError{StartHeight: filter.StartHeight, EndHeight: filter.EndHeight}
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	latestBlock, err := b.storage.LatestBlock()
+	if err != nil {
+		return nil, &StorageError{err}
+	}
+
+	sub := newEventSubscription(b, filter, latestBlock.Header.Height)
+
+	if !sub.finished {
+		b.subscriptionsMu.Lock()
+		b.eventSubscriptions[sub] = struct{}{}
+		b.subscriptionsMu.Unlock()
+	}
+
+	go sub.run()
+
+	return sub, nil
+}
+
+// blockEventsByHeight returns the events of the block at the given height matching the filter.
+func (b *Blockchain) blockEventsByHeight(height uint64, filter EventFilter) (flowgo.BlockEvents, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	block, err := b.storage.BlockByHeight(height)
+	if err != nil {
+		return flowgo.BlockEvents{}, &StorageError{err}
+	}
+
+	events, err := b.storage.EventsByHeight(height, "")
+	if err != nil {
+		return flowgo.BlockEvents{}, &StorageError{err}
+	}
+
+	return flowgo.BlockEvents{
+		BlockID:        block.ID(),
+		BlockHeight:    height,
+		BlockTimestamp: block.Header.Timestamp,
+		Events:         filter.Filter(events),
+	}, nil
+}
+
+func (b *Blockchain) removeEventSubscription(sub *EventSubscription) {
+	b.subscriptionsMu.Lock()
+	defer b.subscriptionsMu.Unlock()
+
+	delete(b.eventSubscriptions, sub)
+}
+
+// resetEventSubscriptions terminates all subscriptions after the chain state was rewound,
+// as the blocks they delivered may no longer exist.
+func (b *Blockchain) resetEventSubscriptions() {
+	b.subscriptionsMu.Lock()
+	defer b.subscriptionsMu.Unlock()
+
+	for sub := range b.eventSubscriptions {
+		sub.stop(&EventSubscriptionResetError{})
+		delete(b.eventSubscriptions, sub)
+	}
+}
+
+// notifyEventSubscriptions delivers the events of a committed block to all subscriptions.
+func (b *Blockchain) notifyEventSubscriptions(block *flowgo.Block, events []flowgo.Event) {
+	b.subscriptionsMu.Lock()
+	defer b.subscriptionsMu.Unlock()
+
+	for sub := range b.eventSubscriptions {
+		if sub.push(block, events) {
+			delete(b.eventSubscriptions, sub)
+		}
+	}
+}
+
+// WaitForTransaction blocks until the transaction with the given ID is sealed
+// in a committed block and returns its result.
+//
+// If the context is done before the transaction is sealed, the context error is returned.
+func (b *Blockchain) WaitForTransaction(ctx context.Context, id sdk.Identifier) (*sdk.TransactionResult, error) {
+	txID := sdkconvert.SDKIdentifierToFlow(id)
+
+	// register before checking the status so that a commit in between is not missed
+	sealed := b.addTransactionWaiter(txID)
+	defer b.removeTransactionWaiter(txID, sealed)
+
+	result, err := b.GetTransactionResult(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Status == sdk.TransactionStatusSealed {
+		return result, nil
+	}
+
+	select {
+	case <-sealed:
+		return b.GetTransactionResult(id)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Blockchain) addTransactionWaiter(txID flowgo.Identifier) chan struct{} {
+	b.subscriptionsMu.Lock()
+	defer b.subscriptionsMu.Unlock()
+
+	sealed := make(chan struct{})
+
+	waiters, ok := b.transactionWaiters[txID]
+	if !ok {
+		waiters = make(map[chan struct{}]struct{})
+		b.transactionWaiters[txID] = waiters
+	}
+
+	waiters[sealed] = struct{}{}
+
+	return sealed
+}
+
+func (b *Blockchain) removeTransactionWaiter(txID flowgo.Identifier, sealed chan struct{}) {
+	b.subscriptionsMu.Lock()
+	defer b.subscriptionsMu.Unlock()
+
+	waiters, ok := b.transactionWaiters[txID]
+	if !ok {
+		return
+	}
+
+	delete(waiters, sealed)
+
+	if len(waiters) == 0 {
+		delete(b.transactionWaiters, txID)
+	}
+}
+
+// notifyTransactionWaiters wakes up all callers waiting for the given transactions to be sealed.
+func (b *Blockchain) notifyTransactionWaiters(txIDs []flowgo.Identifier) {
+	b.subscriptionsMu.Lock()
+	defer b.subscriptionsMu.Unlock()
+
+	for _, txID := range txIDs {
+		for sealed := range b.transactionWaiters[txID] {
+			close(sealed)
+		}
+
+		delete(b.transactionWaiters, txID)
+	}
+}
@@ -0,0 +1,395 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+)
+
+const accountCreatedEventType = "flow.AccountCreated"
+
+func receiveBlockEvents(t *testing.T, sub *emulator.EventSubscription) flowgo.BlockEvents {
+	select {
+	case blockEvents, ok := <-sub.Events():
+		require.True(t, ok, "subscription closed unexpectedly")
+		return blockEvents
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for events")
+	}
+
+	return flowgo.BlockEvents{}
+}
+
+// accountCreatedHeights returns the heights of all committed blocks containing an account creation.
+func accountCreatedHeights(t *testing.T, b *emulator.Blockchain) []uint64 {
+	latestBlock, err := b.GetLatestBlock()
+	require.NoError(t, err)
+
+	heights := make([]uint64, 0)
+
+	for height := uint64(0); height <= latestBlock.Header.Height; height++ {
+		events, err := b.GetEventsByHeight(height, accountCreatedEventType)
+		require.NoError(t, err)
+
+		if len(events) > 0 {
+			heights = append(heights, height)
+		}
+	}
+
+	return heights
+}
+
+func TestSubscribeEvents(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("should deliver events of committed blocks", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		sub, err := b.SubscribeEvents(emulator.EventFilter{
+			EventTypes: []string{accountCreatedEventType},
+		})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		_, err = b.CreateAccount([]*flow.AccountKey{b.ServiceKey().AccountKey()}, nil)
+		require.NoError(t, err)
+
+		heights := accountCreatedHeights(t, b)
+		require.Len(t, heights, 1)
+
+		block, err := b.GetBlockByHeight(heights[0])
+		require.NoError(t, err)
+
+		blockEvents := receiveBlockEvents(t, sub)
+
+		assert.Equal(t, block.ID(), blockEvents.BlockID)
+		assert.Equal(t, block.Header.Height, blockEvents.BlockHeight)
+		require.Len(t, blockEvents.Events, 1)
+		assert.Equal(t, flowgo.EventType(accountCreatedEventType), blockEvents.Events[0].Type)
+	})
+
+	t.Run("should deliver events of past blocks up to end height", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err = b.CreateAccount([]*flow.AccountKey{b.ServiceKey().AccountKey()}, nil)
+			require.NoError(t, err)
+		}
+
+		latestBlock, err := b.GetLatestBlock()
+		require.NoError(t, err)
+
+		sub, err := b.SubscribeEvents(emulator.EventFilter{
+			EventTypes: []string{accountCreatedEventType},
+			EndHeight:  latestBlock.Header.Height,
+		})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		heights := accountCreatedHeights(t, b)
+		require.Len(t, heights, 2)
+
+		assert.Equal(t, heights[0], receiveBlockEvents(t, sub).BlockHeight)
+		assert.Equal(t, heights[1], receiveBlockEvents(t, sub).BlockHeight)
+
+		_, ok := <-sub.Events()
+		assert.False(t, ok)
+	})
+
+	t.Run("should filter events by contract address", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		sub, err := b.SubscribeEvents(emulator.EventFilter{
+			ContractAddresses: []flowgo.Address{flowgo.HexToAddress("01")},
+		})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		_, err = b.CreateAccount([]*flow.AccountKey{b.ServiceKey().AccountKey()}, nil)
+		require.NoError(t, err)
+
+		select {
+		case blockEvents := <-sub.Events():
+			assert.Fail(t, "unexpected events", blockEvents)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("should stop delivering events when closed", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		sub, err := b.SubscribeEvents(emulator.EventFilter{})
+		require.NoError(t, err)
+
+		sub.Close()
+
+		_, err = b.CreateAccount([]*flow.AccountKey{b.ServiceKey().AccountKey()}, nil)
+		require.NoError(t, err)
+
+		_, ok := <-sub.Events()
+		assert.False(t, ok)
+	})
+}
+
+func TestEventSubscriptionTermination(t *testing.T) {
+
+	t.Parallel()
+
+	createAccount := func(t *testing.T, b *emulator.Blockchain) {
+		_, err := b.CreateAccount([]*flow.AccountKey{b.ServiceKey().AccountKey()}, nil)
+		require.NoError(t, err)
+	}
+
+	// drainEvents receives block events until the subscription is terminated
+	drainEvents := func(t *testing.T, sub *emulator.EventSubscription) {
+		for {
+			select {
+			case _, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "timed out waiting for the subscription to terminate")
+			}
+		}
+	}
+
+	t.Run("should terminate subscriptions falling behind", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(emulator.WithEventSubscriptionQueueLimit(1))
+		require.NoError(t, err)
+
+		sub, err := b.SubscribeEvents(emulator.EventFilter{
+			EventTypes: []string{accountCreatedEventType},
+		})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		// one block is waiting to be received, and one is queued
+		for i := 0; i < 3; i++ {
+			createAccount(t, b)
+		}
+
+		drainEvents(t, sub)
+
+		assert.IsType(t, &emulator.EventSubscriptionOverflowError{}, sub.Err())
+	})
+
+	t.Run("should terminate subscriptions when rolling back", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		createAccount(t, b)
+
+		sub, err := b.SubscribeEvents(emulator.EventFilter{
+			EventTypes:  []string{accountCreatedEventType},
+			StartHeight: 2,
+		})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		err = b.RollbackToHeight(0)
+		require.NoError(t, err)
+
+		drainEvents(t, sub)
+
+		assert.IsType(t, &emulator.EventSubscriptionResetError{}, sub.Err())
+	})
+
+	t.Run("should terminate subscriptions when loading a snapshot", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		err = b.CreateSnapshot("genesis")
+		require.NoError(t, err)
+
+		sub, err := b.SubscribeEvents(emulator.EventFilter{
+			EventTypes: []string{accountCreatedEventType},
+		})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		createAccount(t, b)
+
+		receiveBlockEvents(t, sub)
+
+		err = b.LoadSnapshot("genesis")
+		require.NoError(t, err)
+
+		drainEvents(t, sub)
+
+		assert.IsType(t, &emulator.EventSubscriptionResetError{}, sub.Err())
+	})
+
+	t.Run("should not terminate subscriptions when creating a snapshot", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		sub, err := b.SubscribeEvents(emulator.EventFilter{
+			EventTypes: []string{accountCreatedEventType},
+		})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		err = b.Snapshot("created")
+		require.NoError(t, err)
+
+		createAccount(t, b)
+
+		receiveBlockEvents(t, sub)
+		assert.NoError(t, sub.Err())
+	})
+
+	t.Run("should reject invalid height ranges", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		_, err = b.SubscribeEvents(emulator.EventFilter{
+			StartHeight: 2,
+			EndHeight:   1,
+		})
+		assert.IsType(t, &emulator.InvalidBlockHeightRangeError{}, err)
+	})
+}
+
+func TestWaitForTransaction(t *testing.T) {
+
+	t.Parallel()
+
+	newTransaction := func(b *emulator.Blockchain) *flow.Transaction {
+		tx := flow.NewTransaction().
+			SetScript([]byte(`transaction { execute {} }`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		return tx
+	}
+
+	t.Run("should return once the transaction is sealed", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		tx := newTransaction(b)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		done := make(chan *flow.TransactionResult)
+		go func() {
+			result, err := b.WaitForTransaction(context.Background(), tx.ID())
+			assert.NoError(t, err)
+			done <- result
+		}()
+
+		_, _, err = b.ExecuteAndCommitBlock()
+		require.NoError(t, err)
+
+		select {
+		case result := <-done:
+			assert.Equal(t, flow.TransactionStatusSealed, result.Status)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for transaction")
+		}
+	})
+
+	t.Run("should return immediately for sealed transactions", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		tx := newTransaction(b)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		_, _, err = b.ExecuteAndCommitBlock()
+		require.NoError(t, err)
+
+		result, err := b.WaitForTransaction(context.Background(), tx.ID())
+		require.NoError(t, err)
+		assert.Equal(t, flow.TransactionStatusSealed, result.Status)
+	})
+
+	t.Run("should return context error if the transaction is not sealed", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		tx := newTransaction(b)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err = b.WaitForTransaction(ctx, tx.ID())
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+}
@@ -0,0 +1,354 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/common"
+	sdk "github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+	"github.com/onflow/flow-emulator/storage"
+	"github.com/onflow/flow-emulator/types"
+)
+
+// tracer records the execution traces of transactions.
+//
+// Only one transaction is traced at a time, as transactions are executed while
+// holding the blockchain's write lock.
+type tracer struct {
+	current *traceRecorder
+	// whether to record the values of storage accesses, not just their sizes
+	values bool
+}
+
+type traceRecorder struct {
+	start   time.Time
+	entries []types.TraceEntry
+}
+
+func newTracer(values bool) *tracer {
+	return &tracer{values: values}
+}
+
+// trace runs a transaction and returns the trace of its execution.
+func (t *tracer) trace(run func() error) (*types.TransactionTrace, error) {
+	recorder := &traceRecorder{start: time.Now()}
+
+	t.current = recorder
+	err := run()
+	t.current = nil
+
+	if err != nil {
+		return nil, err
+	}
+
+	return recorder.transactionTrace(), nil
+}
+
+// record adds an operation that just completed to the trace being recorded.
+func (t *tracer) record(entry types.TraceEntry) {
+	recorder := t.current
+	if recorder == nil {
+		return
+	}
+
+	entry.Start = time.Since(recorder.start) - entry.Duration
+
+	recorder.entries = append(recorder.entries, entry)
+}
+
+// storageAccessed records a read or write of a register.
+func (t *tracer) storageAccessed(kind types.TraceEntryKind, owner, key, value []byte) {
+	entry := types.TraceEntry{
+		Kind:    storageEntryKind(key, kind),
+		Address: flowgo.BytesToAddress(owner),
+		Key:     string(key),
+		Size:    len(value),
+	}
+
+	if t.values {
+		entry.Value = value
+	}
+
+	t.record(entry)
+}
+
+// functionReturned records a function invocation of an instrumented program.
+func (t *tracer) functionReturned(call functionCall) {
+	t.record(types.TraceEntry{
+		Kind:     types.TraceEntryFunction,
+		Name:     call.function.name,
+		Location: call.function.location,
+		Duration: call.duration,
+	})
+}
+
+var _ callObserver = &tracer{}
+
+// transactionTrace orders the recorded operations by their start,
+// and nests them in the function invocations they occurred in.
+func (r *traceRecorder) transactionTrace() *types.TransactionTrace {
+	entries := r.entries
+
+	// function invocations are recorded when they return, after the operations they contain
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start < entries[j].Start
+	})
+
+	// end times of the function invocations enclosing the current entry
+	var stack []time.Duration
+
+	for i, entry := range entries {
+		for len(stack) > 0 && stack[len(stack)-1] <= entry.Start {
+			stack = stack[:len(stack)-1]
+		}
+
+		entries[i].Depth = len(stack)
+
+		if entry.Kind == types.TraceEntryFunction {
+			stack = append(stack, entry.Start+entry.Duration)
+		}
+	}
+
+	return &types.TransactionTrace{
+		Entries: entries,
+	}
+}
+
+// tracingRuntime wraps a Cadence runtime to record the operations of the transactions it executes.
+//
+// Function invocations are not reported by Cadence, so they are only recorded if the wrapped
+// runtime instruments programs with the tracer as a call observer.
+type tracingRuntime struct {
+	runtime.Runtime
+	tracer *tracer
+}
+
+func newTracingRuntime(rt runtime.Runtime, tracer *tracer) *tracingRuntime {
+	return &tracingRuntime{
+		Runtime: rt,
+		tracer:  tracer,
+	}
+}
+
+func (r *tracingRuntime) ExecuteTransaction(script runtime.Script, context runtime.Context) error {
+	context.Interface = &tracingInterface{Interface: context.Interface, tracer: r.tracer}
+
+	return r.Runtime.ExecuteTransaction(script, context)
+}
+
+// tracingInterface wraps a runtime interface to record the operations of a transaction.
+type tracingInterface struct {
+	runtime.Interface
+	tracer *tracer
+}
+
+// storageEntryKind returns the kind of a storage access. The registers of the
+// public and private paths of an account hold its capabilities.
+func storageEntryKind(key []byte, kind types.TraceEntryKind) types.TraceEntryKind {
+	for _, domain := range []common.PathDomain{common.PathDomainPublic, common.PathDomainPrivate} {
+		if strings.HasPrefix(string(key), domain.Identifier()+storagePathSeparator) {
+			return types.TraceEntryCapability
+		}
+	}
+
+	return kind
+}
+
+func (i *tracingInterface) GetValue(owner, key []byte) ([]byte, error) {
+	value, err := i.Interface.GetValue(owner, key)
+	if err != nil {
+		return nil, err
+	}
+
+	i.tracer.storageAccessed(types.TraceEntryStorageRead, owner, key, value)
+
+	return value, nil
+}
+
+func (i *tracingInterface) SetValue(owner, key, value []byte) error {
+	err := i.Interface.SetValue(owner, key, value)
+	if err != nil {
+		return err
+	}
+
+	i.tracer.storageAccessed(types.TraceEntryStorageWrite, owner, key, value)
+
+	return nil
+}
+
+func (i *tracingInterface) ValueExists(owner, key []byte) (bool, error) {
+	exists, err := i.Interface.ValueExists(owner, key)
+	if err != nil {
+		return false, err
+	}
+
+	i.tracer.storageAccessed(types.TraceEntryStorageRead, owner, key, nil)
+
+	return exists, nil
+}
+
+func (i *tracingInterface) EmitEvent(event cadence.Event) error {
+	err := i.Interface.EmitEvent(event)
+	if err != nil {
+		return err
+	}
+
+	// the payload is informational, so an encoding failure does not fail the transaction
+	payload, _ := jsoncdc.Encode(event)
+
+	i.tracer.record(types.TraceEntry{
+		Kind:  types.TraceEntryEvent,
+		Name:  event.EventType.ID(),
+		Value: payload,
+	})
+
+	return nil
+}
+
+// recordAccountAccess records an access to an account that just completed.
+func (i *tracingInterface) recordAccountAccess(operation string, address runtime.Address, start time.Time) {
+	i.tracer.record(types.TraceEntry{
+		Kind:     types.TraceEntryAccount,
+		Name:     operation,
+		Address:  flowgo.Address(address),
+		Duration: time.Since(start),
+	})
+}
+
+func (i *tracingInterface) CreateAccount(payer runtime.Address) (runtime.Address, error) {
+	defer i.recordAccountAccess("CreateAccount", payer, time.Now())
+
+	return i.Interface.CreateAccount(payer)
+}
+
+func (i *tracingInterface) AddEncodedAccountKey(address runtime.Address, publicKey []byte) error {
+	defer i.recordAccountAccess("AddEncodedAccountKey", address, time.Now())
+
+	return i.Interface.AddEncodedAccountKey(address, publicKey)
+}
+
+func (i *tracingInterface) RevokeEncodedAccountKey(address runtime.Address, index int) ([]byte, error) {
+	defer i.recordAccountAccess("RevokeEncodedAccountKey", address, time.Now())
+
+	return i.Interface.RevokeEncodedAccountKey(address, index)
+}
+
+func (i *tracingInterface) AddAccountKey(
+	address runtime.Address,
+	publicKey *runtime.PublicKey,
+	hashAlgo runtime.HashAlgorithm,
+	weight int,
+) (*runtime.AccountKey, error) {
+	defer i.recordAccountAccess("AddAccountKey", address, time.Now())
+
+	return i.Interface.AddAccountKey(address, publicKey, hashAlgo, weight)
+}
+
+func (i *tracingInterface) GetAccountKey(address runtime.Address, index int) (*runtime.AccountKey, error) {
+	defer i.recordAccountAccess("GetAccountKey", address, time.Now())
+
+	return i.Interface.GetAccountKey(address, index)
+}
+
+func (i *tracingInterface) RevokeAccountKey(address runtime.Address, index int) (*runtime.AccountKey, error) {
+	defer i.recordAccountAccess("RevokeAccountKey", address, time.Now())
+
+	return i.Interface.RevokeAccountKey(address, index)
+}
+
+func (i *tracingInterface) GetAccountContractCode(address runtime.Address, name string) ([]byte, error) {
+	defer i.recordAccountAccess("GetAccountContractCode", address, time.Now())
+
+	return i.Interface.GetAccountContractCode(address, name)
+}
+
+func (i *tracingInterface) UpdateAccountContractCode(address runtime.Address, name string, code []byte) error {
+	defer i.recordAccountAccess("UpdateAccountContractCode", address, time.Now())
+
+	return i.Interface.UpdateAccountContractCode(address, name, code)
+}
+
+func (i *tracingInterface) RemoveAccountContractCode(address runtime.Address, name string) error {
+	defer i.recordAccountAccess("RemoveAccountContractCode", address, time.Now())
+
+	return i.Interface.RemoveAccountContractCode(address, name)
+}
+
+func (i *tracingInterface) GetAccountContractNames(address runtime.Address) ([]string, error) {
+	defer i.recordAccountAccess("GetAccountContractNames", address, time.Now())
+
+	return i.Interface.GetAccountContractNames(address)
+}
+
+func (i *tracingInterface) GetAccountBalance(address common.Address) (uint64, error) {
+	defer i.recordAccountAccess("GetAccountBalance", address, time.Now())
+
+	return i.Interface.GetAccountBalance(address)
+}
+
+func (i *tracingInterface) GetAccountAvailableBalance(address common.Address) (uint64, error) {
+	defer i.recordAccountAccess("GetAccountAvailableBalance", address, time.Now())
+
+	return i.Interface.GetAccountAvailableBalance(address)
+}
+
+func (i *tracingInterface) GetStorageUsed(address runtime.Address) (uint64, error) {
+	defer i.recordAccountAccess("GetStorageUsed", address, time.Now())
+
+	return i.Interface.GetStorageUsed(address)
+}
+
+func (i *tracingInterface) GetStorageCapacity(address runtime.Address) (uint64, error) {
+	defer i.recordAccountAccess("GetStorageCapacity", address, time.Now())
+
+	return i.Interface.GetStorageCapacity(address)
+}
+
+// GetTransactionTrace returns the execution trace of a transaction,
+// or nil if traces were not recorded for it.
+func (b *Blockchain) GetTransactionTrace(txID sdk.Identifier) (*types.TransactionTrace, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	flowTxID := sdkconvert.SDKIdentifierToFlow(txID)
+
+	if result, ok := b.pendingBlock.transactionResults[flowTxID]; ok {
+		return result.Trace, nil
+	}
+
+	result, err := b.storage.TransactionResultByID(flowTxID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, &TransactionNotFoundError{ID: flowTxID}
+		}
+
+		return nil, &StorageError{err}
+	}
+
+	return result.Trace, nil
+}
@@ -0,0 +1,196 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/templates"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/types"
+)
+
+func TestTransactionTrace(t *testing.T) {
+
+	t.Parallel()
+
+	const contract = `
+		pub contract Greeter {
+			pub event Greeted(greeting: String)
+
+			pub fun greet(signer: AuthAccount) {
+				signer.save("Hello, World!", to: /storage/greeting)
+				emit Greeted(greeting: "Hello, World!")
+			}
+		}
+	`
+
+	executeTransaction := func(t *testing.T, b *emulator.Blockchain) *types.TransactionResult {
+		address, err := b.CreateAccount(
+			nil,
+			[]templates.Contract{{Name: "Greeter", Source: contract}},
+		)
+		require.NoError(t, err)
+
+		tx := flow.NewTransaction().
+			SetScript([]byte(fmt.Sprintf(`
+				import Greeter from 0x%s
+
+				transaction {
+					prepare(signer: AuthAccount) {
+						Greeter.greet(signer: signer)
+					}
+				}
+			`, address.Hex()))).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(b.ServiceKey().Address)
+
+		err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err := b.ExecuteNextTransaction()
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+
+		return result
+	}
+
+	t.Run("should record trace", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+			emulator.WithTransactionTraces(true),
+		)
+		require.NoError(t, err)
+
+		result := executeTransaction(t, b)
+		require.NotNil(t, result.Trace)
+
+		var greet, event *types.TraceEntry
+		var writes int
+		var written int
+
+		for i, entry := range result.Trace.Entries {
+			switch entry.Kind {
+			case types.TraceEntryFunction:
+				if strings.HasSuffix(entry.Name, "greet") {
+					greet = &result.Trace.Entries[i]
+				}
+			case types.TraceEntryEvent:
+				event = &result.Trace.Entries[i]
+			case types.TraceEntryStorageWrite:
+				writes++
+				written += entry.Size
+
+				// values are not recorded by default
+				assert.Empty(t, entry.Value)
+			}
+		}
+
+		require.NotNil(t, greet)
+		require.NotNil(t, event)
+
+		assert.Contains(t, event.Name, "Greeter.Greeted")
+		assert.Greater(t, event.Depth, greet.Depth)
+		assert.Greater(t, event.Start, greet.Start)
+		assert.Greater(t, writes, 0)
+		assert.Greater(t, written, 0)
+
+		trace, err := b.GetTransactionTrace(result.TransactionID)
+		require.NoError(t, err)
+		assert.Equal(t, result.Trace, trace)
+
+		_, err = b.CommitBlock()
+		require.NoError(t, err)
+
+		trace, err = b.GetTransactionTrace(result.TransactionID)
+		require.NoError(t, err)
+		assert.Equal(t, result.Trace, trace)
+	})
+
+	t.Run("should record storage values if enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+			emulator.WithTransactionTraces(true),
+			emulator.WithTraceValues(true),
+		)
+		require.NoError(t, err)
+
+		result := executeTransaction(t, b)
+		require.NotNil(t, result.Trace)
+
+		var writes int
+
+		for _, entry := range result.Trace.Entries {
+			if entry.Kind == types.TraceEntryStorageWrite {
+				writes++
+				assert.Len(t, entry.Value, entry.Size)
+			}
+		}
+
+		assert.Greater(t, writes, 0)
+	})
+
+	t.Run("should not record trace by default", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		result := executeTransaction(t, b)
+		assert.Nil(t, result.Trace)
+
+		_, err = b.CommitBlock()
+		require.NoError(t, err)
+
+		trace, err := b.GetTransactionTrace(result.TransactionID)
+		require.NoError(t, err)
+		assert.Nil(t, trace)
+	})
+
+	t.Run("should fail for unknown transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		_, err = b.GetTransactionTrace(flow.EmptyID)
+		assert.IsType(t, &emulator.TransactionNotFoundError{}, err)
+	})
+}
@@ -0,0 +1,155 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator
+
+import (
+	"encoding/binary"
+	"errors"
+
+	sdk "github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	"github.com/onflow/flow-go/fvm/state"
+	flowgo "github.com/onflow/flow-go/model/flow"
+
+	sdkconvert "github.com/onflow/flow-emulator/convert/sdk"
+	"github.com/onflow/flow-emulator/storage"
+	"github.com/onflow/flow-emulator/types"
+)
+
+// newTransactionStateDelta returns the ledger changes of a transaction executed on a child view of the given view.
+func newTransactionStateDelta(parentView state.View, txView state.View) (*types.TransactionStateDelta, error) {
+	childView, ok := txView.(*delta.View)
+	if !ok {
+		return nil, nil
+	}
+
+	ids, values := childView.Delta().RegisterUpdates()
+
+	written := make(map[flowgo.RegisterID]flowgo.RegisterValue, len(ids))
+	for i, id := range ids {
+		written[id] = values[i]
+	}
+
+	storage.SortRegisterIDs(ids)
+
+	stateDelta := &types.TransactionStateDelta{
+		Registers: make([]types.RegisterDelta, len(ids)),
+		Accounts:  make([]types.AccountStorageDelta, 0),
+	}
+
+	for i, id := range ids {
+		before, err := parentView.Get(id.Owner, id.Controller, id.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		after := written[id]
+
+		stateDelta.Registers[i] = types.RegisterDelta{
+			ID:     id,
+			Before: before,
+			After:  after,
+		}
+
+		if len(id.Owner) == 0 {
+			continue
+		}
+
+		address := flowgo.BytesToAddress([]byte(id.Owner))
+
+		// registers are ordered by owner, so an owner is new if it differs from the previous one
+		accounts := stateDelta.Accounts
+		if len(accounts) == 0 || accounts[len(accounts)-1].Address != address {
+			storageUsedBefore, err := storageUsed(parentView, id.Owner)
+			if err != nil {
+				return nil, err
+			}
+
+			storageUsedAfter, err := storageUsed(txView, id.Owner)
+			if err != nil {
+				return nil, err
+			}
+
+			stateDelta.Accounts = append(stateDelta.Accounts, types.AccountStorageDelta{
+				Address:           address,
+				StorageUsedBefore: storageUsedBefore,
+				StorageUsedAfter:  storageUsedAfter,
+			})
+		}
+
+		account := &stateDelta.Accounts[len(stateDelta.Accounts)-1]
+
+		sizeBefore := registerSize(id, before)
+		sizeAfter := registerSize(id, after)
+
+		if sizeAfter > sizeBefore {
+			account.BytesAdded += sizeAfter - sizeBefore
+		} else {
+			account.BytesRemoved += sizeBefore - sizeAfter
+		}
+	}
+
+	return stateDelta, nil
+}
+
+// registerSize returns the size of a register, or zero if it has no value.
+func registerSize(id flowgo.RegisterID, value flowgo.RegisterValue) uint64 {
+	if len(value) == 0 {
+		return 0
+	}
+
+	return uint64(len(id.Owner) + len(id.Controller) + len(id.Key) + len(value))
+}
+
+// storageUsed returns the storage used by the account owning the registers with the given owner.
+func storageUsed(view state.View, owner string) (uint64, error) {
+	value, err := view.Get(owner, "", registerKeyStorageUsed)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(value) != 8 {
+		return 0, nil
+	}
+
+	return binary.BigEndian.Uint64(value), nil
+}
+
+// GetTransactionStateDelta returns the ledger changes of an executed transaction,
+// or nil if state deltas were not recorded for it.
+func (b *Blockchain) GetTransactionStateDelta(txID sdk.Identifier) (*types.TransactionStateDelta, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	flowTxID := sdkconvert.SDKIdentifierToFlow(txID)
+
+	if result, ok := b.pendingBlock.transactionResults[flowTxID]; ok {
+		return result.StateDelta, nil
+	}
+
+	result, err := b.storage.TransactionResultByID(flowTxID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, &TransactionNotFoundError{ID: flowTxID}
+		}
+
+		return nil, &StorageError{err}
+	}
+
+	return result.StateDelta, nil
+}
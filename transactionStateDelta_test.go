@@ -0,0 +1,137 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package emulator_test
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emulator "github.com/onflow/flow-emulator"
+	convert "github.com/onflow/flow-emulator/convert/sdk"
+	"github.com/onflow/flow-emulator/types"
+)
+
+func TestTransactionStateDelta(t *testing.T) {
+
+	t.Parallel()
+
+	executeTransaction := func(t *testing.T, b *emulator.Blockchain) *types.TransactionResult {
+		tx := flow.NewTransaction().
+			SetScript([]byte(`
+				transaction {
+					prepare(signer: AuthAccount) {
+						signer.save("Hello, World!", to: /storage/greeting)
+					}
+				}
+			`)).
+			SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+			SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+			SetPayer(b.ServiceKey().Address).
+			AddAuthorizer(b.ServiceKey().Address)
+
+		err := tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+		require.NoError(t, err)
+
+		err = b.AddTransaction(*tx)
+		require.NoError(t, err)
+
+		result, err := b.ExecuteNextTransaction()
+		require.NoError(t, err)
+		assertTransactionSucceeded(t, result)
+
+		return result
+	}
+
+	t.Run("should record state delta", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+			emulator.WithStateDeltas(true),
+		)
+		require.NoError(t, err)
+
+		result := executeTransaction(t, b)
+		require.NotNil(t, result.StateDelta)
+
+		assert.NotEmpty(t, result.StateDelta.Registers)
+		for _, register := range result.StateDelta.Registers {
+			assert.NotEqual(t, register.Before, register.After)
+		}
+
+		serviceAddress := convert.SDKAddressToFlow(b.ServiceKey().Address)
+
+		var account *types.AccountStorageDelta
+		for i, accountDelta := range result.StateDelta.Accounts {
+			if accountDelta.Address == serviceAddress {
+				account = &result.StateDelta.Accounts[i]
+			}
+		}
+
+		require.NotNil(t, account)
+		assert.Greater(t, account.BytesAdded, uint64(0))
+		assert.Greater(t, account.StorageUsedAfter, account.StorageUsedBefore)
+
+		stateDelta, err := b.GetTransactionStateDelta(result.TransactionID)
+		require.NoError(t, err)
+		assert.Equal(t, result.StateDelta, stateDelta)
+
+		_, err = b.CommitBlock()
+		require.NoError(t, err)
+
+		stateDelta, err = b.GetTransactionStateDelta(result.TransactionID)
+		require.NoError(t, err)
+		assert.Equal(t, result.StateDelta, stateDelta)
+	})
+
+	t.Run("should not record state delta by default", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain(
+			emulator.WithStorageLimitEnabled(false),
+		)
+		require.NoError(t, err)
+
+		result := executeTransaction(t, b)
+		assert.Nil(t, result.StateDelta)
+
+		_, err = b.CommitBlock()
+		require.NoError(t, err)
+
+		stateDelta, err := b.GetTransactionStateDelta(result.TransactionID)
+		require.NoError(t, err)
+		assert.Nil(t, stateDelta)
+	})
+
+	t.Run("should fail for unknown transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		b, err := emulator.NewBlockchain()
+		require.NoError(t, err)
+
+		_, err = b.GetTransactionStateDelta(flow.EmptyID)
+		assert.IsType(t, &emulator.TransactionNotFoundError{}, err)
+	})
+}
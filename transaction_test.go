@@ -21,6 +21,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	emulator "github.com/onflow/flow-emulator"
+	"github.com/onflow/flow-emulator/storage"
 	"github.com/onflow/flow-emulator/types"
 	"github.com/onflow/flow-emulator/utils/unittest"
 )
@@ -1296,6 +1297,146 @@ func TestGetTransactionResult(t *testing.T) {
 	assert.Equal(t, cadence.NewInt(2), event.Value.Fields[0])
 }
 
+func TestGetTransactionExecutionResult(t *testing.T) {
+
+	t.Parallel()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+	)
+	require.NoError(t, err)
+
+	tx := flow.NewTransaction().
+		SetScript([]byte(`
+			transaction {
+				prepare(signer: AuthAccount) {
+					log("Hello, World!")
+				}
+			}
+		`)).
+		SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+		SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+		SetPayer(b.ServiceKey().Address).
+		AddAuthorizer(b.ServiceKey().Address)
+
+	err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+	require.NoError(t, err)
+
+	err = b.AddTransaction(*tx)
+	require.NoError(t, err)
+
+	// the transaction has not been executed yet
+	_, err = b.GetTransactionExecutionResult(tx.ID())
+	assert.IsType(t, &emulator.TransactionNotFoundError{}, err)
+
+	executedResult, err := b.ExecuteNextTransaction()
+	require.NoError(t, err)
+	assertTransactionSucceeded(t, executedResult)
+
+	result, err := b.GetTransactionExecutionResult(tx.ID())
+	require.NoError(t, err)
+	assert.Equal(t, tx.ID(), result.TransactionID)
+	assert.Equal(t, []string{`"Hello, World!"`}, result.Logs)
+	assert.Equal(t, executedResult.ComputationUsed, result.ComputationUsed)
+
+	_, err = b.CommitBlock()
+	require.NoError(t, err)
+
+	result, err = b.GetTransactionExecutionResult(tx.ID())
+	require.NoError(t, err)
+	assert.Equal(t, tx.ID(), result.TransactionID)
+	assert.Equal(t, []string{`"Hello, World!"`}, result.Logs)
+	assert.Equal(t, executedResult.ComputationUsed, result.ComputationUsed)
+	assert.NoError(t, result.Error)
+	assert.Nil(t, result.Debug)
+}
+
+func TestGetTransactionsByAccount(t *testing.T) {
+
+	t.Parallel()
+
+	b, err := emulator.NewBlockchain(
+		emulator.WithStorageLimitEnabled(false),
+	)
+	require.NoError(t, err)
+
+	contract := `
+		pub contract Test {
+			pub event Ping()
+
+			pub fun ping() {
+				emit Ping()
+			}
+		}
+	`
+
+	address, err := b.CreateAccount(
+		[]*flow.AccountKey{b.ServiceKey().AccountKey()},
+		[]templates.Contract{{Name: "Test", Source: contract}},
+	)
+	require.NoError(t, err)
+
+	tx := flow.NewTransaction().
+		SetScript([]byte(fmt.Sprintf(`
+			import Test from 0x%s
+
+			transaction {
+				prepare(signer: AuthAccount) {}
+
+				execute {
+					Test.ping()
+				}
+			}
+		`, address.Hex()))).
+		SetGasLimit(flowgo.DefaultMaxTransactionGasLimit).
+		SetProposalKey(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().SequenceNumber).
+		SetPayer(b.ServiceKey().Address).
+		AddAuthorizer(b.ServiceKey().Address)
+
+	err = tx.SignEnvelope(b.ServiceKey().Address, b.ServiceKey().Index, b.ServiceKey().Signer())
+	require.NoError(t, err)
+
+	err = b.AddTransaction(*tx)
+	require.NoError(t, err)
+
+	_, results, err := b.ExecuteAndCommitBlock()
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assertTransactionSucceeded(t, results[0])
+
+	t.Run("should return transactions of event-emitting contract", func(t *testing.T) {
+		txs, err := b.GetTransactionsByAccount(address, nil, 0)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		assert.Equal(t, flowgo.Identifier(tx.ID()), txs[0].TransactionID)
+		assert.Equal(t, []storage.AccountRole{storage.AccountRoleEmitter}, txs[0].Roles)
+	})
+
+	t.Run("should page transactions of signer", func(t *testing.T) {
+		txs, err := b.GetTransactionsByAccount(b.ServiceKey().Address, nil, 1)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		assert.Equal(t, flowgo.Identifier(tx.ID()), txs[0].TransactionID)
+		assert.Subset(
+			t,
+			txs[0].Roles,
+			[]storage.AccountRole{
+				storage.AccountRoleProposer,
+				storage.AccountRolePayer,
+				storage.AccountRoleAuthorizer,
+			},
+		)
+
+		cursor := txs[0].Cursor()
+
+		// the account creation transaction precedes it
+		txs, err = b.GetTransactionsByAccount(b.ServiceKey().Address, &cursor, 1)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		assert.Less(t, txs[0].BlockHeight, cursor.BlockHeight)
+	})
+}
+
 const helloWorldContract = `
     pub contract HelloWorld {
 
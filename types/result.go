@@ -28,10 +28,14 @@ import (
 )
 
 type StorableTransactionResult struct {
-	ErrorCode    int
-	ErrorMessage string
-	Logs         []string
-	Events       []flowgo.Event
+	ErrorCode       int
+	ErrorMessage    string
+	Logs            []string
+	Events          []flowgo.Event
+	ComputationUsed uint64
+	Debug           *TransactionResultDebug `cbor:",omitempty"`
+	StateDelta      *TransactionStateDelta  `cbor:",omitempty"`
+	Trace           *TransactionTrace       `cbor:",omitempty"`
 }
 
 // A TransactionResult is the result of executing a transaction.
@@ -42,6 +46,14 @@ type TransactionResult struct {
 	Logs            []string
 	Events          []flow.Event
 	Debug           *TransactionResultDebug
+	// BlockID and BlockHeight identify the block that includes the transaction,
+	// and are empty if the transaction is not committed
+	BlockID     flow.Identifier
+	BlockHeight uint64
+	// StateDelta is the ledger changes of the transaction, nil unless state deltas are recorded
+	StateDelta *TransactionStateDelta
+	// Trace is the execution trace of the transaction, nil unless traces are recorded
+	Trace *TransactionTrace
 }
 
 // Succeeded returns true if the transaction executed without errors.
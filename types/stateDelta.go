@@ -0,0 +1,50 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package types
+
+import (
+	flowgo "github.com/onflow/flow-go/model/flow"
+)
+
+// A TransactionStateDelta describes the ledger changes made by a transaction.
+type TransactionStateDelta struct {
+	// Registers contains the registers written by the transaction, ordered by owner, controller and key.
+	Registers []RegisterDelta
+	// Accounts contains the storage changes of the accounts owning written registers, ordered by address.
+	Accounts []AccountStorageDelta
+}
+
+// A RegisterDelta is a register write of a transaction.
+//
+// The previous value of a created register and the new value of a deleted register are empty.
+type RegisterDelta struct {
+	ID     flowgo.RegisterID
+	Before flowgo.RegisterValue
+	After  flowgo.RegisterValue
+}
+
+// An AccountStorageDelta describes the storage changes of an account.
+//
+// The bytes added and removed are the sizes of the written registers, including their IDs.
+type AccountStorageDelta struct {
+	Address           flowgo.Address
+	BytesAdded        uint64
+	BytesRemoved      uint64
+	StorageUsedBefore uint64
+	StorageUsedAfter  uint64
+}
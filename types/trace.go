@@ -0,0 +1,68 @@
+/*
+ * Flow Emulator
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package types
+
+import (
+	"time"
+
+	flowgo "github.com/onflow/flow-go/model/flow"
+)
+
+// A TraceEntryKind is the kind of operation recorded in a transaction trace.
+type TraceEntryKind string
+
+const (
+	// TraceEntryFunction is the invocation of a Cadence function.
+	TraceEntryFunction TraceEntryKind = "function"
+	// TraceEntryEvent is the emission of an event.
+	TraceEntryEvent TraceEntryKind = "event"
+	// TraceEntryStorageRead is the read of a register.
+	TraceEntryStorageRead TraceEntryKind = "storage_read"
+	// TraceEntryStorageWrite is the write of a register.
+	TraceEntryStorageWrite TraceEntryKind = "storage_write"
+	// TraceEntryAccount is an access to an account, e.g. to its keys, contracts or balance.
+	TraceEntryAccount TraceEntryKind = "account"
+	// TraceEntryCapability is an access to the public or private capability domain of an account.
+	TraceEntryCapability TraceEntryKind = "capability"
+)
+
+// A TransactionTrace is the sequence of operations performed by the Cadence program of a transaction.
+type TransactionTrace struct {
+	// Entries contains the operations in the order they started.
+	Entries []TraceEntry
+}
+
+// A TraceEntry is an operation recorded in a transaction trace.
+//
+// Depth is the number of function invocations the operation is nested in.
+// Functions and account accesses have a name and a duration, events have a name
+// and their JSON-Cadence payload as value, and storage accesses have an address,
+// key and the size of the value read or written. The values of storage accesses
+// are only recorded if enabled.
+type TraceEntry struct {
+	Kind     TraceEntryKind
+	Depth    int
+	Name     string `cbor:",omitempty"`
+	Location string `cbor:",omitempty"`
+	Address  flowgo.Address
+	Key      string `cbor:",omitempty"`
+	Size     int    `cbor:",omitempty"`
+	Value    []byte `cbor:",omitempty"`
+	Start    time.Duration
+	Duration time.Duration `cbor:",omitempty"`
+}
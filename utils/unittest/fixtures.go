@@ -44,5 +44,10 @@ func StorableTransactionResultFixture() types.StorableTransactionResult {
 			eventA,
 			eventB,
 		},
+		ComputationUsed: 17,
+		Debug: &types.TransactionResultDebug{
+			Message: "bar",
+			Meta:    map[string]string{"payer": "01"},
+		},
 	}
 }